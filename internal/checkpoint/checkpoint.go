@@ -0,0 +1,375 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestName is the file inside a turn directory that lists the snapshotted files
+const manifestName = "manifest.json"
+
+// FileEntry records the state of a path before a turn first modified it
+type FileEntry struct {
+	Path    string      `json:"path"`
+	Existed bool        `json:"existed"`
+	IsDir   bool        `json:"is_dir,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	Link    string      `json:"link,omitempty"` // symlink target
+	Blob    string      `json:"blob,omitempty"` // file holding the previous content
+}
+
+// Turn is the checkpoint of one session turn
+type Turn struct {
+	Number    int         `json:"number"`
+	CreatedAt time.Time   `json:"created_at"`
+	Files     []FileEntry `json:"files"`
+}
+
+// Store keeps file snapshots grouped by session and turn
+type Store struct {
+	baseDir string
+	mutex   sync.Mutex
+}
+
+// active is the session turn that tool changes are currently recorded against
+var active struct {
+	sync.Mutex
+	store     *Store
+	sessionID string
+	turn      int
+}
+
+// NewStore creates a store under ~/.kiwi/checkpoints
+func NewStore() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return NewStoreAt(filepath.Join(homeDir, ".kiwi", "checkpoints")), nil
+}
+
+// NewStoreAt creates a store in the given directory
+func NewStoreAt(baseDir string) *Store {
+	return &Store{baseDir: baseDir}
+}
+
+// SetActive records all following snapshots against the given session turn
+func SetActive(store *Store, sessionID string, turn int) {
+	active.Lock()
+	defer active.Unlock()
+	active.store = store
+	active.sessionID = sessionID
+	active.turn = turn
+}
+
+// ClearActive stops recording snapshots
+func ClearActive() {
+	SetActive(nil, "", 0)
+}
+
+// Snapshot saves the current state of path to the active turn before it gets modified.
+// It does nothing outside of a session, for example in execute mode.
+func Snapshot(path string) error {
+	active.Lock()
+	store, sessionID, turn := active.store, active.sessionID, active.turn
+	active.Unlock()
+
+	if store == nil {
+		return nil
+	}
+	return store.Snapshot(sessionID, turn, path)
+}
+
+// Snapshot saves the current state of path, and everything below it for directories,
+// unless the turn already holds an earlier snapshot of it
+func (s *Store) Snapshot(sessionID string, turn int, path string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	turnDir := s.turnDir(sessionID, turn)
+	manifest, err := readManifest(turnDir)
+	if os.IsNotExist(err) {
+		manifest = &Turn{Number: turn, CreatedAt: time.Now()}
+	} else if err != nil {
+		return err
+	}
+
+	// Creating a path creates its missing parents too, so record the outermost one
+	for {
+		parent := filepath.Dir(absPath)
+		if parent == absPath {
+			break
+		}
+		if _, err := os.Lstat(parent); !os.IsNotExist(err) {
+			break
+		}
+		absPath = parent
+	}
+
+	seen := make(map[string]bool, len(manifest.Files))
+	for _, entry := range manifest.Files {
+		seen[entry.Path] = true
+		if !entry.Existed && strings.HasPrefix(absPath, entry.Path+string(filepath.Separator)) {
+			// Below a path created during this turn, undoing removes it anyway
+			return nil
+		}
+	}
+	if seen[absPath] {
+		return nil
+	}
+
+	if err := os.MkdirAll(turnDir, 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	record := func(p string) error {
+		if seen[p] {
+			return nil
+		}
+		seen[p] = true
+
+		entry, err := snapshotPath(p, turnDir, len(manifest.Files))
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, entry)
+		return nil
+	}
+
+	info, err := os.Lstat(absPath)
+	if err == nil && info.IsDir() {
+		err = filepath.WalkDir(absPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			return record(p)
+		})
+	} else {
+		err = record(absPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s: %w", path, err)
+	}
+
+	return writeManifest(turnDir, manifest)
+}
+
+// snapshotPath records a single path, copying file content into the turn directory
+func snapshotPath(path, turnDir string, index int) (FileEntry, error) {
+	entry := FileEntry{Path: path}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return entry, nil
+	}
+	if err != nil {
+		return entry, err
+	}
+
+	entry.Existed = true
+	entry.Mode = info.Mode()
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		entry.Link, err = os.Readlink(path)
+	case info.IsDir():
+		entry.IsDir = true
+	default:
+		entry.Blob = strconv.Itoa(index)
+		err = copyFile(path, filepath.Join(turnDir, entry.Blob))
+	}
+	return entry, err
+}
+
+// Turns returns the checkpoints of a session, oldest first
+func (s *Store) Turns(sessionID string) ([]Turn, error) {
+	entries, err := os.ReadDir(s.sessionDir(sessionID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+
+	var turns []Turn
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+		manifest, err := readManifest(filepath.Join(s.sessionDir(sessionID), entry.Name()))
+		if err != nil {
+			continue // Skip checkpoints that can't be loaded
+		}
+		turns = append(turns, *manifest)
+	}
+
+	sort.Slice(turns, func(i, j int) bool {
+		return turns[i].Number < turns[j].Number
+	})
+	return turns, nil
+}
+
+// Undo restores the files changed in the most recent turn that has a checkpoint
+func (s *Store) Undo(sessionID string) (*Turn, error) {
+	turns, err := s.Turns(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(turns) == 0 {
+		return nil, fmt.Errorf("nothing to undo, no file changes were recorded in this session")
+	}
+
+	last := turns[len(turns)-1]
+	if err := s.restore(sessionID, last); err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
+
+// Rewind restores the workspace to how it was at the end of the given turn by
+// undoing every later turn, newest first. Turn 0 is the start of the session.
+func (s *Store) Rewind(sessionID string, turn int) ([]Turn, error) {
+	turns, err := s.Turns(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []Turn
+	for i := len(turns) - 1; i >= 0 && turns[i].Number > turn; i-- {
+		if err := s.restore(sessionID, turns[i]); err != nil {
+			return undone, err
+		}
+		undone = append(undone, turns[i])
+	}
+	return undone, nil
+}
+
+// Delete removes all checkpoints of a session
+func (s *Store) Delete(sessionID string) error {
+	return os.RemoveAll(s.sessionDir(sessionID))
+}
+
+// restore puts back the snapshotted files of a turn and drops its checkpoint
+func (s *Store) restore(sessionID string, turn Turn) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	turnDir := s.turnDir(sessionID, turn.Number)
+
+	// Undo in reverse order so that directories are recreated after their contents were removed
+	for i := len(turn.Files) - 1; i >= 0; i-- {
+		entry := turn.Files[i]
+		if err := restoreEntry(entry, turnDir); err != nil {
+			return fmt.Errorf("failed to restore %s from turn %d: %w", entry.Path, turn.Number, err)
+		}
+	}
+
+	return os.RemoveAll(turnDir)
+}
+
+// restoreEntry puts a single path back into its recorded state
+func restoreEntry(entry FileEntry, turnDir string) error {
+	current, err := os.Lstat(entry.Path)
+	exists := err == nil
+
+	if !entry.Existed {
+		// The path was created during the turn
+		if exists {
+			return os.RemoveAll(entry.Path)
+		}
+		return nil
+	}
+
+	if entry.IsDir {
+		if exists && !current.IsDir() {
+			if err := os.Remove(entry.Path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(entry.Path, entry.Mode.Perm()); err != nil {
+			return err
+		}
+		return os.Chmod(entry.Path, entry.Mode.Perm())
+	}
+
+	if exists {
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+		return err
+	}
+
+	if entry.Link != "" {
+		return os.Symlink(entry.Link, entry.Path)
+	}
+	if err := copyFile(filepath.Join(turnDir, entry.Blob), entry.Path); err != nil {
+		return err
+	}
+	return os.Chmod(entry.Path, entry.Mode.Perm())
+}
+
+func (s *Store) sessionDir(sessionID string) string {
+	return filepath.Join(s.baseDir, sessionID)
+}
+
+func (s *Store) turnDir(sessionID string, turn int) string {
+	return filepath.Join(s.sessionDir(sessionID), strconv.Itoa(turn))
+}
+
+func readManifest(turnDir string) (*Turn, error) {
+	data, err := os.ReadFile(filepath.Join(turnDir, manifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var turn Turn
+	if err := json.Unmarshal(data, &turn); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	return &turn, nil
+}
+
+func writeManifest(turnDir string, turn *Turn) error {
+	data, err := json.MarshalIndent(turn, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	return os.WriteFile(filepath.Join(turnDir, manifestName), data, 0644)
+}
+
+// copyFile copies the content of a regular file
+func copyFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
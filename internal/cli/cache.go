@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/saurabh0719/kiwi/internal/httpcache"
+	"github.com/saurabh0719/kiwi/internal/util"
+	"github.com/spf13/cobra"
+)
+
+func initCacheCmd() {
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the HTTP cache of fetched pages",
+		Long: `Manage the on-disk HTTP cache (~/.kiwi/cache) of pages fetched by the web search tool.
+
+Cached pages are reused while their Cache-Control or Expires headers say they are fresh,
+and revalidated with their ETag or Last-Modified date afterwards. Use --offline to serve
+pages from the cache only.
+
+Examples:
+  # Show the number and size of cached pages
+  kiwi cache
+  kiwi cache stats
+
+  # Remove all cached pages
+  kiwi cache clear`,
+		// Run stats command by default when no subcommand is specified
+		RunE: handleCacheStats,
+	}
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show cache statistics",
+		Long:  "Display the number, size and age of the cached pages",
+		Args:  cobra.NoArgs,
+		RunE:  handleCacheStats,
+	}
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Clear the cache",
+		Long:  "Remove all cached pages",
+		Args:  cobra.NoArgs,
+		RunE:  handleCacheClear,
+	}
+
+	cacheCmd.AddCommand(statsCmd)
+	cacheCmd.AddCommand(clearCmd)
+}
+
+func handleCacheStats(cmd *cobra.Command, args []string) error {
+	cache, err := httpcache.New()
+	if err != nil {
+		return err
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	util.InfoColor.Println("HTTP cache:")
+	fmt.Printf("  Location: %s\n", cache.Dir())
+	fmt.Printf("  Pages: %d (%d fresh, %d to revalidate)\n", stats.Entries, stats.Fresh, stats.Entries-stats.Fresh)
+	fmt.Printf("  Size: %s\n", formatBytes(stats.Bytes))
+	if stats.Entries > 0 {
+		fmt.Printf("  Oldest: %s\n", stats.Oldest.Format("2006-01-02 15:04"))
+		fmt.Printf("  Newest: %s\n", stats.Newest.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func handleCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := httpcache.New()
+	if err != nil {
+		return err
+	}
+
+	count, err := cache.Clear()
+	if err != nil {
+		return err
+	}
+
+	util.InfoColor.Printf("Removed %d cached pages\n", count)
+	return nil
+}
+
+// formatBytes formats a size in bytes for display
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,7 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/saurabh0719/kiwi/internal/config"
 	"github.com/spf13/cobra"
@@ -36,7 +39,14 @@ Examples:
   kiwi config set llm.safe_mode true
   kiwi config set ui.debug true
   kiwi config set ui.streaming true
-  kiwi config set ui.render_markdown true`,
+  kiwi config set ui.render_markdown true
+  kiwi config set tools.workspace.read_only ~/docs,/usr/share/doc
+  kiwi config set tools.search.backend searxng
+  kiwi config set tools.search.url https://searx.example.org
+  kiwi config set tools.http.secrets.github_token env:GITHUB_TOKEN
+  kiwi config set tools.network.deny internal.example.com,10.0.0.0/8
+  kiwi config set tools.cache.ttl 24h
+  kiwi config set tools.sqlite.allow_writes true`,
 		// Run list command by default when no subcommand is specified
 		RunE: handleConfigList,
 	}
@@ -105,6 +115,7 @@ func handleConfigList(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  ui.debug: %t\n", cfg.UI.Debug)
 	fmt.Printf("  ui.streaming: %t\n", cfg.UI.Streaming)
 	fmt.Printf("  ui.render_markdown: %t\n", cfg.UI.RenderMarkdown)
+	printToolsConfig(cfg)
 
 	return nil
 }
@@ -136,6 +147,42 @@ func handleConfigGet(cmd *cobra.Command, args []string) error {
 		fmt.Println(cfg.UI.Streaming)
 	case "ui.render_markdown":
 		fmt.Println(cfg.UI.RenderMarkdown)
+	case "tools.workspace.read_write":
+		fmt.Println(formatList(cfg.Tools.Workspace.ReadWrite, "<working directory>"))
+	case "tools.workspace.read_only":
+		fmt.Println(formatList(cfg.Tools.Workspace.ReadOnly, "<none>"))
+	case "tools.workspace.deny":
+		fmt.Println(formatList(cfg.Tools.Workspace.Deny, "<none>"))
+	case "tools.search.backend":
+		fmt.Println(cfg.Tools.Search.Backend)
+	case "tools.search.url":
+		fmt.Println(cfg.Tools.Search.URL)
+	case "tools.search.api_key":
+		if cfg.Tools.Search.APIKey == "" {
+			fmt.Println("<not set>")
+		} else {
+			fmt.Println(maskString(cfg.Tools.Search.APIKey))
+		}
+	case "tools.search.max_results":
+		fmt.Println(cfg.Tools.Search.MaxResults)
+	case "tools.network.allow":
+		fmt.Println(formatList(cfg.Tools.Network.Allow, "<any>"))
+	case "tools.network.deny":
+		fmt.Println(formatList(cfg.Tools.Network.Deny, "<none>"))
+	case "tools.network.allow_private":
+		fmt.Println(cfg.Tools.Network.AllowPrivate)
+	case "tools.network.max_response_mb":
+		fmt.Println(cfg.Tools.Network.MaxResponseMB)
+	case "tools.network.confirm_new_domains":
+		fmt.Println(cfg.Tools.Network.ConfirmNewDomains)
+	case "tools.cache.enabled":
+		fmt.Println(cfg.Tools.Cache.Enabled)
+	case "tools.cache.ttl":
+		fmt.Println(orDefault(cfg.Tools.Cache.TTL, "<from response headers>"))
+	case "tools.cache.offline":
+		fmt.Println(cfg.Tools.Cache.Offline)
+	case "tools.sqlite.allow_writes":
+		fmt.Println(cfg.Tools.SQLite.AllowWrites)
 	default:
 		// Check if it's an option
 		if strings.HasPrefix(key, "llm.options.") {
@@ -145,6 +192,13 @@ func handleConfigGet(cmd *cobra.Command, args []string) error {
 			} else {
 				fmt.Println("<not set>")
 			}
+		} else if strings.HasPrefix(key, "tools.http.secrets.") {
+			name := strings.ToLower(strings.TrimPrefix(key, "tools.http.secrets."))
+			if val, ok := cfg.Tools.HTTP.Secrets[name]; ok {
+				fmt.Println(formatSecret(val))
+			} else {
+				fmt.Println("<not set>")
+			}
 		} else {
 			return fmt.Errorf("unknown config key: %s", key)
 		}
@@ -215,6 +269,93 @@ func handleConfigSet(cmd *cobra.Command, args []string) error {
 		} else {
 			return fmt.Errorf("render_markdown must be 'true' or 'false'")
 		}
+	case "tools.workspace.read_write":
+		oldValue = formatList(cfg.Tools.Workspace.ReadWrite, "<working directory>")
+		cfg.Tools.Workspace.ReadWrite = parseList(value)
+	case "tools.workspace.read_only":
+		oldValue = formatList(cfg.Tools.Workspace.ReadOnly, "<none>")
+		cfg.Tools.Workspace.ReadOnly = parseList(value)
+	case "tools.workspace.deny":
+		oldValue = formatList(cfg.Tools.Workspace.Deny, "<none>")
+		cfg.Tools.Workspace.Deny = parseList(value)
+	case "tools.search.backend":
+		oldValue = cfg.Tools.Search.Backend
+		switch value {
+		case "duckduckgo", "searxng", "brave", "bing":
+		default:
+			return fmt.Errorf("search backend must be one of: duckduckgo, searxng, brave, bing")
+		}
+		cfg.Tools.Search.Backend = value
+	case "tools.search.url":
+		oldValue = cfg.Tools.Search.URL
+		cfg.Tools.Search.URL = value
+	case "tools.search.api_key":
+		oldValue = "<hidden>"
+		cfg.Tools.Search.APIKey = value
+		newValue = "<hidden>"
+	case "tools.search.max_results":
+		oldValue = cfg.Tools.Search.MaxResults
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("max_results must be a positive number")
+		}
+		cfg.Tools.Search.MaxResults = n
+	case "tools.network.allow":
+		oldValue = formatList(cfg.Tools.Network.Allow, "<any>")
+		cfg.Tools.Network.Allow = parseList(value)
+	case "tools.network.deny":
+		oldValue = formatList(cfg.Tools.Network.Deny, "<none>")
+		cfg.Tools.Network.Deny = parseList(value)
+	case "tools.network.allow_private":
+		oldValue = cfg.Tools.Network.AllowPrivate
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("allow_private must be 'true' or 'false'")
+		}
+		cfg.Tools.Network.AllowPrivate = b
+	case "tools.network.max_response_mb":
+		oldValue = cfg.Tools.Network.MaxResponseMB
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("max_response_mb must be a positive number")
+		}
+		cfg.Tools.Network.MaxResponseMB = n
+	case "tools.network.confirm_new_domains":
+		oldValue = cfg.Tools.Network.ConfirmNewDomains
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("confirm_new_domains must be 'true' or 'false'")
+		}
+		cfg.Tools.Network.ConfirmNewDomains = b
+	case "tools.cache.enabled":
+		oldValue = cfg.Tools.Cache.Enabled
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("enabled must be 'true' or 'false'")
+		}
+		cfg.Tools.Cache.Enabled = b
+	case "tools.cache.ttl":
+		oldValue = orDefault(cfg.Tools.Cache.TTL, "<from response headers>")
+		if value != "" {
+			if ttl, err := time.ParseDuration(value); err != nil || ttl < 0 {
+				return fmt.Errorf("ttl must be a duration like 30m or 24h, or empty to use the response headers")
+			}
+		}
+		cfg.Tools.Cache.TTL = value
+	case "tools.cache.offline":
+		oldValue = cfg.Tools.Cache.Offline
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("offline must be 'true' or 'false'")
+		}
+		cfg.Tools.Cache.Offline = b
+	case "tools.sqlite.allow_writes":
+		oldValue = cfg.Tools.SQLite.AllowWrites
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("allow_writes must be 'true' or 'false'")
+		}
+		cfg.Tools.SQLite.AllowWrites = b
 	default:
 		// Check if it's an option
 		if strings.HasPrefix(key, "llm.options.") {
@@ -224,6 +365,22 @@ func handleConfigSet(cmd *cobra.Command, args []string) error {
 			}
 			oldValue = cfg.LLM.Options[optKey]
 			cfg.LLM.Options[optKey] = value
+		} else if strings.HasPrefix(key, "tools.http.secrets.") {
+			// Viper lowercases keys, so secret names are stored lowercased
+			name := strings.ToLower(strings.TrimPrefix(key, "tools.http.secrets."))
+			if name == "" || strings.Contains(name, ".") {
+				return fmt.Errorf("secret names can't be empty or contain dots")
+			}
+			if cfg.Tools.HTTP.Secrets == nil {
+				cfg.Tools.HTTP.Secrets = make(map[string]string)
+			}
+			oldValue = "<hidden>"
+			newValue = formatSecret(value)
+			if value == "" {
+				delete(cfg.Tools.HTTP.Secrets, name)
+			} else {
+				cfg.Tools.HTTP.Secrets[name] = value
+			}
 		} else {
 			return fmt.Errorf("unknown config key: %s", key)
 		}
@@ -268,10 +425,80 @@ func handleConfigSet(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  ui.debug: %t\n", updatedCfg.UI.Debug)
 	fmt.Printf("  ui.streaming: %t\n", updatedCfg.UI.Streaming)
 	fmt.Printf("  ui.render_markdown: %t\n", updatedCfg.UI.RenderMarkdown)
+	printToolsConfig(updatedCfg)
 
 	return nil
 }
 
+// printToolsConfig displays the settings of the built-in tools
+func printToolsConfig(cfg *config.Config) {
+	fmt.Printf("  tools.workspace.read_write: %s\n", formatList(cfg.Tools.Workspace.ReadWrite, "<working directory>"))
+	fmt.Printf("  tools.workspace.read_only: %s\n", formatList(cfg.Tools.Workspace.ReadOnly, "<none>"))
+	fmt.Printf("  tools.workspace.deny: %s\n", formatList(cfg.Tools.Workspace.Deny, "<none>"))
+	fmt.Printf("  tools.search.backend: %s\n", cfg.Tools.Search.Backend)
+	if cfg.Tools.Search.URL != "" {
+		fmt.Printf("  tools.search.url: %s\n", cfg.Tools.Search.URL)
+	}
+	if cfg.Tools.Search.APIKey != "" {
+		fmt.Printf("  tools.search.api_key: %s\n", maskString(cfg.Tools.Search.APIKey))
+	}
+	fmt.Printf("  tools.search.max_results: %d\n", cfg.Tools.Search.MaxResults)
+	fmt.Printf("  tools.network.allow: %s\n", formatList(cfg.Tools.Network.Allow, "<any>"))
+	fmt.Printf("  tools.network.deny: %s\n", formatList(cfg.Tools.Network.Deny, "<none>"))
+	fmt.Printf("  tools.network.allow_private: %t\n", cfg.Tools.Network.AllowPrivate)
+	fmt.Printf("  tools.network.max_response_mb: %d\n", cfg.Tools.Network.MaxResponseMB)
+	fmt.Printf("  tools.network.confirm_new_domains: %t\n", cfg.Tools.Network.ConfirmNewDomains)
+	fmt.Printf("  tools.cache.enabled: %t\n", cfg.Tools.Cache.Enabled)
+	fmt.Printf("  tools.cache.ttl: %s\n", orDefault(cfg.Tools.Cache.TTL, "<from response headers>"))
+	fmt.Printf("  tools.cache.offline: %t\n", cfg.Tools.Cache.Offline)
+	fmt.Printf("  tools.sqlite.allow_writes: %t\n", cfg.Tools.SQLite.AllowWrites)
+	if len(cfg.Tools.HTTP.Secrets) > 0 {
+		fmt.Println("  tools.http.secrets:")
+		names := make([]string, 0, len(cfg.Tools.HTTP.Secrets))
+		for name := range cfg.Tools.HTTP.Secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("    %s: %s\n", name, formatSecret(cfg.Tools.HTTP.Secrets[name]))
+		}
+	}
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// formatSecret masks a secret value, environment variable references are shown as is
+func formatSecret(value string) string {
+	if strings.HasPrefix(value, "env:") || value == "" {
+		return value
+	}
+	return maskString(value)
+}
+
+// formatList joins a list setting for display
+func formatList(values []string, empty string) string {
+	if len(values) == 0 {
+		return empty
+	}
+	return strings.Join(values, ",")
+}
+
+// parseList splits a comma separated list setting, an empty value clears the list
+func parseList(value string) []string {
+	values := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
 // Mask a string (like an API key) for display
 func maskString(input string) string {
 	if len(input) <= 8 {
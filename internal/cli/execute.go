@@ -9,6 +9,7 @@ import (
 	"github.com/saurabh0719/kiwi/internal/config"
 	"github.com/saurabh0719/kiwi/internal/llm"
 	"github.com/saurabh0719/kiwi/internal/llm/core"
+	"github.com/saurabh0719/kiwi/internal/memory"
 	"github.com/saurabh0719/kiwi/internal/tools"
 	"github.com/saurabh0719/kiwi/internal/util"
 	"github.com/spf13/cobra"
@@ -22,7 +23,7 @@ func handleExecute(cmd *cobra.Command, prompt string) error {
 	}
 
 	toolRegistry := tools.NewRegistry()
-	tools.RegisterStandardTools(toolRegistry)
+	tools.RegisterStandardTools(toolRegistry, cfg)
 
 	adapter, err := llm.NewAdapter(cfg.LLM.Provider, cfg.LLM.Model, cfg.LLM.APIKey, toolRegistry)
 	if err != nil {
@@ -67,6 +68,11 @@ Remember that users in execute mode typically want quick, actionable information
 		},
 	}
 
+	// Facts saved in earlier sessions apply to one-off prompts too
+	if memories := memory.SystemPrompt(); memories != "" {
+		messages[0].Content += "\n\n" + memories
+	}
+
 	// Get the global spinner manager
 	spinnerManager := util.GetGlobalSpinnerManager()
 
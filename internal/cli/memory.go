@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/memory"
+	"github.com/saurabh0719/kiwi/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	memoryGlobal bool // Use global memories instead of those of the current project
+	memoryAll    bool // List or delete the memories of every project
+)
+
+func initMemoryCmd() {
+	memoryCmd = &cobra.Command{
+		Use:   "memory",
+		Short: "Manage the facts kiwi remembers across sessions",
+		Long: `Manage the memories (~/.kiwi/memory) saved with the memory tool.
+
+Memories are short facts like project conventions and preferences. They belong to the
+current project, keyed by the root of its git repository, or are global. The memories
+of the current project and the global ones are added to the system prompt of every new
+session.
+
+Examples:
+  # List the memories of the current project and the global ones
+  kiwi memory
+  kiwi memory list
+  kiwi memory list --all
+
+  # Remember a fact for the current project, or everywhere
+  kiwi memory add "we use make lint, not golangci-lint directly"
+  kiwi memory add --global "prefer table-driven tests"
+
+  # Find and forget memories
+  kiwi memory search lint
+  kiwi memory delete 1a2b3c4d
+  kiwi memory delete --all 5e6f7a8b
+
+  # Forget every memory of the current project
+  kiwi memory clear`,
+		// Run list command by default when no subcommand is specified
+		RunE: handleMemoryList,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List memories",
+		Long:  "List the memories of the current project and the global ones",
+		Args:  cobra.NoArgs,
+		RunE:  handleMemoryList,
+	}
+	listCmd.Flags().BoolVar(&memoryAll, "all", false, "List the memories of every project")
+
+	addCmd := &cobra.Command{
+		Use:   "add [fact]",
+		Short: "Remember a fact",
+		Long:  "Save a fact for the current project, or a global one with --global",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  handleMemoryAdd,
+	}
+	addCmd.Flags().BoolVarP(&memoryGlobal, "global", "g", false, "Remember the fact for every project")
+
+	searchCmd := &cobra.Command{
+		Use:   "search [words]",
+		Short: "Search memories",
+		Long:  "Find the memories of the current project and the global ones containing any of the words",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  handleMemorySearch,
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id...]",
+		Short: "Forget memories",
+		Long:  "Delete memories of the current project or global ones by ID, as shown by kiwi memory list, or of any project with --all",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  handleMemoryDelete,
+	}
+	deleteCmd.Flags().BoolVar(&memoryAll, "all", false, "Delete memories of any project")
+
+	clearCmd := &cobra.Command{
+		Use:   "clear",
+		Short: "Forget all memories of the current project",
+		Long:  "Delete every memory of the current project, or the global ones with --global",
+		Args:  cobra.NoArgs,
+		RunE:  handleMemoryClear,
+	}
+	clearCmd.Flags().BoolVarP(&memoryGlobal, "global", "g", false, "Delete the global memories instead")
+
+	memoryCmd.AddCommand(listCmd)
+	memoryCmd.AddCommand(addCmd)
+	memoryCmd.AddCommand(searchCmd)
+	memoryCmd.AddCommand(deleteCmd)
+	memoryCmd.AddCommand(clearCmd)
+}
+
+func handleMemoryList(cmd *cobra.Command, args []string) error {
+	store, err := memory.New()
+	if err != nil {
+		return err
+	}
+
+	projects := []string{memory.CurrentProject()}
+	if memoryAll {
+		if projects, err = store.Projects(); err != nil {
+			return err
+		}
+	}
+	// Global memories come last, like in the system prompt
+	projects = append(projects, "")
+
+	total := 0
+	for _, project := range projects {
+		memories, err := store.List(project)
+		if err != nil {
+			return err
+		}
+		if len(memories) == 0 && memoryAll {
+			continue
+		}
+		if project == "" {
+			util.InfoColor.Println("Global memories:")
+		} else {
+			util.InfoColor.Printf("Memories of %s:\n", project)
+		}
+		printMemories(memories)
+		total += len(memories)
+	}
+	if total == 0 {
+		fmt.Println("Save facts with: kiwi memory add \"...\", or ask kiwi to remember them")
+	}
+	return nil
+}
+
+func handleMemoryAdd(cmd *cobra.Command, args []string) error {
+	store, err := memory.New()
+	if err != nil {
+		return err
+	}
+
+	project := memory.CurrentProject()
+	if memoryGlobal {
+		project = ""
+	}
+	m, err := store.Add(project, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	if memoryGlobal {
+		util.InfoColor.Printf("Saved global memory [%s]\n", m.ID)
+	} else {
+		util.InfoColor.Printf("Saved memory [%s] for %s\n", m.ID, project)
+	}
+	return nil
+}
+
+func handleMemorySearch(cmd *cobra.Command, args []string) error {
+	store, err := memory.New()
+	if err != nil {
+		return err
+	}
+
+	memories, err := store.Search(memory.CurrentProject(), strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		fmt.Println("No matching memories")
+		return nil
+	}
+	for _, m := range memories {
+		fmt.Printf("  [%s] %s (%s)\n", m.ID, m.Content, m.Scope())
+	}
+	return nil
+}
+
+func handleMemoryDelete(cmd *cobra.Command, args []string) error {
+	store, err := memory.New()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range args {
+		var m memory.Memory
+		if memoryAll {
+			m, err = store.DeleteAny(id)
+		} else {
+			m, err = store.Delete(memory.CurrentProject(), id)
+		}
+		if err != nil {
+			return err
+		}
+		util.InfoColor.Printf("Deleted memory [%s]: %s\n", m.ID, m.Content)
+	}
+	return nil
+}
+
+func handleMemoryClear(cmd *cobra.Command, args []string) error {
+	store, err := memory.New()
+	if err != nil {
+		return err
+	}
+
+	project := memory.CurrentProject()
+	if memoryGlobal {
+		project = ""
+	}
+	count, err := store.Clear(project)
+	if err != nil {
+		return err
+	}
+
+	if memoryGlobal {
+		util.InfoColor.Printf("Removed %d global memories\n", count)
+	} else {
+		util.InfoColor.Printf("Removed %d memories of %s\n", count, project)
+	}
+	return nil
+}
+
+// printMemories prints memories with their IDs and dates
+func printMemories(memories []memory.Memory) {
+	if len(memories) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	for _, m := range memories {
+		fmt.Printf("  [%s] %s (%s)\n", m.ID, m.Content, m.CreatedAt.Format("2006-01-02"))
+	}
+}
@@ -16,10 +16,13 @@ var (
 	streaming      bool   // Streaming mode flag
 	configPath     string // Path to config file
 	renderMarkdown bool   // Markdown rendering flag
+	offline        bool   // Serve web requests from the cache only
 
 	// Command declarations
 	assistantCmd *cobra.Command
 	configCmd    *cobra.Command
+	cacheCmd     *cobra.Command
+	memoryCmd    *cobra.Command
 	// sessionsCmd is already declared in sessions.go
 
 	// Root command declaration
@@ -42,6 +45,8 @@ func init() {
 	initSessionsCmd()
 	initAssistantCmd()
 	initConfigCmd()
+	initCacheCmd()
+	initMemoryCmd()
 
 	// Initialize root command
 	rootCmd = &cobra.Command{
@@ -69,6 +74,16 @@ Examples:
   kiwi -s -o 1234567       # Continue a session by ID
   kiwi -s -d 1234567       # Delete a session by ID
   kiwi -s -C                    # Clear all sessions
+  kiwi -s --rewind 1234567 2    # Undo all file changes made after turn 2
+
+  # HTTP cache of fetched pages
+  kiwi cache stats
+  kiwi cache clear
+  kiwi --offline summarize https://go.dev/doc/effective_go
+
+  # Facts remembered across sessions
+  kiwi memory
+  kiwi memory add "we use make lint, not golangci-lint directly"
 
   # Configuration
   kiwi -c
@@ -111,6 +126,10 @@ Configuration:
 					return handleSessionsDelete(cmd, args)
 				}
 				return fmt.Errorf("session ID required with -d flag")
+			case sessionsFlag && checkpointsFlag:
+				return handleSessionsCheckpoints(cmd, args)
+			case sessionsFlag && rewindFlag:
+				return handleSessionsRewind(cmd, args)
 			case sessionsFlag && newFlag:
 				// Handle new session directly with any provided name
 				if len(args) > 0 {
@@ -145,6 +164,7 @@ Configuration:
 	rootCmd.PersistentFlags().BoolVarP(&streaming, "streaming", "S", true, "Enable streaming mode for incremental response display")
 	rootCmd.PersistentFlags().StringVarP(&configPath, "config-path", "p", "", "Path to config file")
 	rootCmd.PersistentFlags().BoolVarP(&renderMarkdown, "render-markdown", "r", false, "Enable Markdown rendering for output")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Serve web pages from the HTTP cache only, without using the network")
 
 	// Shorthand command flags
 	rootCmd.Flags().BoolVarP(&assistantFlag, "assistant", "a", false, "Start a new assistant session (interactive chat)")
@@ -156,6 +176,8 @@ Configuration:
 	rootCmd.Flags().BoolVarP(&continueFlag, "continue", "o", false, "Continue a session (requires ID as argument)")
 	rootCmd.Flags().BoolVarP(&deleteFlag, "delete", "d", false, "Delete a session (requires ID as argument)")
 	rootCmd.Flags().BoolVarP(&newFlag, "new", "n", false, "Create a new session (requires name as argument)")
+	rootCmd.Flags().BoolVar(&checkpointsFlag, "checkpoints", false, "List the files each turn of a session changed (requires ID as argument)")
+	rootCmd.Flags().BoolVar(&rewindFlag, "rewind", false, "Restore files to the end of a turn (requires ID and turn as arguments)")
 	rootCmd.Flags().StringVar(&sessionID, "id", "", "Session ID (alternative to providing as argument)")
 
 	// Config flags
@@ -165,6 +187,8 @@ Configuration:
 	// Add commands to root
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(memoryCmd)
 }
 
 func Execute() error {
@@ -14,10 +14,12 @@ import (
 
 var (
 	// Session command flags
-	listFlag     bool // -l flag for listing sessions
-	newFlag      bool // -n flag for creating a new session
-	continueFlag bool // -o flag for continuing a session
-	deleteFlag   bool // -d flag for deleting a session
+	listFlag        bool // -l flag for listing sessions
+	newFlag         bool // -n flag for creating a new session
+	continueFlag    bool // -o flag for continuing a session
+	deleteFlag      bool // -d flag for deleting a session
+	rewindFlag      bool // --rewind flag for restoring files to an earlier turn
+	checkpointsFlag bool // --checkpoints flag for listing the files each turn changed
 	// clearFlag is now defined in root.go
 	sessionName string // session name for new sessions
 	sessionID   string // session ID for actions like continue, delete
@@ -53,6 +55,8 @@ Available flags:
   -o, --continue    Continue a session (provide ID as argument)
   -d, --delete      Delete a session (provide ID as argument)
   -C, --clear       Clear all sessions
+      --checkpoints List the files each turn of a session changed (provide ID as argument)
+      --rewind      Restore files to how they were at the end of a turn (provide ID and turn)
 
 Examples:
   kiwi -s            # List all sessions
@@ -60,7 +64,9 @@ Examples:
   kiwi -s -n my_project    # Create a new named session
   kiwi -s -o 1234567       # Continue a session by ID
   kiwi -s -d 1234567       # Delete a session by ID
-  kiwi -s -C                    # Clear all sessions`,
+  kiwi -s -C                    # Clear all sessions
+  kiwi -s --checkpoints 1234567 # List the files each turn changed
+  kiwi -s --rewind 1234567 2    # Undo all file changes made after turn 2`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Check flags in priority order
 			switch {
@@ -89,6 +95,10 @@ Examples:
 				return fmt.Errorf("session ID required with -d flag (either as argument or with --id)")
 			case clearFlag:
 				return handleSessionsClear(cmd, args)
+			case checkpointsFlag:
+				return handleSessionsCheckpoints(cmd, args)
+			case rewindFlag:
+				return handleSessionsRewind(cmd, args)
 			default:
 				// Default behavior is to list sessions
 				return handleSessionsList(cmd, args)
@@ -102,6 +112,8 @@ Examples:
 	sessionsCmd.Flags().BoolVarP(&continueFlag, "continue", "o", false, "Continue a session")
 	sessionsCmd.Flags().BoolVarP(&deleteFlag, "delete", "d", false, "Delete a session")
 	sessionsCmd.Flags().BoolVarP(&clearFlag, "clear", "C", false, "Clear all sessions")
+	sessionsCmd.Flags().BoolVar(&checkpointsFlag, "checkpoints", false, "List the files each turn of a session changed")
+	sessionsCmd.Flags().BoolVar(&rewindFlag, "rewind", false, "Restore files to the end of a turn (requires ID and turn)")
 
 	// Parameters for flags
 	sessionsCmd.Flags().StringVar(&sessionName, "name", "", "Name for the new session (used with -n)")
@@ -200,6 +212,10 @@ func handleSessionsList(cmd *cobra.Command, args []string) error {
 	fmt.Printf("New:      ")
 	util.HighlightColor.Printf("kiwi -s -n <name>\n")
 
+	util.CommandColor.Printf("  • ")
+	fmt.Printf("Rewind:   ")
+	util.HighlightColor.Printf("kiwi -s --rewind <id> <turn>\n")
+
 	fmt.Println()
 
 	return nil
@@ -238,6 +254,33 @@ func handleSessionsDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func handleSessionsCheckpoints(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && sessionID != "" {
+		args = []string{sessionID}
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("session ID required")
+	}
+
+	return session.PrintCheckpoints(getFullSessionID(args[0]))
+}
+
+func handleSessionsRewind(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 && sessionID != "" {
+		args = []string{sessionID, args[0]}
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("session ID and turn required, e.g. kiwi -s --rewind <id> <turn>")
+	}
+
+	turn, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("turn must be a number: %s", args[1])
+	}
+
+	return session.RewindSession(getFullSessionID(args[0]), turn)
+}
+
 func handleSessionsClear(cmd *cobra.Command, args []string) error {
 	sessionMgr, err := session.NewManager()
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -27,10 +28,62 @@ type UIConfig struct {
 	RenderMarkdown     bool   `mapstructure:"render_markdown"`
 }
 
+// WorkspaceConfig controls which paths tools may read and write
+type WorkspaceConfig struct {
+	ReadWrite []string `mapstructure:"read_write"`
+	ReadOnly  []string `mapstructure:"read_only"`
+	Deny      []string `mapstructure:"deny"`
+}
+
+// SearchConfig selects the backend of the web search tool
+type SearchConfig struct {
+	Backend    string `mapstructure:"backend"`
+	URL        string `mapstructure:"url"`
+	APIKey     string `mapstructure:"api_key"`
+	MaxResults int    `mapstructure:"max_results"`
+}
+
+// HTTPConfig holds the secrets that the HTTP tool can reference as {{secret:name}}
+type HTTPConfig struct {
+	Secrets map[string]string `mapstructure:"secrets"`
+}
+
+// NetworkConfig controls which hosts the network tools may connect to
+type NetworkConfig struct {
+	Allow             []string `mapstructure:"allow"`
+	Deny              []string `mapstructure:"deny"`
+	AllowPrivate      bool     `mapstructure:"allow_private"`
+	MaxResponseMB     int      `mapstructure:"max_response_mb"`
+	ConfirmNewDomains bool     `mapstructure:"confirm_new_domains"`
+}
+
+// CacheConfig controls the on-disk HTTP cache of the web search tool
+type CacheConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	TTL     string `mapstructure:"ttl"`
+	Offline bool   `mapstructure:"offline"`
+}
+
+// SQLiteConfig controls whether the SQLite tool may change databases
+type SQLiteConfig struct {
+	AllowWrites bool `mapstructure:"allow_writes"`
+}
+
+// ToolsConfig represents settings for the built-in tools
+type ToolsConfig struct {
+	Workspace WorkspaceConfig `mapstructure:"workspace"`
+	Search    SearchConfig    `mapstructure:"search"`
+	HTTP      HTTPConfig      `mapstructure:"http"`
+	Network   NetworkConfig   `mapstructure:"network"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	SQLite    SQLiteConfig    `mapstructure:"sqlite"`
+}
+
 // Config represents the overall application configuration
 type Config struct {
-	LLM LLMConfig `mapstructure:"llm"`
-	UI  UIConfig  `mapstructure:"ui"`
+	LLM   LLMConfig   `mapstructure:"llm"`
+	UI    UIConfig    `mapstructure:"ui"`
+	Tools ToolsConfig `mapstructure:"tools"`
 }
 
 func getConfigDir() (string, error) {
@@ -87,6 +140,24 @@ func Load(rootCmd *cobra.Command) (*Config, error) {
 	v.SetDefault("ui.theme", "default")
 	v.SetDefault("ui.render_markdown", false)
 
+	// Set tool defaults, an empty read_write list means the working directory
+	v.SetDefault("tools.workspace.read_write", []string{})
+	v.SetDefault("tools.workspace.read_only", []string{})
+	v.SetDefault("tools.workspace.deny", workspace.DefaultDeny)
+	v.SetDefault("tools.search.backend", "duckduckgo")
+	v.SetDefault("tools.search.url", "")
+	v.SetDefault("tools.search.max_results", 8)
+	v.SetDefault("tools.http.secrets", map[string]string{})
+	v.SetDefault("tools.network.allow", []string{})
+	v.SetDefault("tools.network.deny", []string{})
+	v.SetDefault("tools.network.allow_private", false)
+	v.SetDefault("tools.network.max_response_mb", 10)
+	v.SetDefault("tools.network.confirm_new_domains", true)
+	v.SetDefault("tools.cache.enabled", true)
+	v.SetDefault("tools.cache.ttl", "")
+	v.SetDefault("tools.cache.offline", false)
+	v.SetDefault("tools.sqlite.allow_writes", false)
+
 	// Create config directory if it doesn't exist
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
@@ -121,6 +192,9 @@ func Load(rootCmd *cobra.Command) (*Config, error) {
 	if err := v.BindPFlag("ui.render_markdown", rootCmd.Flags().Lookup("render-markdown")); err != nil {
 		return nil, fmt.Errorf("failed to bind render-markdown flag: %w", err)
 	}
+	if err := v.BindPFlag("tools.cache.offline", rootCmd.Flags().Lookup("offline")); err != nil {
+		return nil, fmt.Errorf("failed to bind offline flag: %w", err)
+	}
 
 	var config Config
 	if err := v.Unmarshal(&config); err != nil {
@@ -157,6 +231,25 @@ func (c *Config) Save() error {
 	v.Set("ui.theme", c.UI.Theme)
 	v.Set("ui.render_markdown", c.UI.RenderMarkdown)
 
+	// Set tool values
+	v.Set("tools.workspace.read_write", c.Tools.Workspace.ReadWrite)
+	v.Set("tools.workspace.read_only", c.Tools.Workspace.ReadOnly)
+	v.Set("tools.workspace.deny", c.Tools.Workspace.Deny)
+	v.Set("tools.search.backend", c.Tools.Search.Backend)
+	v.Set("tools.search.url", c.Tools.Search.URL)
+	v.Set("tools.search.api_key", c.Tools.Search.APIKey)
+	v.Set("tools.search.max_results", c.Tools.Search.MaxResults)
+	v.Set("tools.http.secrets", c.Tools.HTTP.Secrets)
+	v.Set("tools.network.allow", c.Tools.Network.Allow)
+	v.Set("tools.network.deny", c.Tools.Network.Deny)
+	v.Set("tools.network.allow_private", c.Tools.Network.AllowPrivate)
+	v.Set("tools.network.max_response_mb", c.Tools.Network.MaxResponseMB)
+	v.Set("tools.network.confirm_new_domains", c.Tools.Network.ConfirmNewDomains)
+	v.Set("tools.cache.enabled", c.Tools.Cache.Enabled)
+	v.Set("tools.cache.ttl", c.Tools.Cache.TTL)
+	v.Set("tools.cache.offline", c.Tools.Cache.Offline)
+	v.Set("tools.sqlite.allow_writes", c.Tools.SQLite.AllowWrites)
+
 	// Write to file
 	configPath := filepath.Join(configDir, "config.yaml")
 	return v.WriteConfigAs(configPath)
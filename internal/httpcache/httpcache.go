@@ -0,0 +1,383 @@
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
+)
+
+// maxHeuristicLifetime caps how long a response without explicit freshness
+// information is reused based on its Last-Modified date
+const maxHeuristicLifetime = 24 * time.Hour
+
+// cachedHeaders are the response headers kept with a cached body
+var cachedHeaders = []string{
+	"Content-Type", "Content-Language", "ETag", "Last-Modified", "Cache-Control", "Expires", "Date",
+}
+
+// Entry describes a cached response
+type Entry struct {
+	URL       string      `json:"url"`
+	Status    int         `json:"status"`
+	Header    http.Header `json:"header"`
+	StoredAt  time.Time   `json:"stored_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Size      int64       `json:"size"`
+}
+
+// Fresh reports whether the entry can be used without asking the server
+func (e *Entry) Fresh(now time.Time) bool {
+	return now.Before(e.ExpiresAt)
+}
+
+// Stats summarizes the contents of the cache
+type Stats struct {
+	Entries int
+	Fresh   int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Cache stores GET responses on disk, keyed by URL
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	offline bool
+	mutex   sync.Mutex
+}
+
+// New creates a cache under ~/.kiwi/cache
+func New() (*Cache, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return NewAt(filepath.Join(homeDir, ".kiwi", "cache")), nil
+}
+
+// NewAt creates a cache in the given directory
+func NewAt(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the directory the cache is stored in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// SetTTL makes every cached response fresh for ttl, whatever its headers say.
+// Responses marked no-store are still never cached. Zero honors the headers.
+func (c *Cache) SetTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+// SetOffline serves every request from the cache, stale or not, and fails
+// requests for anything that isn't cached instead of using the network
+func (c *Cache) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// Transport returns a round tripper that answers from the cache when it can and
+// sends everything else through base
+func (c *Cache) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{cache: c, base: base}
+}
+
+type transport struct {
+	cache *Cache
+	base  http.RoundTripper
+}
+
+// RoundTrip serves fresh responses from the cache, revalidates stale ones with
+// their ETag or Last-Modified date and stores cacheable responses
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Cached answers are subject to the network policy too, a host denied after it was
+	// cached must not be served from the cache
+	if err := egress.Current().CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	c := t.cache
+	cacheable := req.Method == http.MethodGet && req.Header.Get("Authorization") == "" && req.Header.Get("Range") == ""
+	if !cacheable {
+		if c.offline {
+			return nil, fmt.Errorf("offline mode: only GET requests can be served from the cache")
+		}
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry, body, found := c.load(key)
+
+	if c.offline {
+		if !found {
+			return nil, fmt.Errorf("offline mode: %s is not in the cache", req.URL.Redacted())
+		}
+		return entry.response(req, body, "offline"), nil
+	}
+
+	now := time.Now()
+	if found && entry.Fresh(now) {
+		return entry.response(req, body, "hit"), nil
+	}
+
+	outgoing := req
+	if found {
+		etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			outgoing = req.Clone(req.Context())
+			if etag != "" {
+				outgoing.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				outgoing.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && found {
+		resp.Body.Close()
+
+		// The server confirmed the cached copy, take its new freshness information
+		for _, name := range cachedHeaders {
+			if value := resp.Header.Get(name); value != "" {
+				entry.Header.Set(name, value)
+			}
+		}
+		entry.ExpiresAt = now.Add(c.lifetime(entry.Header, now))
+		if err := c.store(key, entry, nil); err != nil {
+			return nil, err
+		}
+		return entry.response(req, body, "revalidated"), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || noStore(resp.Header) {
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	stored := &Entry{
+		URL:       key,
+		Status:    resp.StatusCode,
+		Header:    http.Header{},
+		StoredAt:  now,
+		ExpiresAt: now.Add(c.lifetime(resp.Header, now)),
+		Size:      int64(len(data)),
+	}
+	for _, name := range cachedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			stored.Header.Set(name, value)
+		}
+	}
+	// A failure to write the cache shouldn't fail the request
+	c.store(key, stored, data)
+
+	resp.Header.Set("X-Kiwi-Cache", "miss")
+	return resp, nil
+}
+
+// lifetime returns how long a response stays fresh, from the TTL override or its headers
+func (c *Cache) lifetime(header http.Header, now time.Time) time.Duration {
+	if c.ttl > 0 {
+		return c.ttl
+	}
+
+	directives := cacheControl(header)
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date := now
+	if parsed, err := http.ParseTime(header.Get("Date")); err == nil {
+		date = parsed
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		parsed, err := http.ParseTime(expires)
+		if err != nil || parsed.Before(date) {
+			return 0
+		}
+		return parsed.Sub(date)
+	}
+
+	// Without explicit information, reuse a page for a tenth of the time since it last changed
+	if lastModified, err := http.ParseTime(header.Get("Last-Modified")); err == nil && lastModified.Before(date) {
+		return min(date.Sub(lastModified)/10, maxHeuristicLifetime)
+	}
+	return 0
+}
+
+// response rebuilds an HTTP response from a cached entry
+func (e *Entry) response(req *http.Request, body []byte, status string) *http.Response {
+	header := e.Header.Clone()
+	header.Set("X-Kiwi-Cache", status)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// Clear removes every cached response and returns how many there were
+func (c *Cache) Clear() (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		return 0, fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return len(entries), nil
+}
+
+// Stats counts the cached responses and their size
+func (c *Cache) Stats() (Stats, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var stats Stats
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	for _, path := range paths {
+		entry, err := readEntry(path)
+		if err != nil {
+			continue // Skip entries that can't be loaded
+		}
+		stats.Entries++
+		stats.Bytes += entry.Size
+		if entry.Fresh(now) {
+			stats.Fresh++
+		}
+		if stats.Oldest.IsZero() || entry.StoredAt.Before(stats.Oldest) {
+			stats.Oldest = entry.StoredAt
+		}
+		if entry.StoredAt.After(stats.Newest) {
+			stats.Newest = entry.StoredAt
+		}
+	}
+	return stats, nil
+}
+
+// load returns the cached entry and body for a URL
+func (c *Cache) load(key string) (*Entry, []byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	base := c.path(key)
+	entry, err := readEntry(base + ".json")
+	if err != nil || entry.URL != key {
+		return nil, nil, false
+	}
+	body, err := os.ReadFile(base + ".body")
+	if err != nil {
+		return nil, nil, false
+	}
+	return entry, body, true
+}
+
+// store writes an entry, and its body unless body is nil
+func (c *Cache) store(key string, entry *Entry, body []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	base := c.path(key)
+	if body != nil {
+		if err := os.WriteFile(base+".body", body, 0644); err != nil {
+			return fmt.Errorf("failed to write cache: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	return os.WriteFile(base+".json", data, 0644)
+}
+
+// path returns the location of a cache entry without extension
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// cacheControl parses the Cache-Control header into its directives
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+// noStore reports whether a response must not be cached
+func noStore(header http.Header) bool {
+	_, ok := cacheControl(header)["no-store"]
+	return ok
+}
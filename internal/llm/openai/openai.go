@@ -64,10 +64,14 @@ func (a *Adapter) prepareTools() []openaiapi.Tool {
 		required := schema["required"].([]string)
 
 		for name, param := range params {
-			properties[name] = map[string]interface{}{
+			property := map[string]interface{}{
 				"type":        param.Type,
 				"description": param.Description,
 			}
+			if param.Items != nil {
+				property["items"] = param.Items
+			}
+			properties[name] = property
 
 			if param.Required {
 				required = append(required, name)
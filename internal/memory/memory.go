@@ -0,0 +1,420 @@
+package memory
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxContentLength caps the length of a memory, they are meant to be short facts
+	MaxContentLength = 500
+
+	// MaxPerScope caps the memories of a project, or the global ones
+	MaxPerScope = 200
+
+	// maxPromptMemories and maxPromptLength bound what is added to the system prompt
+	maxPromptMemories = 50
+	maxPromptLength   = 6000
+)
+
+// Memory is a fact saved for later sessions
+type Memory struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Project is the root of the project the memory belongs to, empty for global ones
+	Project string `json:"-"`
+}
+
+// Scope returns "global" or the project the memory belongs to
+func (m Memory) Scope() string {
+	if m.Project == "" {
+		return "global"
+	}
+	return m.Project
+}
+
+// scopeFile is the file holding the memories of a project, or the global ones
+type scopeFile struct {
+	Project  string   `json:"project,omitempty"`
+	Memories []Memory `json:"memories"`
+}
+
+// Store keeps memories on disk, one file per project and one for global memories
+type Store struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// New creates a store under ~/.kiwi/memory
+func New() (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return NewAt(filepath.Join(homeDir, ".kiwi", "memory")), nil
+}
+
+// NewAt creates a store in the given directory
+func NewAt(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory the store is kept in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// ProjectRoot returns the project dir belongs to: the root of its git repository, or
+// dir itself outside of one
+func ProjectRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for current := absDir; ; current = filepath.Dir(current) {
+		// .git is a file in worktrees and submodules
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			return absDir
+		}
+	}
+}
+
+// CurrentProject returns the project of the working directory
+func CurrentProject() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return ProjectRoot(dir)
+}
+
+// Add saves a memory for a project, or a global one for an empty project. Saving a
+// fact that is already known returns the existing memory.
+func (s *Store) Add(project, content string) (Memory, error) {
+	content = strings.Join(strings.Fields(content), " ")
+	if content == "" {
+		return Memory{}, fmt.Errorf("memory content is empty")
+	}
+	if len(content) > MaxContentLength {
+		return Memory{}, fmt.Errorf("memory is %d characters long, keep it under %d", len(content), MaxContentLength)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := s.read(s.path(project))
+	if err != nil {
+		return Memory{}, err
+	}
+	for _, m := range file.Memories {
+		if strings.EqualFold(m.Content, content) {
+			return m, nil
+		}
+	}
+	if len(file.Memories) >= MaxPerScope {
+		return Memory{}, fmt.Errorf("%s already has %d memories, delete some first", scopeName(project), MaxPerScope)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Memory{}, err
+	}
+	m := Memory{ID: id, Content: content, CreatedAt: time.Now(), Project: project}
+	file.Project = project
+	file.Memories = append(file.Memories, m)
+	if err := s.write(s.path(project), file); err != nil {
+		return Memory{}, err
+	}
+	return m, nil
+}
+
+// List returns the memories of a project, or the global ones for an empty project,
+// newest first
+func (s *Store) List(project string) ([]Memory, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := s.read(s.path(project))
+	if err != nil {
+		return nil, err
+	}
+	return newestFirst(file.Memories), nil
+}
+
+// Relevant returns the memories of a project followed by the global ones
+func (s *Store) Relevant(project string) ([]Memory, error) {
+	var memories []Memory
+	if project != "" {
+		projectMemories, err := s.List(project)
+		if err != nil {
+			return nil, err
+		}
+		memories = projectMemories
+	}
+	global, err := s.List("")
+	if err != nil {
+		return nil, err
+	}
+	return append(memories, global...), nil
+}
+
+// Search returns the memories of a project and the global ones containing any word
+// of the query, those containing the most words first
+func (s *Store) Search(project, query string) ([]Memory, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	memories, err := s.Relevant(project)
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		memory Memory
+		score  int
+	}
+	var matches []scored
+	for _, m := range memories {
+		content := strings.ToLower(m.Content)
+		score := 0
+		for _, word := range words {
+			if strings.Contains(content, word) {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{m, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	result := make([]Memory, len(matches))
+	for i, match := range matches {
+		result[i] = match.memory
+	}
+	return result, nil
+}
+
+// Delete removes a memory by ID from the memories of a project or the global ones
+func (s *Store) Delete(project, id string) (Memory, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	paths := []string{s.path("")}
+	if project != "" {
+		paths = append(paths, s.path(project))
+	}
+	return s.deleteFrom(paths, id)
+}
+
+// DeleteAny removes a memory by ID, whatever project it belongs to
+func (s *Store) DeleteAny(id string) (Memory, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	paths, err := s.files()
+	if err != nil {
+		return Memory{}, err
+	}
+	return s.deleteFrom(paths, id)
+}
+
+// deleteFrom removes the memory with the given ID from the first of the files holding it
+func (s *Store) deleteFrom(paths []string, id string) (Memory, error) {
+	for _, path := range paths {
+		file, err := s.read(path)
+		if err != nil {
+			return Memory{}, err
+		}
+		for i, m := range file.Memories {
+			if m.ID != id {
+				continue
+			}
+			file.Memories = append(file.Memories[:i], file.Memories[i+1:]...)
+			if err := s.write(path, file); err != nil {
+				return Memory{}, err
+			}
+			return m, nil
+		}
+	}
+	return Memory{}, fmt.Errorf("no memory with ID %s", id)
+}
+
+// Clear removes the memories of a project, or the global ones for an empty project
+func (s *Store) Clear(project string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := s.path(project)
+	file, err := s.read(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove memories: %w", err)
+	}
+	return len(file.Memories), nil
+}
+
+// Projects returns the roots of the projects that have memories
+func (s *Store) Projects() ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	paths, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, path := range paths {
+		file, err := s.read(path)
+		if err != nil {
+			return nil, err
+		}
+		if file.Project != "" && len(file.Memories) > 0 {
+			projects = append(projects, file.Project)
+		}
+	}
+	sort.Strings(projects)
+	return projects, nil
+}
+
+// Prompt formats the memories of a project and the global ones for the system prompt,
+// or returns an empty string when there are none
+func (s *Store) Prompt(project string) string {
+	memories, err := s.Relevant(project)
+	if err != nil || len(memories) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Memories saved with the memory tool in earlier sessions. Follow them unless the user says otherwise, and keep them up to date with the memory tool:\n")
+	scope := ""
+	for i, m := range memories {
+		line := fmt.Sprintf("- [%s] %s\n", m.ID, m.Content)
+		if i == maxPromptMemories || b.Len()+len(line) > maxPromptLength {
+			b.WriteString(fmt.Sprintf("[%d more memories, search them with the memory tool]\n", len(memories)-i))
+			break
+		}
+		if m.Scope() != scope {
+			scope = m.Scope()
+			if m.Project == "" {
+				b.WriteString("\nGlobal:\n")
+			} else {
+				b.WriteString(fmt.Sprintf("\nProject %s:\n", m.Project))
+			}
+		}
+		b.WriteString(line)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// SystemPrompt returns the memories of the current project and the global ones for
+// the system prompt of a new session, or an empty string when there are none
+func SystemPrompt() string {
+	store, err := New()
+	if err != nil {
+		return ""
+	}
+	return store.Prompt(CurrentProject())
+}
+
+// path returns the file of a project, named after it so the directory stays readable
+func (s *Store) path(project string) string {
+	if project == "" {
+		return filepath.Join(s.dir, "global.json")
+	}
+	sum := sha256.Sum256([]byte(project))
+	name := fmt.Sprintf("%s-%s.json", filepath.Base(project), hex.EncodeToString(sum[:])[:12])
+	return filepath.Join(s.dir, "projects", name)
+}
+
+// files returns the paths of all memory files
+func (s *Store) files() ([]string, error) {
+	paths := []string{filepath.Join(s.dir, "global.json")}
+	projects, err := filepath.Glob(filepath.Join(s.dir, "projects", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return append(paths, projects...), nil
+}
+
+func (s *Store) read(path string) (*scopeFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &scopeFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+	var file scopeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i := range file.Memories {
+		file.Memories[i].Project = file.Project
+	}
+	return &file, nil
+}
+
+// write saves a memory file through a temporary file, so a crash can't leave it half written
+func (s *Store) write(path string, file *scopeFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save memories: %w", err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to save memories: %w", err)
+	}
+	return nil
+}
+
+// newestFirst returns memories sorted by creation time, newest first
+func newestFirst(memories []Memory) []Memory {
+	sorted := append([]Memory{}, memories...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	return sorted
+}
+
+func scopeName(project string) string {
+	if project == "" {
+		return "global memory"
+	}
+	return "project " + project
+}
+
+// newID returns a short random ID, easy to type in kiwi memory delete
+func newID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,198 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxItems caps the items of a plan, longer lists are better split into phases
+const MaxItems = 50
+
+// Status is the progress of a plan item
+type Status string
+
+const (
+	Pending    Status = "pending"
+	InProgress Status = "in_progress"
+	Done       Status = "done"
+)
+
+// ParseStatus returns the status named s, accepting "in-progress" and "in progress" too
+func ParseStatus(s string) (Status, error) {
+	switch strings.ReplaceAll(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_"), " ", "_") {
+	case "pending", "todo":
+		return Pending, nil
+	case "in_progress", "started":
+		return InProgress, nil
+	case "done", "completed", "complete":
+		return Done, nil
+	}
+	return "", fmt.Errorf("unknown status: %s, use 'pending', 'in_progress' or 'done'", s)
+}
+
+// Item is a step of a plan
+type Item struct {
+	Title  string `json:"title"`
+	Status Status `json:"status"`
+}
+
+// Plan is the todo list the assistant works through during a multi-step request
+type Plan struct {
+	Goal      string    `json:"goal,omitempty"`
+	Items     []Item    `json:"items"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// New creates a plan with every item pending
+func New(goal string, titles []string) (*Plan, error) {
+	p := &Plan{Goal: strings.TrimSpace(goal)}
+	if err := p.Add(titles); err != nil {
+		return nil, err
+	}
+	if len(p.Items) == 0 {
+		return nil, fmt.Errorf("a plan needs at least one item")
+	}
+	return p, nil
+}
+
+// Add appends pending items to the plan
+func (p *Plan) Add(titles []string) error {
+	var items []Item
+	for _, title := range titles {
+		title = strings.Join(strings.Fields(title), " ")
+		if title == "" {
+			continue
+		}
+		items = append(items, Item{Title: title, Status: Pending})
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("no items given")
+	}
+	if len(p.Items)+len(items) > MaxItems {
+		return fmt.Errorf("a plan can have at most %d items", MaxItems)
+	}
+	p.Items = append(p.Items, items...)
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// Update sets the status of the item with the given 1-based number
+func (p *Plan) Update(number int, status Status) error {
+	if number < 1 || number > len(p.Items) {
+		return fmt.Errorf("no item %d, the plan has %d items", number, len(p.Items))
+	}
+	p.Items[number-1].Status = status
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// Count returns the number of items with the given status
+func (p *Plan) Count(status Status) int {
+	count := 0
+	for _, item := range p.Items {
+		if item.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Complete returns true when every item is done
+func (p *Plan) Complete() bool {
+	return p.Count(Done) == len(p.Items)
+}
+
+// Checklist formats the plan for the terminal, one box per item
+func (p *Plan) Checklist() string {
+	var b strings.Builder
+	header := "Plan"
+	if p.Goal != "" {
+		header += ": " + p.Goal
+	}
+	b.WriteString(fmt.Sprintf("📋 %s (%d/%d done)\n", header, p.Count(Done), len(p.Items)))
+	for _, item := range p.Items {
+		b.WriteString(fmt.Sprintf("  %s %s\n", box(item.Status), item.Title))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// String formats the plan for the model, with the item numbers used to update it
+func (p *Plan) String() string {
+	var b strings.Builder
+	if p.Goal != "" {
+		b.WriteString(fmt.Sprintf("Goal: %s\n", p.Goal))
+	}
+	for i, item := range p.Items {
+		b.WriteString(fmt.Sprintf("%d. %s %s (%s)\n", i+1, box(item.Status), item.Title, item.Status))
+	}
+	b.WriteString(fmt.Sprintf("%d of %d items done", p.Count(Done), len(p.Items)))
+	return b.String()
+}
+
+// Clone returns a copy of the plan that can be changed independently
+func (p *Plan) Clone() *Plan {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.Items = append([]Item{}, p.Items...)
+	return &clone
+}
+
+func box(status Status) string {
+	switch status {
+	case Done:
+		return "[x]"
+	case InProgress:
+		return "[>]"
+	}
+	return "[ ]"
+}
+
+// active is the plan of the current session turn and how to save it
+var active struct {
+	sync.Mutex
+	plan *Plan
+	save func(*Plan) error
+}
+
+// SetActive makes p the plan the plan tool works on, calling save after every change.
+// A nil save keeps the plan in memory only.
+func SetActive(p *Plan, save func(*Plan) error) {
+	active.Lock()
+	defer active.Unlock()
+	active.plan = p.Clone()
+	active.save = save
+}
+
+// ClearActive forgets the active plan
+func ClearActive() {
+	SetActive(nil, nil)
+}
+
+// Current returns a copy of the active plan, or nil when there is none
+func Current() *Plan {
+	active.Lock()
+	defer active.Unlock()
+	return active.plan.Clone()
+}
+
+// Apply changes the active plan with change and saves it. The plan passed to change
+// is nil when there is none yet, and the plan it returns becomes the active one.
+func Apply(change func(*Plan) (*Plan, error)) (*Plan, error) {
+	active.Lock()
+	defer active.Unlock()
+	updated, err := change(active.plan.Clone())
+	if err != nil {
+		return nil, err
+	}
+	if active.save != nil {
+		if err := active.save(updated); err != nil {
+			return nil, fmt.Errorf("failed to save plan: %w", err)
+		}
+	}
+	active.plan = updated
+	return updated.Clone(), nil
+}
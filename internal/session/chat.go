@@ -6,9 +6,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/saurabh0719/kiwi/internal/checkpoint"
 	"github.com/saurabh0719/kiwi/internal/config"
 	"github.com/saurabh0719/kiwi/internal/llm"
 	"github.com/saurabh0719/kiwi/internal/llm/core"
+	"github.com/saurabh0719/kiwi/internal/memory"
+	"github.com/saurabh0719/kiwi/internal/plan"
 	"github.com/saurabh0719/kiwi/internal/tools"
 	"github.com/saurabh0719/kiwi/internal/util"
 )
@@ -29,12 +32,20 @@ For this assistant session:
 - Balance brevity with completeness based on user's engagement style
 
 When handling shell commands:
-- ALWAYS use the shell tool to execute commands when users ask for file operations, git commands, or system tasks
+- ALWAYS use the shell tool to execute commands when users ask for file operations or system tasks
 - If the user's request implies running a terminal command, use the shell tool rather than just showing commands
-- Examples: "list files," "find large files," "add files to git," etc. should all use the shell tool
+- Examples: "list files," "find large files," etc. should all use the shell tool
+- For git status, diffs, history, blame, branches, staging, commits and stashes use the git tool instead of the shell tool
+- To find Go functions, types and their callers, use the code tool instead of reading whole files
+- To inspect or query SQLite databases, use the sqlite tool instead of running the sqlite3 shell
+- To answer questions about large JSON, YAML, TOML or CSV files, use the data tool's schema operation and then query only the values you need instead of reading the whole file
+- To look inside or unpack tar, tar.gz and zip archives, use the archive tool instead of tar or unzip
+- For multi-step requests, first create a plan with the plan tool, then mark each item in_progress when you start it and done when you finish it
+- When the user explains a project convention, a command to use or a preference, save it as a short fact with the memory tool so later sessions know it
 
 For build-related commands:
-- When asked to build a project, FIRST examine the project structure with 'ls -la' and look for build files
+- When asked to build or test a project, FIRST use the sysinfo tool with type 'project' to find its build files and the commands to use
+- To run Go, pytest or jest tests, use the test tool, it returns the failing tests with their locations
 - For Go projects, look for go.mod and use 'go build'
 - For Node.js projects, look for package.json and use 'npm install' followed by 'npm run build'
 - For Python projects, look for setup.py, requirements.txt, or pyproject.toml
@@ -70,15 +81,39 @@ func ProcessChatMessage(mgr *Manager, sess Session, cfg config.Config, adapter c
 		return fmt.Errorf("failed to get updated session: %w", err)
 	}
 
+	// Record the files tools change during this turn so that they can be undone
+	if store, err := checkpoint.NewStore(); err == nil {
+		checkpoint.SetActive(store, sess.ID, updatedSess.TurnCount())
+		defer checkpoint.ClearActive()
+	}
+
+	// The plan tool works on the plan of this session and saves every change to it
+	plan.SetActive(updatedSess.Plan, func(p *plan.Plan) error {
+		return mgr.SavePlan(sess.ID, p)
+	})
+	defer plan.ClearActive()
+
 	var messages []llm.Message
 	if len(updatedSess.Messages) == 1 {
+		// Facts saved in earlier sessions, so conventions don't need to be explained again
+		systemPrompt := AssistantSystemPrompt
+		if memories := memory.SystemPrompt(); memories != "" {
+			systemPrompt += "\n\n" + memories
+		}
 		messages = append(messages, llm.Message{
 			Role:    "system",
-			Content: AssistantSystemPrompt,
+			Content: systemPrompt,
 		})
 	}
 
-	for _, msg := range updatedSess.Messages {
+	for i, msg := range updatedSess.Messages {
+		// Remind the model of an unfinished plan right before the new request, so it can resume it
+		if i == len(updatedSess.Messages)-1 && updatedSess.Plan != nil && !updatedSess.Plan.Complete() {
+			messages = append(messages, llm.Message{
+				Role:    "system",
+				Content: "Current plan of this session, keep it up to date with the plan tool:\n" + updatedSess.Plan.String(),
+			})
+		}
 		messages = append(messages, llm.Message{
 			Role:    msg.Role,
 			Content: msg.Content,
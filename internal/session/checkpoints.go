@@ -0,0 +1,116 @@
+package session
+
+import (
+	"fmt"
+
+	"github.com/saurabh0719/kiwi/internal/checkpoint"
+	"github.com/saurabh0719/kiwi/internal/util"
+)
+
+// TurnCount returns the number of user messages in the session, the first turn is 1
+func (s *Session) TurnCount() int {
+	turns := 0
+	for _, msg := range s.Messages {
+		if msg.Role == "user" {
+			turns++
+		}
+	}
+	return turns
+}
+
+// UndoLastTurn restores the files changed by tools in the most recent turn of a session
+func UndoLastTurn(sessionID string) error {
+	store, err := checkpoint.NewStore()
+	if err != nil {
+		return err
+	}
+
+	turn, err := store.Undo(sessionID)
+	if err != nil {
+		return err
+	}
+
+	util.SuccessColor.Printf("Restored %d file(s) changed in turn %d:\n", len(turn.Files), turn.Number)
+	printTurnFiles(*turn)
+	return nil
+}
+
+// RewindSession restores the workspace to how it was at the end of the given turn.
+// Turn 0 restores the workspace to how it was before the session changed anything.
+func RewindSession(sessionID string, turn int) error {
+	if turn < 0 {
+		return fmt.Errorf("turn must be 0 or greater")
+	}
+
+	store, err := checkpoint.NewStore()
+	if err != nil {
+		return err
+	}
+
+	undone, err := store.Rewind(sessionID, turn)
+	for _, t := range undone {
+		util.InfoColor.Printf("Undid turn %d:\n", t.Number)
+		printTurnFiles(t)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewind session: %w", err)
+	}
+
+	if len(undone) == 0 {
+		util.InfoColor.Printf("No file changes recorded after turn %d, nothing to rewind.\n", turn)
+		return nil
+	}
+
+	util.SuccessColor.Printf("Workspace rewound to the end of turn %d.\n", turn)
+	return nil
+}
+
+// PrintCheckpoints lists which files each turn of a session changed
+func PrintCheckpoints(sessionID string) error {
+	store, err := checkpoint.NewStore()
+	if err != nil {
+		return err
+	}
+
+	turns, err := store.Turns(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if len(turns) == 0 {
+		util.InfoColor.Println("No file changes recorded in this session.")
+		return nil
+	}
+
+	util.HeaderColor.Println("\n📌 Checkpoints")
+	for _, t := range turns {
+		fmt.Println()
+		util.SessionIDColor.Printf("  Turn %d", t.Number)
+		fmt.Printf(" • %s\n", t.CreatedAt.Format("Jan 2, 2006 15:04"))
+		printTurnFiles(t)
+	}
+	fmt.Println()
+	return nil
+}
+
+// deleteCheckpoints removes the checkpoints of a session, errors are ignored since
+// a session without checkpoints is perfectly valid
+func deleteCheckpoints(sessionID string) {
+	if store, err := checkpoint.NewStore(); err == nil {
+		store.Delete(sessionID)
+	}
+}
+
+// printTurnFiles prints the files a turn touched and what undoing it does to them
+func printTurnFiles(t checkpoint.Turn) {
+	for _, entry := range t.Files {
+		action := "modified"
+		switch {
+		case !entry.Existed:
+			action = "created"
+		case entry.IsDir:
+			action = "directory"
+		}
+		fmt.Printf("     %-9s %s\n", action, entry.Path)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/saurabh0719/kiwi/internal/config"
 	"github.com/saurabh0719/kiwi/internal/input"
 	"github.com/saurabh0719/kiwi/internal/llm"
+	"github.com/saurabh0719/kiwi/internal/plan"
 	"github.com/saurabh0719/kiwi/internal/tools"
 	"github.com/saurabh0719/kiwi/internal/util"
 )
@@ -26,10 +27,11 @@ type Message struct {
 }
 
 type Session struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	Summary   string    `json:"summary"` // Simple 1-line summary
-	Messages  []Message `json:"messages"`
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Summary   string     `json:"summary"` // Simple 1-line summary
+	Messages  []Message  `json:"messages"`
+	Plan      *plan.Plan `json:"plan,omitempty"` // Todo list kept with the plan tool
 }
 
 type Manager struct {
@@ -120,6 +122,17 @@ func (m *Manager) AddMessage(sessionID string, role, content string) error {
 	return nil
 }
 
+// SavePlan stores the plan of a session so that it can be resumed later
+func (m *Manager) SavePlan(sessionID string, p *plan.Plan) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	session.Plan = p
+	return m.saveSession(session)
+}
+
 // UpdateSessionSummary generates a simple one-line summary from the first user message
 // or sets the provided summaryText if not empty
 func (m *Manager) UpdateSessionSummary(sessionID string, summaryText ...string) error {
@@ -202,7 +215,11 @@ func (m *Manager) ListSessions() ([]string, error) {
 }
 
 func (m *Manager) DeleteSession(id string) error {
-	return os.Remove(m.sessionPath(id))
+	if err := os.Remove(m.sessionPath(id)); err != nil {
+		return err
+	}
+	deleteCheckpoints(id)
+	return nil
 }
 
 func (m *Manager) ClearSessions() error {
@@ -216,6 +233,7 @@ func (m *Manager) ClearSessions() error {
 			if err := os.Remove(filepath.Join(m.baseDir, file.Name())); err != nil {
 				return fmt.Errorf("failed to delete session %s: %w", file.Name(), err)
 			}
+			deleteCheckpoints(strings.TrimSuffix(file.Name(), ".json"))
 		}
 	}
 
@@ -290,7 +308,7 @@ func (m *Manager) RunInteractiveSession(sessionID string, cfg *config.Config, is
 	}
 
 	toolRegistry := tools.NewRegistry()
-	tools.RegisterStandardTools(toolRegistry)
+	tools.RegisterStandardTools(toolRegistry, cfg)
 
 	adapter, err := llm.NewAdapter(cfg.LLM.Provider, cfg.LLM.Model, cfg.LLM.APIKey, toolRegistry)
 	if err != nil {
@@ -300,9 +318,14 @@ func (m *Manager) RunInteractiveSession(sessionID string, cfg *config.Config, is
 	// Session info
 	if isNewSession {
 		fmt.Println("Assistant session started. Type 'exit' to end the session. Use Shift+Enter for new lines, Enter to submit")
+		fmt.Println("Type '/undo' to revert the file changes of the last turn, '/checkpoints' to list them, '/plan' to show the plan")
 	} else {
 		fmt.Println("Assistant session continued. Type 'exit' to end the session. Use Shift+Enter for new lines, Enter to submit")
+		fmt.Println("Type '/undo' to revert the file changes of the last turn, '/checkpoints' to list them, '/plan' to show the plan")
 		fmt.Printf("Previous conversation has %d messages.\n", len(sess.Messages))
+		if sess.Plan != nil && !sess.Plan.Complete() {
+			util.InfoColor.Println(sess.Plan.Checklist())
+		}
 	}
 
 	util.InfoColor.Printf("Using %s model: %s\n", adapter.GetProvider(), adapter.GetModel())
@@ -353,6 +376,26 @@ func (m *Manager) RunInteractiveSession(sessionID string, cfg *config.Config, is
 			return nil
 		}
 
+		switch strings.TrimSpace(userInput) {
+		case "/undo":
+			if err := UndoLastTurn(sessionID); err != nil {
+				util.WarningColor.Printf("%v\n", err)
+			}
+			continue
+		case "/checkpoints":
+			if err := PrintCheckpoints(sessionID); err != nil {
+				util.WarningColor.Printf("Failed to list checkpoints: %v\n", err)
+			}
+			continue
+		case "/plan":
+			if sess.Plan == nil {
+				fmt.Println("No plan yet, the assistant creates one for multi-step requests")
+			} else {
+				util.InfoColor.Println(sess.Plan.Checklist())
+			}
+			continue
+		}
+
 		if err := ProcessChatMessage(m, *sess, *cfg, adapter, userInput); err != nil {
 			return err
 		}
@@ -0,0 +1,287 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxListed caps the entries shown by list
+	maxListed = 500
+)
+
+// Tool lists, reads and extracts tar, tar.gz and zip archives
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new archive tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'list' (entries with sizes and dates), 'read' (content of a single entry), 'extract' (write the entries, or those below entry, to destination)",
+			Required:    true,
+		},
+		"path": {
+			Type:        "string",
+			Description: "Path to a .tar, .tar.gz, .tgz or .zip archive",
+			Required:    true,
+		},
+		"entry": {
+			Type:        "string",
+			Description: "Entry as listed, like 'src/main.go' (required for read, for extract only this file or directory is extracted)",
+			Required:    false,
+		},
+		"destination": {
+			Type:        "string",
+			Description: "Directory to extract to (for extract only, default: a directory named after the archive next to it)",
+			Required:    false,
+		},
+		"overwrite": {
+			Type:        "boolean",
+			Description: "Replace existing files (for extract only, default false: nothing is extracted if a file would be replaced)",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "archive",
+		description: "Lists, reads single entries from and extracts tar, tar.gz and zip archives. Extraction never writes outside of the destination, refuses to replace existing files unless overwrite is set, and requires confirmation",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, only extraction is confirmed
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// NeedsConfirmation returns true for extract, which writes files
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	operation, _ := core.GetString(args, "operation", "")
+	return operation == "extract"
+}
+
+// Execute runs the archive operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	archivePath, err := core.GetString(args, "path", "")
+	if err != nil {
+		return result, err
+	}
+	if archivePath == "" {
+		return result, fmt.Errorf("path parameter is required")
+	}
+	entryName, err := core.GetString(args, "entry", "")
+	if err != nil {
+		return result, err
+	}
+	destination, err := core.GetString(args, "destination", "")
+	if err != nil {
+		return result, err
+	}
+	overwrite, err := core.GetBool(args, "overwrite", false)
+	if err != nil {
+		return result, err
+	}
+
+	file, err := workspace.Current().Check(archivePath, workspace.Read)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Path check failed: %v", err))
+		return result, err
+	}
+	if info, err := os.Stat(file); err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, fmt.Errorf("failed to access %s: %w", archivePath, err)
+	} else if info.IsDir() {
+		return result, fmt.Errorf("%s is a directory, not an archive", archivePath)
+	}
+	format, err := detectFormat(file)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+	result.AddStep(fmt.Sprintf("Opened %s as %s", file, format))
+
+	var output string
+	switch operation {
+	case "list":
+		output, err = list(file, format)
+	case "read":
+		if entryName == "" {
+			return result, fmt.Errorf("entry parameter is required for read")
+		}
+		output, err = read(file, format, entryName)
+	case "extract":
+		if destination == "" {
+			destination = defaultDestination(file)
+		}
+		output, err = extract(ctx, file, format, entryName, destination, overwrite)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+
+	output = result.TruncateOutput(output, "")
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
+
+// list describes the entries of an archive
+func list(file, format string) (string, error) {
+	var lines []string
+	files, dirs, links, count := 0, 0, 0, 0
+	var total int64
+	err := walk(file, format, func(e entry) error {
+		count++
+		size := "-"
+		name := displayName(e)
+		switch e.kind {
+		case kindFile:
+			files++
+			total += e.size
+			size = formatSize(e.size)
+		case kindDir:
+			dirs++
+		case kindSymlink, kindHardlink:
+			links++
+			name += " -> " + e.link
+		}
+		if len(lines) < maxListed {
+			modified := ""
+			if !e.modTime.IsZero() {
+				modified = e.modTime.Format("2006-01-02 15:04")
+			}
+			lines = append(lines, fmt.Sprintf("%10s  %-16s  %s", size, modified, name))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %s, %d files, %d directories", filepath.Base(file), format, files, dirs))
+	if links > 0 {
+		b.WriteString(fmt.Sprintf(", %d links", links))
+	}
+	b.WriteString(fmt.Sprintf(", %s uncompressed\n\n", formatSize(total)))
+	b.WriteString(fmt.Sprintf("%10s  %-16s  %s\n", "SIZE", "MODIFIED", "NAME"))
+	b.WriteString(strings.Join(lines, "\n"))
+	if count > maxListed {
+		b.WriteString(fmt.Sprintf("\n\n[%d more entries not shown]", count-maxListed))
+	}
+	return b.String(), nil
+}
+
+// read returns the content of a single file of an archive
+func read(file, format, name string) (string, error) {
+	want, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+
+	var output string
+	found := false
+	err = walk(file, format, func(e entry) error {
+		clean, err := cleanName(e.name)
+		if err != nil || clean != want {
+			return nil
+		}
+		found = true
+		switch e.kind {
+		case kindDir:
+			return fmt.Errorf("%s is a directory, list the archive to see its entries", name)
+		case kindSymlink, kindHardlink:
+			output = fmt.Sprintf("%s is a link to %s", name, e.link)
+			return errStop
+		case kindOther:
+			return fmt.Errorf("%s is not a regular file", name)
+		}
+
+		r, err := e.open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		defer r.Close()
+		content, err := io.ReadAll(io.LimitReader(r, core.MaxOutput+1))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+			output = fmt.Sprintf("%s is a binary file (%s), extract it to inspect it", name, formatSize(e.size))
+			return errStop
+		}
+		output = string(content)
+		if len(content) > core.MaxOutput {
+			output = core.Truncate(output, core.MaxOutput) + fmt.Sprintf("\n\n[Entry truncated at %d of %d bytes, extract it to read the rest]", core.MaxOutput, e.size)
+		}
+		return errStop
+	})
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("no entry %s in %s, list the archive to see its entries", name, filepath.Base(file))
+	}
+	return output, nil
+}
+
+// formatSize formats a byte count for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
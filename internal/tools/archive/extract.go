@@ -0,0 +1,275 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/checkpoint"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxEntries caps the entries an archive may have to be extracted
+	maxEntries = 20000
+
+	// maxExtractSize caps the bytes written by an extraction. It is checked against the
+	// sizes in the archive before extracting and against the bytes actually written,
+	// so an archive lying about its sizes can't fill the disk.
+	maxExtractSize = 1 << 30
+
+	// maxConflictsShown caps the existing files named when an extraction is refused
+	maxConflictsShown = 10
+)
+
+// planned is an entry to extract and where it goes
+type planned struct {
+	entry  string // cleaned name, the key to match entries on the second pass
+	target string
+	kind   entryKind
+	exists bool
+}
+
+// defaultDestination returns a directory next to the archive named after it, like
+// release for release.tar.gz
+func defaultDestination(file string) string {
+	name := filepath.Base(file)
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip", ".jar", ".whl"} {
+		if strings.HasSuffix(lower, ext) && len(name) > len(ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	if name == filepath.Base(file) {
+		name += ".extracted"
+	}
+	return filepath.Join(filepath.Dir(file), name)
+}
+
+// extract writes the entries of an archive, or those below only, into destination.
+// Every target is checked before anything is written: entries that would land outside
+// of destination fail the whole extraction, and so do existing files unless overwrite
+// is set. Links and special files are skipped.
+func extract(ctx context.Context, file, format, only, destination string, overwrite bool) (string, error) {
+	dest, err := workspace.Current().Check(destination, workspace.Write)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dest); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("destination %s is a file, not a directory", destination)
+	}
+	prefix := ""
+	if only != "" {
+		if prefix, err = cleanName(only); err != nil {
+			return "", err
+		}
+	}
+
+	// First pass: check every entry before writing anything
+	plan := make(map[string]planned)
+	var order []string
+	var skipped, conflicts []string
+	var total int64
+	err = walk(file, format, func(e entry) error {
+		name, err := cleanName(e.name)
+		if err != nil {
+			return fmt.Errorf("refusing to extract %s: %w", filepath.Base(file), err)
+		}
+		if prefix != "" && name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			return nil
+		}
+		switch e.kind {
+		case kindSymlink, kindHardlink:
+			skipped = append(skipped, fmt.Sprintf("%s (link to %s)", displayName(e), e.link))
+			return nil
+		case kindOther:
+			skipped = append(skipped, fmt.Sprintf("%s (not a regular file)", displayName(e)))
+			return nil
+		}
+
+		target := dest
+		if name != "." {
+			target = filepath.Join(dest, filepath.FromSlash(name))
+		}
+		if rel, err := filepath.Rel(dest, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to extract %s: entry %q points outside of the destination", filepath.Base(file), e.name)
+		}
+		if _, err := workspace.Current().Check(target, workspace.Write); err != nil {
+			return fmt.Errorf("refusing to extract %s: %w", e.name, err)
+		}
+
+		if len(order) == maxEntries {
+			return fmt.Errorf("%s has more than %d entries, extract a directory of it with entry", filepath.Base(file), maxEntries)
+		}
+		if e.kind == kindFile {
+			total += e.size
+			if total > maxExtractSize {
+				return fmt.Errorf("%s holds more than %s, extract a part of it with entry", filepath.Base(file), formatSize(maxExtractSize))
+			}
+		}
+
+		p := planned{entry: name, target: target, kind: e.kind}
+		if info, err := os.Lstat(target); err == nil {
+			p.exists = true
+			switch {
+			case e.kind == kindDir && info.IsDir():
+				// Extracting into an existing directory replaces nothing
+			case e.kind == kindDir:
+				return fmt.Errorf("can't extract %s: %s already exists and is not a directory", displayName(e), target)
+			case info.IsDir():
+				return fmt.Errorf("can't extract %s: %s already exists and is a directory", displayName(e), target)
+			default:
+				conflicts = append(conflicts, target)
+			}
+		}
+		if _, seen := plan[name]; !seen {
+			order = append(order, name)
+		}
+		// Later entries of a tar replace earlier ones of the same name
+		plan[name] = p
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if prefix != "" && len(plan) == 0 && len(skipped) == 0 {
+		return "", fmt.Errorf("no entry %s in %s, list the archive to see its entries", only, filepath.Base(file))
+	}
+	if len(conflicts) > 0 && !overwrite {
+		shown := conflicts
+		if len(shown) > maxConflictsShown {
+			shown = shown[:maxConflictsShown]
+		}
+		message := fmt.Sprintf("nothing extracted, %d files already exist: %s", len(conflicts), strings.Join(shown, ", "))
+		if len(conflicts) > len(shown) {
+			message += fmt.Sprintf(" and %d more", len(conflicts)-len(shown))
+		}
+		return "", fmt.Errorf("%s. Set overwrite to replace them, or choose another destination", message)
+	}
+
+	// Second pass: write the planned entries
+	if err := snapshot(dest); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	var written int64
+	files, dirs := 0, 0
+	err = walk(file, format, func(e entry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name, err := cleanName(e.name)
+		if err != nil {
+			return err
+		}
+		p, ok := plan[name]
+		if !ok || p.kind != e.kind {
+			return nil
+		}
+
+		if e.kind == kindDir {
+			if err := snapshot(p.target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(p.target, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", p.target, err)
+			}
+			dirs++
+			return nil
+		}
+
+		n, err := writeFile(e, p.target, maxExtractSize-written)
+		written += n
+		if err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("extraction stopped after %d files: %w", files, err)
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Extracted %d files and %d directories (%s) to %s", files, dirs, formatSize(written), dest))
+	if len(conflicts) > 0 {
+		b.WriteString(fmt.Sprintf("\n\nReplaced %d existing files:\n", len(conflicts)))
+		b.WriteString(listLines(conflicts))
+	}
+	if len(skipped) > 0 {
+		b.WriteString(fmt.Sprintf("\n\nSkipped %d entries:\n", len(skipped)))
+		b.WriteString(listLines(skipped))
+	}
+	return b.String(), nil
+}
+
+// writeFile writes the content of an entry to target, failing once more than limit
+// bytes have been written
+func writeFile(e entry, target string, limit int64) (int64, error) {
+	if err := snapshot(target); err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+	// Never write through an existing link, it could point anywhere
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return 0, fmt.Errorf("failed to replace %s: %w", target, err)
+		}
+	}
+
+	r, err := e.open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", e.name, err)
+	}
+	defer r.Close()
+
+	// Keep the permissions of the archive, but always let the user read and write
+	mode := e.mode.Perm()&0755 | 0600
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	n, err := io.Copy(out, io.LimitReader(r, limit+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if n > limit {
+		return n, fmt.Errorf("%s expands to more than %s", e.name, formatSize(maxExtractSize))
+	}
+	if !e.modTime.IsZero() {
+		os.Chtimes(target, e.modTime, e.modTime)
+	}
+	return n, nil
+}
+
+// snapshot records a path before it is created or replaced so that the extraction can be undone
+func snapshot(path string) error {
+	if err := checkpoint.Snapshot(path); err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	return nil
+}
+
+// listLines formats paths as an indented list, capped like the conflicts
+func listLines(items []string) string {
+	shown := items
+	if len(shown) > maxConflictsShown {
+		shown = shown[:maxConflictsShown]
+	}
+	text := "  " + strings.Join(shown, "\n  ")
+	if len(items) > len(shown) {
+		text += fmt.Sprintf("\n  [%d more]", len(items)-len(shown))
+	}
+	return text
+}
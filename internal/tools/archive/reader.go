@@ -0,0 +1,214 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errStop ends a walk early without an error
+var errStop = errors.New("stop")
+
+// entryKind is the type of an archive entry
+type entryKind int
+
+const (
+	kindFile entryKind = iota
+	kindDir
+	kindSymlink
+	kindHardlink
+	kindOther // devices, fifos and the like, never extracted
+)
+
+// entry is a file, directory or link in an archive
+type entry struct {
+	name    string // as stored in the archive
+	kind    entryKind
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	link    string // target of links
+
+	// open returns the content of a file, only valid during the walk callback
+	open func() (io.ReadCloser, error)
+}
+
+// detectFormat returns the format of an archive from its name, or from its first bytes
+func detectFormat(file string) (string, error) {
+	name := strings.ToLower(filepath.Base(file))
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz", nil
+	case strings.HasSuffix(name, ".tar"):
+		return "tar", nil
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".jar"), strings.HasSuffix(name, ".whl"):
+		return "zip", nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return "tar", nil
+	}
+	return "", fmt.Errorf("%s is not a tar, tar.gz or zip archive", filepath.Base(file))
+}
+
+// walk calls fn for every entry of an archive, in the order they are stored
+func walk(file, format string, fn func(e entry) error) error {
+	var err error
+	switch format {
+	case "zip":
+		err = walkZip(file, fn)
+	case "tar", "tar.gz":
+		err = walkTar(file, format == "tar.gz", fn)
+	default:
+		return fmt.Errorf("unknown format: %s, use tar, tar.gz or zip", format)
+	}
+	if err == errStop {
+		return nil
+	}
+	return err
+}
+
+func walkZip(file string, fn func(e entry) error) error {
+	reader, err := zip.OpenReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read zip archive: %w", err)
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		f := f
+		mode := f.Mode()
+		e := entry{
+			name:    f.Name,
+			size:    int64(f.UncompressedSize64),
+			mode:    mode,
+			modTime: f.Modified,
+			open:    func() (io.ReadCloser, error) { return f.Open() },
+		}
+		switch {
+		case mode.IsDir() || strings.HasSuffix(f.Name, "/"):
+			e.kind = kindDir
+		case mode&fs.ModeSymlink != 0:
+			e.kind = kindSymlink
+			// Zip stores the target of a link as its content
+			if r, err := f.Open(); err == nil {
+				target, _ := io.ReadAll(io.LimitReader(r, 4096))
+				r.Close()
+				e.link = string(target)
+			}
+		case mode.IsRegular():
+			e.kind = kindFile
+		default:
+			e.kind = kindOther
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(file string, compressed bool, fn func(e entry) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to read gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		e := entry{
+			name:    header.Name,
+			size:    header.Size,
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+			link:    header.Linkname,
+			open:    func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse:
+			e.kind = kindFile
+		case tar.TypeDir:
+			e.kind = kindDir
+		case tar.TypeSymlink:
+			e.kind = kindSymlink
+		case tar.TypeLink:
+			e.kind = kindHardlink
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			e.kind = kindOther
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanName returns the path of an entry relative to the root of the archive, or an
+// error for names that would end up outside of it, like ../../etc/passwd or /etc/passwd
+func cleanName(name string) (string, error) {
+	// Zip archives made on Windows may use backslashes
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(slashed, "/") || (len(slashed) >= 2 && slashed[1] == ':') {
+		return "", fmt.Errorf("entry %q has an absolute path", name)
+	}
+	clean := path.Clean(slashed)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry %q points outside of the archive", name)
+	}
+	return clean, nil
+}
+
+// displayName returns an entry name as listed, without a leading ./ and with a
+// trailing / for directories
+func displayName(e entry) string {
+	name := strings.TrimPrefix(strings.ReplaceAll(e.name, `\`, "/"), "./")
+	name = strings.TrimSuffix(name, "/")
+	if e.kind == kindDir {
+		name += "/"
+	}
+	return name
+}
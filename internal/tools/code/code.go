@@ -0,0 +1,157 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxSourceLines caps the source shown for a single definition
+	maxSourceLines = 150
+
+	// maxDefinitions caps the definitions shown when a name is declared in several packages
+	maxDefinitions = 5
+
+	// maxReferences caps the references listed
+	maxReferences = 200
+)
+
+// Tool navigates Go code using the parser and type checker of the standard library
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new code navigation tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'symbols' (constants, variables, types, methods and functions of the package with their signatures), 'definition' (location, doc comment and source of symbol), 'references' (every use of symbol in the module, grouped by file, and the functions calling it), 'methods' (method set of the type symbol, including promoted methods, and the interfaces it implements)",
+			Required:    true,
+		},
+		"path": {
+			Type:        "string",
+			Description: "Package directory or Go file to start from (default: current directory). Symbols not declared in this package are looked up in the rest of the module",
+			Required:    false,
+		},
+		"symbol": {
+			Type:        "string",
+			Description: "Name to look up, like Server, NewServer, Server.Start, Config.Timeout or pkg.Name (for definition, references and methods)",
+			Required:    false,
+		},
+		"exported": {
+			Type:        "boolean",
+			Description: "List exported symbols only (for symbols only). Defaults to false.",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "code",
+		description: "Navigates Go code precisely without reading whole files: lists the symbols of a package, shows the definition of a function, type, method or field with its doc comment, finds its references and callers across the module, and shows the method set of a type",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, the tool only reads code
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// Execute runs the code navigation operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	path, err := core.GetString(args, "path", ".")
+	if err != nil {
+		return result, err
+	}
+	dir, err := workspace.Current().Check(path, workspace.Read)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Path check failed: %v", err))
+		return result, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, fmt.Errorf("failed to access %s: %w", path, err)
+	} else if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	symbol, err := core.GetString(args, "symbol", "")
+	if err != nil {
+		return result, err
+	}
+	if symbol == "" && operation != "symbols" {
+		return result, fmt.Errorf("symbol parameter is required for %s operation", operation)
+	}
+
+	exported, err := core.GetBool(args, "exported", false)
+	if err != nil {
+		return result, err
+	}
+
+	l := newLoader(dir)
+	if l.modulePath != "" {
+		result.AddStep(fmt.Sprintf("Using module %s at %s", l.modulePath, l.root))
+	}
+
+	var output string
+	switch operation {
+	case "symbols":
+		output, err = l.symbols(dir, exported)
+	case "definition":
+		output, err = l.definition(dir, symbol)
+	case "references":
+		output, err = l.references(ctx, dir, symbol)
+	case "methods":
+		output, err = l.methods(dir, symbol)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+
+	output = result.TruncateOutput(output, "narrow it down with path or symbol")
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
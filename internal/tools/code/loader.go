@@ -0,0 +1,283 @@
+package code
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+// skippedDirs are never searched for packages
+var skippedDirs = map[string]bool{
+	"vendor": true, "testdata": true, "node_modules": true,
+}
+
+// pkg is a parsed package, type-checked on demand
+type pkg struct {
+	dir        string
+	importPath string
+	name       string
+	files      []*ast.File // non-test files
+	testFiles  []*ast.File // _test.go files of the same package
+	xtestFiles []*ast.File // _test.go files of the external test package
+	types      *types.Package
+	info       *types.Info
+	checking   bool
+}
+
+// loader parses and type-checks the packages of a Go module from source. Packages of
+// the module are parsed by the loader so that references between them can be followed,
+// dependencies are left to the source importer of the standard library. Nothing is
+// compiled, so looking up a symbol never runs the build of the module.
+type loader struct {
+	fset       *token.FileSet
+	root       string // directory of go.mod, or of the package when there is none
+	modulePath string
+	packages   map[string]*pkg     // by import path
+	sources    map[string][]string // lines of files shown in the output
+	source     types.Importer
+}
+
+// newLoader creates a loader for the module containing dir
+func newLoader(dir string) *loader {
+	root, modulePath := findModule(dir)
+	fset := token.NewFileSet()
+	l := &loader{
+		fset:       fset,
+		root:       root,
+		modulePath: modulePath,
+		packages:   make(map[string]*pkg),
+		sources:    make(map[string][]string),
+		source:     importer.ForCompiler(fset, "source", nil),
+	}
+	return l
+}
+
+// findModule walks up from dir to the nearest go.mod and returns its directory and module path.
+// The walk stops at the workspace root, so a module above it is never loaded.
+func findModule(dir string) (string, string) {
+	policy := workspace.Current()
+	for current := dir; ; current = filepath.Dir(current) {
+		if !policy.Allowed(current, workspace.Read) {
+			return dir, ""
+		}
+		if data, err := os.ReadFile(filepath.Join(current, "go.mod")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if path, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return current, strings.Trim(strings.TrimSpace(path), `"`)
+				}
+			}
+			return current, ""
+		}
+		if filepath.Dir(current) == current {
+			return dir, ""
+		}
+	}
+}
+
+// importPathOf returns the import path of a directory of the module
+func (l *loader) importPathOf(dir string) string {
+	rel, err := filepath.Rel(l.root, dir)
+	if err != nil || rel == "." {
+		if l.modulePath == "" {
+			return filepath.Base(dir)
+		}
+		return l.modulePath
+	}
+	if l.modulePath == "" {
+		return filepath.ToSlash(rel)
+	}
+	return l.modulePath + "/" + filepath.ToSlash(rel)
+}
+
+// dirOf returns the directory of a module package, or false for other import paths
+func (l *loader) dirOf(importPath string) (string, bool) {
+	if l.modulePath == "" {
+		return "", false
+	}
+	if importPath == l.modulePath {
+		return l.root, true
+	}
+	rel, ok := strings.CutPrefix(importPath, l.modulePath+"/")
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(l.root, filepath.FromSlash(rel)), true
+}
+
+// load parses the package in dir, or returns nil if there is none
+func (l *loader) load(dir string) (*pkg, error) {
+	importPath := l.importPathOf(dir)
+	if p, ok := l.packages[importPath]; ok {
+		return p, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pkg{dir: dir, importPath: importPath}
+	policy := workspace.Current()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		// A symlinked file may point outside of the workspace
+		if policy.IsDenied(path) || entry.Type()&fs.ModeSymlink != 0 && !policy.Allowed(path, workspace.Read) {
+			continue
+		}
+		// Skip files for other platforms or excluded by build tags
+		if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+			continue
+		}
+
+		file, err := parser.ParseFile(l.fset, path, nil, parser.ParseComments)
+		if file == nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		isTest := strings.HasSuffix(name, "_test.go")
+		switch {
+		case isTest && strings.HasSuffix(file.Name.Name, "_test"):
+			p.xtestFiles = append(p.xtestFiles, file)
+		case isTest:
+			p.testFiles = append(p.testFiles, file)
+		default:
+			p.files = append(p.files, file)
+		}
+	}
+
+	switch {
+	case len(p.files) > 0:
+		p.name = p.files[0].Name.Name
+	case len(p.testFiles) > 0:
+		p.name = p.testFiles[0].Name.Name
+	case len(p.xtestFiles) > 0:
+		p.name = strings.TrimSuffix(p.xtestFiles[0].Name.Name, "_test")
+	default:
+		return nil, nil
+	}
+	l.packages[importPath] = p
+	return p, nil
+}
+
+// loadAll parses every package of the module
+func (l *loader) loadAll() ([]*pkg, error) {
+	policy := workspace.Current()
+	var packages []*pkg
+	err := filepath.WalkDir(l.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if path != l.root {
+			name := entry.Name()
+			if skippedDirs[name] || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || !policy.Allowed(path, workspace.Read) {
+				return filepath.SkipDir
+			}
+			// Nested modules are separate projects
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+		}
+		p, err := l.load(path)
+		if err != nil {
+			return err
+		}
+		if p != nil {
+			packages = append(packages, p)
+		}
+		return nil
+	})
+	sort.Slice(packages, func(i, j int) bool { return packages[i].importPath < packages[j].importPath })
+	return packages, err
+}
+
+// Import implements types.Importer, checking packages of the module from source
+func (l *loader) Import(importPath string) (*types.Package, error) {
+	if dir, ok := l.dirOf(importPath); ok {
+		p, err := l.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, fmt.Errorf("no Go files in %s", dir)
+		}
+		if err := l.check(p); err != nil {
+			return nil, err
+		}
+		return p.types, nil
+	}
+
+	return l.source.Import(importPath)
+}
+
+// check type-checks the non-test files of a package. Errors are tolerated, so that
+// a package with a missing dependency still resolves everything else.
+func (l *loader) check(p *pkg) error {
+	if p.types != nil {
+		return nil
+	}
+	if p.checking {
+		return fmt.Errorf("import cycle through %s", p.importPath)
+	}
+	p.checking = true
+	defer func() { p.checking = false }()
+
+	p.types, p.info = l.checkFiles(p.importPath, p.files)
+	return nil
+}
+
+// checkFiles type-checks a set of files as one package
+func (l *loader) checkFiles(importPath string, files []*ast.File) (*types.Package, *types.Info) {
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	config := &types.Config{
+		Importer: l,
+		Error:    func(error) {},
+	}
+	checked, _ := config.Check(importPath, l.fset, files, info)
+	return checked, info
+}
+
+// relative returns a filename relative to the module root
+func (l *loader) relative(filename string) string {
+	if rel, err := filepath.Rel(l.root, filename); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filename
+}
+
+// position formats a position relative to the module root
+func (l *loader) position(pos token.Pos) string {
+	position := l.fset.Position(pos)
+	return fmt.Sprintf("%s:%d", l.relative(position.Filename), position.Line)
+}
+
+// objectKey identifies an object by where it is declared, which stays the same
+// when a package is checked more than once, for example together with its tests
+func (l *loader) objectKey(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		obj = o.Origin()
+	case *types.Var:
+		obj = o.Origin()
+	}
+	position := l.fset.Position(obj.Pos())
+	return fmt.Sprintf("%s:%d:%d", position.Filename, position.Line, position.Column)
+}
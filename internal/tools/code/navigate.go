@@ -0,0 +1,771 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+// wellKnownInterfaces are standard library interfaces checked by methods in addition
+// to the interfaces of the module
+var wellKnownInterfaces = []struct{ path, name string }{
+	{"fmt", "Stringer"}, {"io", "Reader"}, {"io", "Writer"}, {"io", "Closer"},
+	{"sort", "Interface"}, {"encoding/json", "Marshaler"}, {"encoding/json", "Unmarshaler"},
+}
+
+// symbolName is a parsed symbol parameter: Name, Type.Member, pkg.Name or pkg.Type.Member
+type symbolName struct {
+	qualifier string
+	name      string
+	member    string
+}
+
+func parseSymbol(symbol string) symbolName {
+	// Accept receivers written like (*Server).Start
+	symbol = strings.NewReplacer("(", "", ")", "", "*", "").Replace(strings.TrimSpace(symbol))
+	parts := strings.Split(symbol, ".")
+	switch len(parts) {
+	case 1:
+		return symbolName{name: parts[0]}
+	case 2:
+		return symbolName{name: parts[0], member: parts[1]}
+	default:
+		n := len(parts)
+		return symbolName{qualifier: parts[n-3], name: parts[n-2], member: parts[n-1]}
+	}
+}
+
+// qualifier writes other packages by name and leaves names of the package itself unqualified
+func qualifier(p *types.Package) types.Qualifier {
+	return func(other *types.Package) string {
+		if other == p {
+			return ""
+		}
+		return other.Name()
+	}
+}
+
+// describe returns a one-line signature of a declared object
+func describe(obj types.Object, qualify types.Qualifier) string {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		line := types.ObjectString(obj, qualify)
+		if constant, ok := obj.(*types.Const); ok {
+			line += " = " + constant.Val().ExactString()
+		}
+		if len(line) > 200 {
+			line = line[:200] + "…"
+		}
+		return line
+	}
+
+	var b strings.Builder
+	b.WriteString("type " + typeName.Name())
+	if typeName.IsAlias() {
+		b.WriteString(" = " + types.TypeString(types.Unalias(typeName.Type()), qualify))
+		return b.String()
+	}
+	named, ok := typeName.Type().(*types.Named)
+	if !ok {
+		return b.String()
+	}
+	if params := named.TypeParams(); params.Len() > 0 {
+		var list []string
+		for i := 0; i < params.Len(); i++ {
+			param := params.At(i)
+			list = append(list, param.Obj().Name()+" "+types.TypeString(param.Constraint(), qualify))
+		}
+		b.WriteString("[" + strings.Join(list, ", ") + "]")
+	}
+	switch underlying := named.Underlying().(type) {
+	case *types.Struct:
+		b.WriteString(fmt.Sprintf(" struct (%d fields)", underlying.NumFields()))
+	case *types.Interface:
+		b.WriteString(fmt.Sprintf(" interface (%d methods)", underlying.NumMethods()))
+	default:
+		b.WriteString(" " + types.TypeString(underlying, qualify))
+	}
+	return b.String()
+}
+
+// declares reports whether the non-test files of the package declare name at package level
+func (p *pkg) declares(name string) bool {
+	for _, file := range p.files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil && decl.Name.Name == name {
+					return true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.Name == name {
+							return true
+						}
+					case *ast.ValueSpec:
+						for _, ident := range spec.Names {
+							if ident.Name == name {
+								return true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// imports reports whether any of the files imports the package
+func imports(files []*ast.File, importPath string) bool {
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			if path, err := strconv.Unquote(spec.Path.Value); err == nil && path == importPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup finds a symbol in a package, type-checking it only when it declares the name
+func (l *loader) lookup(p *pkg, s symbolName) types.Object {
+	if s.qualifier != "" && p.name != s.qualifier {
+		return nil
+	}
+	qualified := s.qualifier == "" && s.member != "" && p.name == s.name
+	if !p.declares(s.name) && !(qualified && p.declares(s.member)) {
+		return nil
+	}
+	if err := l.check(p); err != nil {
+		return nil
+	}
+
+	scope := p.types.Scope()
+	if obj := scope.Lookup(s.name); obj != nil {
+		if s.member == "" {
+			return obj
+		}
+		if _, ok := obj.(*types.TypeName); ok {
+			if member, _, _ := types.LookupFieldOrMethod(obj.Type(), true, p.types, s.member); member != nil {
+				return member
+			}
+		}
+	}
+	if qualified {
+		return scope.Lookup(s.member)
+	}
+	return nil
+}
+
+// resolve finds the objects a symbol refers to, in the package in dir or else anywhere in the module
+func (l *loader) resolve(dir, symbol string) ([]types.Object, error) {
+	s := parseSymbol(symbol)
+	if s.name == "" {
+		return nil, fmt.Errorf("invalid symbol: %q", symbol)
+	}
+
+	p, err := l.load(dir)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		if obj := l.lookup(p, s); obj != nil {
+			return []types.Object{obj}, nil
+		}
+	}
+
+	packages, err := l.loadAll()
+	if err != nil {
+		return nil, err
+	}
+	var objects []types.Object
+	for _, other := range packages {
+		if other == p {
+			continue
+		}
+		if obj := l.lookup(other, s); obj != nil {
+			objects = append(objects, obj)
+		}
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("symbol %s not found in %s", symbol, l.root)
+	}
+	return objects, nil
+}
+
+// resolveOne is resolve for operations that need a single object
+func (l *loader) resolveOne(dir, symbol string) (types.Object, error) {
+	objects, err := l.resolve(dir, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) > 1 {
+		var found []string
+		for _, obj := range objects {
+			found = append(found, obj.Pkg().Path())
+		}
+		return nil, fmt.Errorf("symbol %s is declared in several packages (%s), set path to one of them", symbol, strings.Join(found, ", "))
+	}
+	return objects[0], nil
+}
+
+// symbols lists the package-level declarations of the package in dir, with the methods of each type
+func (l *loader) symbols(dir string, exported bool) (string, error) {
+	p, err := l.load(dir)
+	if err != nil {
+		return "", err
+	}
+	if p == nil {
+		return "", fmt.Errorf("no Go files in %s", dir)
+	}
+	if err := l.check(p); err != nil {
+		return "", err
+	}
+
+	qualify := qualifier(p.types)
+	var constants, variables, typeNames, functions []string
+	scope := p.types.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if exported && !obj.Exported() {
+			continue
+		}
+		line := fmt.Sprintf("  %s  // %s\n", describe(obj, qualify), l.position(obj.Pos()))
+		switch obj := obj.(type) {
+		case *types.Const:
+			constants = append(constants, line)
+		case *types.Var:
+			variables = append(variables, line)
+		case *types.Func:
+			functions = append(functions, line)
+		case *types.TypeName:
+			named, ok := obj.Type().(*types.Named)
+			if ok && !obj.IsAlias() {
+				for i := 0; i < named.NumMethods(); i++ {
+					method := named.Method(i)
+					if exported && !method.Exported() {
+						continue
+					}
+					line += fmt.Sprintf("      %s  // %s\n", describe(method, qualify), l.position(method.Pos()))
+				}
+			}
+			typeNames = append(typeNames, line)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("package %s (%s), %d files\n", p.name, p.importPath, len(p.files)))
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Constants", constants}, {"Variables", variables}, {"Types", typeNames}, {"Functions", functions},
+	} {
+		if len(section.lines) == 0 {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("\n%s:\n", section.title))
+		for _, line := range section.lines {
+			b.WriteString(line)
+		}
+	}
+	if len(constants)+len(variables)+len(typeNames)+len(functions) == 0 {
+		b.WriteString("\nNo symbols declared\n")
+	}
+	return b.String(), nil
+}
+
+// definition shows where a symbol is declared, with its doc comment and source
+func (l *loader) definition(dir, symbol string) (string, error) {
+	objects, err := l.resolve(dir, symbol)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, obj := range objects {
+		if i == maxDefinitions {
+			b.WriteString(fmt.Sprintf("[%d more definitions not shown, set path to the package you want]\n", len(objects)-i))
+			break
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("%s (package %s)\n\n", l.position(obj.Pos()), obj.Pkg().Path()))
+		source := l.declarationSource(obj)
+		if source == "" {
+			source = describe(obj, qualifier(obj.Pkg())) + "\n"
+		}
+		b.WriteString(source)
+	}
+	return b.String(), nil
+}
+
+// fileOf returns the parsed file containing pos
+func (l *loader) fileOf(pos token.Pos) *ast.File {
+	for _, p := range l.packages {
+		for _, files := range [][]*ast.File{p.files, p.testFiles, p.xtestFiles} {
+			for _, file := range files {
+				if file.FileStart <= pos && pos <= file.FileEnd {
+					return file
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// declarationSource returns the source of the declaration of obj, including its doc comment
+func (l *loader) declarationSource(obj types.Object) string {
+	file := l.fileOf(obj.Pos())
+	if file == nil {
+		return ""
+	}
+
+	// Find the outermost node declaring obj: a whole declaration when it declares
+	// only obj, otherwise the spec or field within it
+	var start, end token.Pos
+	withDoc := func(doc *ast.CommentGroup, node ast.Node) {
+		start, end = node.Pos(), node.End()
+		if doc != nil {
+			start = doc.Pos()
+		}
+	}
+	declaresObj := func(idents []*ast.Ident) bool {
+		for _, ident := range idents {
+			if ident.Pos() == obj.Pos() {
+				return true
+			}
+		}
+		return false
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if start.IsValid() || node == nil || !(node.Pos() <= obj.Pos() && obj.Pos() < node.End()) {
+			return false
+		}
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			if node.Name.Pos() == obj.Pos() {
+				withDoc(node.Doc, node)
+			}
+		case *ast.GenDecl:
+			for _, spec := range node.Specs {
+				var idents []*ast.Ident
+				var doc *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					idents, doc = []*ast.Ident{spec.Name}, spec.Doc
+				case *ast.ValueSpec:
+					idents, doc = spec.Names, spec.Doc
+				}
+				if !declaresObj(idents) {
+					continue
+				}
+				if len(node.Specs) == 1 && !node.Lparen.IsValid() {
+					withDoc(node.Doc, node)
+				} else {
+					withDoc(doc, spec)
+				}
+			}
+		case *ast.Field:
+			if declaresObj(node.Names) {
+				withDoc(node.Doc, node)
+				if node.Comment != nil {
+					end = node.Comment.End()
+				}
+			}
+		}
+		return true
+	})
+	if !start.IsValid() {
+		return ""
+	}
+
+	from, to := l.fset.Position(start), l.fset.Position(end)
+	lines := l.lines(from.Filename)
+	if lines == nil || to.Line > len(lines) {
+		return ""
+	}
+	var b strings.Builder
+	for line := from.Line; line <= to.Line; line++ {
+		if line-from.Line == maxSourceLines {
+			b.WriteString(fmt.Sprintf("[%d more lines, up to %s:%d]\n", to.Line-line+1, l.relative(from.Filename), to.Line))
+			break
+		}
+		b.WriteString(lines[line-1] + "\n")
+	}
+	return b.String()
+}
+
+// lines returns the lines of a file, read once
+func (l *loader) lines(filename string) []string {
+	if lines, ok := l.sources[filename]; ok {
+		return lines
+	}
+	var lines []string
+	// Sources outside of the workspace are never shown
+	if workspace.Current().Allowed(filename, workspace.Read) {
+		if data, err := os.ReadFile(filename); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+	}
+	l.sources[filename] = lines
+	return lines
+}
+
+// reference is a use of the symbol looked up by references
+type reference struct {
+	position token.Position
+	caller   string // enclosing function, empty at package level
+	call     bool
+}
+
+// references lists every use of a symbol in the module, with the functions calling it
+func (l *loader) references(ctx context.Context, dir, symbol string) (string, error) {
+	target, err := l.resolveOne(dir, symbol)
+	if err != nil {
+		return "", err
+	}
+	key := l.objectKey(target)
+	declaring := target.Pkg().Path()
+
+	packages, err := l.loadAll()
+	if err != nil {
+		return "", err
+	}
+
+	seen := make(map[string]bool)
+	var refs []reference
+	collect := func(files []*ast.File, info *types.Info) {
+		for _, file := range files {
+			for _, decl := range file.Decls {
+				caller := ""
+				if fn, ok := decl.(*ast.FuncDecl); ok {
+					caller = funcName(fn)
+				}
+				calls := make(map[*ast.Ident]bool)
+				ast.Inspect(decl, func(node ast.Node) bool {
+					switch node := node.(type) {
+					case *ast.CallExpr:
+						if ident := calledIdent(node.Fun); ident != nil {
+							calls[ident] = true
+						}
+					case *ast.Ident:
+						obj := info.Uses[node]
+						if obj == nil || obj.Name() != target.Name() || l.objectKey(obj) != key {
+							return true
+						}
+						position := l.fset.Position(node.Pos())
+						id := position.String()
+						if seen[id] {
+							return true
+						}
+						seen[id] = true
+						refs = append(refs, reference{position: position, caller: caller, call: calls[node]})
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	// Only packages importing the declaring package can refer to the symbol. Test files
+	// are checked with the package they belong to, which also covers its other files.
+	for _, p := range packages {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		own := p.importPath == declaring
+		if len(p.testFiles) > 0 && (own || imports(p.files, declaring) || imports(p.testFiles, declaring)) {
+			files := append(append([]*ast.File{}, p.files...), p.testFiles...)
+			_, info := l.checkFiles(p.importPath, files)
+			collect(files, info)
+		} else if own || imports(p.files, declaring) {
+			if err := l.check(p); err == nil {
+				collect(p.files, p.info)
+			}
+		}
+		if imports(p.xtestFiles, declaring) {
+			_, info := l.checkFiles(p.importPath+"_test", p.xtestFiles)
+			collect(p.xtestFiles, info)
+		}
+	}
+
+	header := fmt.Sprintf("%s, declared at %s", describe(target, qualifier(target.Pkg())), l.position(target.Pos()))
+	if len(refs) == 0 {
+		return fmt.Sprintf("No references to %s\n", header), nil
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].position.Filename != refs[j].position.Filename {
+			return refs[i].position.Filename < refs[j].position.Filename
+		}
+		return refs[i].position.Offset < refs[j].position.Offset
+	})
+
+	files := make(map[string]bool)
+	for _, ref := range refs {
+		files[ref.position.Filename] = true
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("References to %s: %d in %d files\n", header, len(refs), len(files)))
+
+	var callers []string
+	callerSeen := make(map[string]bool)
+	lastFile := ""
+	for i, ref := range refs {
+		if ref.call && ref.caller != "" {
+			caller := fmt.Sprintf("%s (%s)", ref.caller, l.relative(ref.position.Filename))
+			if !callerSeen[caller] {
+				callerSeen[caller] = true
+				callers = append(callers, caller)
+			}
+		}
+		if i >= maxReferences {
+			continue
+		}
+		if ref.position.Filename != lastFile {
+			lastFile = ref.position.Filename
+			b.WriteString("\n" + l.relative(lastFile) + "\n")
+		}
+		where := "package level"
+		if ref.caller != "" {
+			where = "in " + ref.caller
+		}
+		text := ""
+		if lines := l.lines(ref.position.Filename); ref.position.Line <= len(lines) {
+			text = strings.TrimSpace(lines[ref.position.Line-1])
+			if len(text) > 120 {
+				text = text[:120] + "…"
+			}
+		}
+		b.WriteString(fmt.Sprintf("  %d %s: %s\n", ref.position.Line, where, text))
+	}
+	if len(refs) > maxReferences {
+		b.WriteString(fmt.Sprintf("\n[%d more references not shown]\n", len(refs)-maxReferences))
+	}
+
+	if len(callers) > 0 {
+		b.WriteString(fmt.Sprintf("\nCallers (%d):\n", len(callers)))
+		for _, caller := range callers {
+			b.WriteString("  " + caller + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// funcName names a function declaration like it is called, with the receiver type for methods
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	receiver := fn.Recv.List[0].Type
+	pointer := ""
+	if star, ok := receiver.(*ast.StarExpr); ok {
+		receiver, pointer = star.X, "*"
+	}
+	// Drop the type parameters of generic receivers
+	switch index := receiver.(type) {
+	case *ast.IndexExpr:
+		receiver = index.X
+	case *ast.IndexListExpr:
+		receiver = index.X
+	}
+	if ident, ok := receiver.(*ast.Ident); ok {
+		return fmt.Sprintf("(%s%s).%s", pointer, ident.Name, fn.Name.Name)
+	}
+	return fn.Name.Name
+}
+
+// calledIdent returns the identifier naming the function of a call, if any
+func calledIdent(fun ast.Expr) *ast.Ident {
+	for {
+		switch expr := fun.(type) {
+		case *ast.ParenExpr:
+			fun = expr.X
+		case *ast.IndexExpr:
+			fun = expr.X
+		case *ast.IndexListExpr:
+			fun = expr.X
+		case *ast.SelectorExpr:
+			return expr.Sel
+		case *ast.Ident:
+			return expr
+		default:
+			return nil
+		}
+	}
+}
+
+// methods shows the method set of a type, including promoted methods, and the interfaces
+// it implements. For an interface, it shows the types of the module implementing it instead.
+func (l *loader) methods(dir, symbol string) (string, error) {
+	obj, err := l.resolveOne(dir, symbol)
+	if err != nil {
+		return "", err
+	}
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		return "", fmt.Errorf("%s is not a type", symbol)
+	}
+	T := typeName.Type()
+	qualify := qualifier(typeName.Pkg())
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s  // %s\n", describe(typeName, qualify), l.position(typeName.Pos())))
+
+	isInterface := types.IsInterface(T)
+	valueMethods := types.NewMethodSet(T)
+	methodSet := valueMethods
+	if !isInterface {
+		methodSet = types.NewMethodSet(types.NewPointer(T))
+	}
+	if methodSet.Len() == 0 {
+		b.WriteString("\nNo methods\n")
+	} else {
+		b.WriteString(fmt.Sprintf("\nMethods (%d):\n", methodSet.Len()))
+	}
+	for i := 0; i < methodSet.Len(); i++ {
+		selection := methodSet.At(i)
+		method := selection.Obj()
+		var notes []string
+		if !isInterface && valueMethods.Lookup(method.Pkg(), method.Name()) == nil {
+			notes = append(notes, "pointer receiver")
+		}
+		if path := embeddedPath(T, selection.Index()); path != "" {
+			notes = append(notes, "promoted from "+path)
+		}
+		line := "  " + describe(method, qualify)
+		if len(notes) > 0 {
+			line += " [" + strings.Join(notes, ", ") + "]"
+		}
+		b.WriteString(fmt.Sprintf("%s  // %s\n", line, l.position(method.Pos())))
+	}
+
+	// Compare against every named type of the module, checked from source
+	packages, err := l.loadAll()
+	if err != nil {
+		return "", err
+	}
+	var candidates []*types.TypeName
+	for _, p := range packages {
+		if err := l.check(p); err != nil {
+			continue
+		}
+		scope := p.types.Scope()
+		for _, name := range scope.Names() {
+			if candidate, ok := scope.Lookup(name).(*types.TypeName); ok && !candidate.IsAlias() && candidate != typeName {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	var related []string
+	if isInterface {
+		iface := T.Underlying().(*types.Interface)
+		for _, candidate := range candidates {
+			if types.IsInterface(candidate.Type()) || isGeneric(candidate) {
+				continue
+			}
+			if name := implementsAs(candidate.Type(), iface, qualifiedName(candidate, qualify)); name != "" {
+				related = append(related, fmt.Sprintf("%s  // %s", name, l.position(candidate.Pos())))
+			}
+		}
+		writeList(&b, "Implemented by", related)
+		return b.String(), nil
+	}
+
+	if isGeneric(typeName) {
+		return b.String(), nil
+	}
+	for _, known := range wellKnownInterfaces {
+		if imported, err := l.Import(known.path); err == nil {
+			if candidate, ok := imported.Scope().Lookup(known.name).(*types.TypeName); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	candidates = append(candidates, types.Universe.Lookup("error").(*types.TypeName))
+	for _, candidate := range candidates {
+		iface, ok := candidate.Type().Underlying().(*types.Interface)
+		if !ok || iface.Empty() || isGeneric(candidate) {
+			continue
+		}
+		if implementsAs(T, iface, typeName.Name()) == "" {
+			continue
+		}
+		name := qualifiedName(candidate, qualify)
+		if !types.Implements(T, iface) {
+			name += " (as *" + typeName.Name() + ")"
+		}
+		related = append(related, name)
+	}
+	writeList(&b, "Implements", related)
+	return b.String(), nil
+}
+
+// implementsAs returns how a type implements an interface: as itself, as a pointer, or not at all
+func implementsAs(T types.Type, iface *types.Interface, name string) string {
+	switch {
+	case types.Implements(T, iface):
+		return name
+	case types.Implements(types.NewPointer(T), iface):
+		return "*" + name
+	default:
+		return ""
+	}
+}
+
+// qualifiedName names a type the way the qualifier writes it
+func qualifiedName(typeName *types.TypeName, qualify types.Qualifier) string {
+	if typeName.Pkg() == nil {
+		return typeName.Name()
+	}
+	if prefix := qualify(typeName.Pkg()); prefix != "" {
+		return prefix + "." + typeName.Name()
+	}
+	return typeName.Name()
+}
+
+func isGeneric(typeName *types.TypeName) bool {
+	named, ok := typeName.Type().(*types.Named)
+	return ok && named.TypeParams().Len() > 0
+}
+
+// embeddedPath names the embedded fields a promoted method is reached through
+func embeddedPath(T types.Type, index []int) string {
+	var path []string
+	for _, i := range index[:len(index)-1] {
+		if pointer, ok := T.Underlying().(*types.Pointer); ok {
+			T = pointer.Elem()
+		}
+		structType, ok := T.Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+		field := structType.Field(i)
+		path = append(path, field.Name())
+		T = field.Type()
+	}
+	return strings.Join(path, ".")
+}
+
+func writeList(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(lines)))
+	for _, line := range lines {
+		b.WriteString("  " + line + "\n")
+	}
+}
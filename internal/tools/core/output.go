@@ -0,0 +1,34 @@
+package core
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxOutput caps the size of the output a tool returns to the model
+const MaxOutput = 20000
+
+// Truncate returns s cut to at most n bytes, without splitting a UTF-8 character
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// TruncateOutput caps output at MaxOutput, recording a step and appending a note when it
+// was cut. hint tells the model how to ask for less and may be empty.
+func (r *ToolExecutionResult) TruncateOutput(output, hint string) string {
+	if len(output) <= MaxOutput {
+		return output
+	}
+	r.AddStep(fmt.Sprintf("Output truncated from %d to %d characters", len(output), MaxOutput))
+	note := fmt.Sprintf("Output truncated at %d characters", MaxOutput)
+	if hint != "" {
+		note += ", " + hint
+	}
+	return Truncate(output, MaxOutput) + "\n\n[" + note + "]"
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GetString returns a string parameter, or def if it is missing
+func GetString(args map[string]interface{}, name string, def string) (string, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return def, nil
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	return str, nil
+}
+
+// GetInt returns an integer parameter, or def if it is missing.
+// JSON numbers arrive as float64, and some models send numbers as strings, so both are accepted.
+func GetInt(args map[string]interface{}, name string, def int) (int, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return def, nil
+	}
+
+	switch v := val.(type) {
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer", name)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+}
+
+// GetBool returns a boolean parameter, or def if it is missing
+func GetBool(args map[string]interface{}, name string, def bool) (bool, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return def, nil
+	}
+
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if v == "" {
+			return def, nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("%s must be a boolean", name)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+}
+
+// GetStringSlice returns a list of strings parameter, or nil if it is missing.
+// A single string is accepted as a list of one, since models sometimes send one.
+func GetStringSlice(args map[string]interface{}, name string) ([]string, error) {
+	val, ok := args[name]
+	if !ok || val == nil {
+		return nil, nil
+	}
+
+	switch v := val.(type) {
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", name)
+			}
+			items = append(items, str)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("%s must be a list of strings", name)
+	}
+}
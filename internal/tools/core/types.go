@@ -8,6 +8,8 @@ type Parameter struct {
 	Description string      `json:"description"`
 	Required    bool        `json:"required"`
 	Default     interface{} `json:"default,omitempty"`
+	// Items is the JSON schema of the elements when Type is "array"
+	Items map[string]interface{} `json:"items,omitempty"`
 }
 
 // Tool is the interface for tools that can be used by LLMs
@@ -33,6 +35,8 @@ type ToolExecutionResult struct {
 	ToolMethod         string   `json:"tool_method,omitempty"`
 	ToolExecutionSteps []string `json:"tool_execution_steps,omitempty"`
 	Output             string   `json:"output"`
+	// Display is shown to the user after the steps, for results meant to be read by them like a checklist
+	Display string `json:"display,omitempty"`
 }
 
 // AddStep adds an execution step to the result
@@ -42,3 +46,16 @@ func (r *ToolExecutionResult) AddStep(step string) {
 
 // Factory is a function type that creates new tools
 type Factory func() Tool
+
+// ConfirmationChecker is implemented by tools that only need confirmation for some calls,
+// for example a tool with both read-only and mutating operations
+type ConfirmationChecker interface {
+	// NeedsConfirmation returns true if the call with the given parameters should be confirmed by the user
+	NeedsConfirmation(params map[string]interface{}) bool
+}
+
+// DiffPreviewer is implemented by tools that can show the change a call would make before it is executed
+type DiffPreviewer interface {
+	// PreviewDiff returns a unified diff of the change the call would make, or an empty string if there is nothing to show
+	PreviewDiff(params map[string]interface{}) (string, error)
+}
@@ -0,0 +1,199 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxFileSize caps the size of the files the tool loads
+	maxFileSize = 50 * 1024 * 1024
+)
+
+// Tool queries structured data files with jq-like expressions
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new data tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'query' (evaluate expression and return only what it matches), 'schema' (keys and types of the data, or of what expression matches, without values)",
+			Required:    true,
+		},
+		"path": {
+			Type:        "string",
+			Description: "Path to a JSON, JSON lines, YAML, TOML, CSV or TSV file",
+			Required:    true,
+		},
+		"expression": {
+			Type:        "string",
+			Description: "jq-like expression, like '.items[0].name', '.users[] | select(.age > 30) | {name, email}', '[.deps[] | .version] | unique', '.. | .port? | select(. != null)'. Supports .key, .[n], .[a:b], .[], .., |, ',', ?, (), [..], {..}, ==, !=, <, <=, >, >=, and, or, //, and select, map, keys, length, type, has, not, first, last, sort, sort_by, unique, min, max, add, to_entries, test (default: .)",
+			Required:    false,
+		},
+		"format": {
+			Type:        "string",
+			Description: "Format of the file: 'json', 'yaml', 'toml', 'csv' or 'tsv' (default: from the file extension)",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "data",
+		description: "Queries JSON, YAML, TOML and CSV files with jq-like expressions, returning only the matched values instead of the whole file, and summarizes their structure as keys and types without values",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false as the tool only reads files
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// Execute runs the data operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+	if operation != "query" && operation != "schema" {
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+
+	path, err := core.GetString(args, "path", "")
+	if err != nil {
+		return result, err
+	}
+	if path == "" {
+		return result, fmt.Errorf("path parameter is required")
+	}
+	expression, err := core.GetString(args, "expression", ".")
+	if err != nil {
+		return result, err
+	}
+	format, err := core.GetString(args, "format", "")
+	if err != nil {
+		return result, err
+	}
+
+	// Parse first, a typo in the expression shouldn't cost reading a large file
+	filter, err := parse(expression)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Invalid expression: %v", err))
+		return result, fmt.Errorf("invalid expression: %w", err)
+	}
+
+	absPath, err := workspace.Current().Check(path, workspace.Read)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Path check failed: %v", err))
+		return result, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, fmt.Errorf("failed to access %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return result, fmt.Errorf("%s is a directory, not a file", path)
+	}
+	if info.Size() > maxFileSize {
+		return result, fmt.Errorf("%s is too large (%d bytes, at most %d)", path, info.Size(), maxFileSize)
+	}
+
+	if format == "" {
+		format = detectFormat(absPath)
+		if format == "" {
+			return result, fmt.Errorf("can't tell the format of %s from its extension, set format", path)
+		}
+	}
+	value, err := load(absPath, format)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+	result.AddStep(fmt.Sprintf("Loaded %s as %s", absPath, format))
+
+	matches, err := filter(value)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Evaluation failed: %v", err))
+		return result, fmt.Errorf("failed to evaluate %s: %w", expression, err)
+	}
+	result.AddStep(fmt.Sprintf("Expression matched %d values", len(matches)))
+
+	var output string
+	if operation == "schema" {
+		if len(matches) == 1 {
+			output = summarize(matches[0])
+		} else {
+			// Several matches are summarized like the elements of an array
+			output = summarize(matches)
+		}
+	} else {
+		output, err = formatMatches(matches)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error: %v", err))
+			return result, err
+		}
+	}
+
+	output = result.TruncateOutput(output, "narrow down the expression")
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
+
+// formatMatches returns the matched values as indented JSON, one after the other
+func formatMatches(matches []interface{}) (string, error) {
+	if len(matches) == 0 {
+		return "No matches", nil
+	}
+	var b strings.Builder
+	for _, match := range matches {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(match); err != nil {
+			return "", fmt.Errorf("failed to format result: %w", err)
+		}
+		b.Write(buf.Bytes())
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,848 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+// builtins are the functions without arguments, applied to their input
+var builtins map[string]filter
+
+// builtinsWithArgument are the functions taking an expression as argument
+var builtinsWithArgument map[string]func(argument filter) filter
+
+func init() {
+	builtins = map[string]filter{
+		"keys":       each(keys),
+		"length":     each(length),
+		"type":       each(func(v interface{}) (interface{}, error) { return typeName(v), nil }),
+		"not":        each(func(v interface{}) (interface{}, error) { return !truthy(v), nil }),
+		"first":      each(func(v interface{}) (interface{}, error) { return indexValue(v, int64(0)) }),
+		"last":       each(func(v interface{}) (interface{}, error) { return indexValue(v, int64(-1)) }),
+		"sort":       each(sortValues),
+		"unique":     each(unique),
+		"min":        each(func(v interface{}) (interface{}, error) { return extreme(v, -1) }),
+		"max":        each(func(v interface{}) (interface{}, error) { return extreme(v, 1) }),
+		"add":        each(add),
+		"to_entries": each(toEntries),
+	}
+	builtinsWithArgument = map[string]func(filter) filter{
+		"select":  selectValues,
+		"map":     mapValues,
+		"has":     has,
+		"sort_by": sortBy,
+		"test":    test,
+	}
+}
+
+// each turns a function of a single value into a filter
+func each(fn func(interface{}) (interface{}, error)) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		value, err := fn(input)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+}
+
+func identity(input interface{}) ([]interface{}, error) {
+	return []interface{}{input}, nil
+}
+
+func literal(value interface{}) filter {
+	return func(interface{}) ([]interface{}, error) {
+		return []interface{}{value}, nil
+	}
+}
+
+// pipe feeds every value of left into right
+func pipe(left, right filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			results, err := right(value)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, results...)
+		}
+		return outputs, nil
+	}
+}
+
+// concat yields the values of left, then those of right
+func concat(left, right filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		more, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+		return append(values, more...), nil
+	}
+}
+
+// alternative yields the values of left other than false and null, or right if there are none
+func alternative(left, right filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, _ := left(input)
+		var kept []interface{}
+		for _, value := range values {
+			if truthy(value) {
+				kept = append(kept, value)
+			}
+		}
+		if len(kept) > 0 {
+			return kept, nil
+		}
+		return right(input)
+	}
+}
+
+// logical combines conditions with or when any is true, and otherwise
+func logical(left, right filter, any bool) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			// Short-circuit like jq: true or .., false and ..
+			if truthy(value) == any {
+				outputs = append(outputs, any)
+				continue
+			}
+			results, err := right(input)
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range results {
+				outputs = append(outputs, truthy(result))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// compare yields the comparison of every value of left with every value of right
+func compare(left, right filter, op string) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		lefts, err := left(input)
+		if err != nil {
+			return nil, err
+		}
+		rights, err := right(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, l := range lefts {
+			for _, r := range rights {
+				c := compareValues(l, r)
+				var result bool
+				switch op {
+				case "==":
+					result = c == 0
+				case "!=":
+					result = c != 0
+				case "<":
+					result = c < 0
+				case "<=":
+					result = c <= 0
+				case ">":
+					result = c > 0
+				case ">=":
+					result = c >= 0
+				}
+				outputs = append(outputs, result)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// field yields the key of every value of base
+func field(base filter, key string) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := base(input)
+		if err != nil {
+			return nil, err
+		}
+		outputs := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			result, err := indexValue(value, key)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, result)
+		}
+		return outputs, nil
+	}
+}
+
+// index yields base[key], the key evaluated against the input like in jq
+func index(base, key filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := base(input)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := key(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			for _, k := range keys {
+				result, err := indexValue(value, k)
+				if err != nil {
+					return nil, err
+				}
+				outputs = append(outputs, result)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// indexValue returns an object key or an array element, null when it is missing
+func indexValue(value, key interface{}) (interface{}, error) {
+	switch k := key.(type) {
+	case string:
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case map[string]interface{}:
+			return v[k], nil
+		}
+		return nil, fmt.Errorf("cannot index %s with %q", typeName(value), k)
+	case int64, float64:
+		i, ok := integer(k)
+		if !ok {
+			return nil, fmt.Errorf("cannot index with %v, indexes are integers", k)
+		}
+		switch v := value.(type) {
+		case nil:
+			return nil, nil
+		case []interface{}:
+			if i < 0 {
+				i += int64(len(v))
+			}
+			if i < 0 || i >= int64(len(v)) {
+				return nil, nil
+			}
+			return v[i], nil
+		}
+		return nil, fmt.Errorf("cannot index %s with a number", typeName(value))
+	}
+	return nil, fmt.Errorf("cannot index %s with %s", typeName(value), typeName(key))
+}
+
+// slice yields base[from:to] of arrays and strings, either bound may be left out
+func slice(base, from, to filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := base(input)
+		if err != nil {
+			return nil, err
+		}
+		bound := func(f filter) (*int64, error) {
+			if f == nil {
+				return nil, nil
+			}
+			results, err := f(input)
+			if err != nil {
+				return nil, err
+			}
+			if len(results) != 1 {
+				return nil, fmt.Errorf("slice bounds must be single numbers")
+			}
+			if results[0] == nil {
+				return nil, nil
+			}
+			n, ok := integer(results[0])
+			if !ok {
+				return nil, fmt.Errorf("slice bounds must be integers, not %s", typeName(results[0]))
+			}
+			return &n, nil
+		}
+		start, err := bound(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := bound(to)
+		if err != nil {
+			return nil, err
+		}
+
+		var outputs []interface{}
+		for _, value := range values {
+			switch v := value.(type) {
+			case nil:
+				outputs = append(outputs, nil)
+			case []interface{}:
+				i, j := clamp(start, end, len(v))
+				outputs = append(outputs, v[i:j])
+			case string:
+				runes := []rune(v)
+				i, j := clamp(start, end, len(runes))
+				outputs = append(outputs, string(runes[i:j]))
+			default:
+				return nil, fmt.Errorf("cannot slice %s", typeName(value))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// clamp resolves slice bounds, negative ones counting from the end
+func clamp(start, end *int64, length int) (int, int) {
+	resolve := func(bound *int64, def int) int {
+		if bound == nil {
+			return def
+		}
+		n := *bound
+		if n < 0 {
+			n += int64(length)
+		}
+		return int(math.Max(0, math.Min(float64(n), float64(length))))
+	}
+	i, j := resolve(start, 0), resolve(end, length)
+	if j < i {
+		j = i
+	}
+	return i, j
+}
+
+// iterate yields the elements of arrays and the values of objects
+func iterate(base filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := base(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, value := range values {
+			elements, err := elementsOf(value)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, elements...)
+		}
+		return outputs, nil
+	}
+}
+
+// elementsOf returns the elements of an array, or the values of an object by key
+func elementsOf(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case map[string]interface{}:
+		elements := make([]interface{}, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			elements = append(elements, v[key])
+		}
+		return elements, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", typeName(value))
+}
+
+// try drops the errors of base, .a? yields nothing for values without keys
+func try(base filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := base(input)
+		if err != nil {
+			return nil, nil
+		}
+		return values, nil
+	}
+}
+
+// recurse yields a value and everything it contains, depth first
+func recurse(input interface{}) ([]interface{}, error) {
+	var outputs []interface{}
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		outputs = append(outputs, value)
+		if elements, err := elementsOf(value); err == nil {
+			for _, element := range elements {
+				walk(element)
+			}
+		}
+	}
+	walk(input)
+	return outputs, nil
+}
+
+// collect yields a single array of all the values of f
+func collect(f filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		values, err := f(input)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			values = []interface{}{}
+		}
+		return []interface{}{values}, nil
+	}
+}
+
+// construct yields objects, one for every combination of the values of its keys and values
+func construct(keys, values []filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		objects := []map[string]interface{}{{}}
+		for i := range keys {
+			names, err := keys[i](input)
+			if err != nil {
+				return nil, err
+			}
+			results, err := values[i](input)
+			if err != nil {
+				return nil, err
+			}
+			var extended []map[string]interface{}
+			for _, object := range objects {
+				for _, name := range names {
+					key, ok := name.(string)
+					if !ok {
+						return nil, fmt.Errorf("object keys must be strings, not %s", typeName(name))
+					}
+					for _, result := range results {
+						copied := make(map[string]interface{}, len(object)+1)
+						for k, v := range object {
+							copied[k] = v
+						}
+						copied[key] = result
+						extended = append(extended, copied)
+					}
+				}
+			}
+			objects = extended
+		}
+		outputs := make([]interface{}, len(objects))
+		for i, object := range objects {
+			outputs[i] = object
+		}
+		return outputs, nil
+	}
+}
+
+// selectValues yields the input when the condition is true
+func selectValues(condition filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		results, err := condition(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, result := range results {
+			if truthy(result) {
+				outputs = append(outputs, input)
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// mapValues applies f to the elements of an array, or the values of an object
+func mapValues(f filter) filter {
+	return each(func(input interface{}) (interface{}, error) {
+		elements, err := elementsOf(input)
+		if err != nil {
+			return nil, err
+		}
+		outputs := []interface{}{}
+		for _, element := range elements {
+			results, err := f(element)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, results...)
+		}
+		return outputs, nil
+	})
+}
+
+// has yields whether an object has a key, or an array an index
+func has(key filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		keys, err := key(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, k := range keys {
+			switch v := input.(type) {
+			case map[string]interface{}:
+				name, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("cannot check whether an object has a %s key", typeName(k))
+				}
+				_, found := v[name]
+				outputs = append(outputs, found)
+			case []interface{}:
+				i, ok := integer(k)
+				if !ok {
+					return nil, fmt.Errorf("cannot check whether an array has a %s index", typeName(k))
+				}
+				outputs = append(outputs, i >= 0 && i < int64(len(v)))
+			default:
+				return nil, fmt.Errorf("cannot check whether %s has a key", typeName(input))
+			}
+		}
+		return outputs, nil
+	}
+}
+
+// sortBy sorts an array by the values of f for each element
+func sortBy(f filter) filter {
+	return each(func(input interface{}) (interface{}, error) {
+		elements, ok := input.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot sort %s, only arrays", typeName(input))
+		}
+		type keyed struct {
+			key   interface{}
+			value interface{}
+		}
+		items := make([]keyed, len(elements))
+		for i, element := range elements {
+			results, err := f(element)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = keyed{key: results, value: element}
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return compareValues(items[i].key, items[j].key) < 0
+		})
+		sorted := make([]interface{}, len(items))
+		for i, item := range items {
+			sorted[i] = item.value
+		}
+		return sorted, nil
+	})
+}
+
+// test yields whether a string matches a regular expression
+func test(pattern filter) filter {
+	return func(input interface{}) ([]interface{}, error) {
+		text, ok := input.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot test %s against a regular expression, only strings", typeName(input))
+		}
+		patterns, err := pattern(input)
+		if err != nil {
+			return nil, err
+		}
+		var outputs []interface{}
+		for _, p := range patterns {
+			expr, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("regular expressions are strings, not %s", typeName(p))
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression: %w", err)
+			}
+			outputs = append(outputs, re.MatchString(text))
+		}
+		return outputs, nil
+	}
+}
+
+func keys(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		names := sortedKeys(v)
+		result := make([]interface{}, len(names))
+		for i, name := range names {
+			result[i] = name
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i := range v {
+			result[i] = int64(i)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", typeName(value))
+}
+
+func length(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return int64(0), nil
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return int64(len(v)), nil
+	case map[string]interface{}:
+		return int64(len(v)), nil
+	case int64:
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	}
+	return nil, fmt.Errorf("%s has no length", typeName(value))
+}
+
+func sortValues(value interface{}) (interface{}, error) {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot sort %s, only arrays", typeName(value))
+	}
+	sorted := append([]interface{}{}, elements...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareValues(sorted[i], sorted[j]) < 0
+	})
+	return sorted, nil
+}
+
+func unique(value interface{}) (interface{}, error) {
+	sorted, err := sortValues(value)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	for _, element := range sorted.([]interface{}) {
+		if len(result) == 0 || compareValues(result[len(result)-1], element) != 0 {
+			result = append(result, element)
+		}
+	}
+	if result == nil {
+		result = []interface{}{}
+	}
+	return result, nil
+}
+
+// extreme returns the smallest element of an array for direction -1, the largest for 1
+func extreme(value interface{}, direction int) (interface{}, error) {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot find the minimum or maximum of %s, only arrays", typeName(value))
+	}
+	var result interface{}
+	for i, element := range elements {
+		if i == 0 || compareValues(element, result)*direction > 0 {
+			result = element
+		}
+	}
+	return result, nil
+}
+
+// add sums numbers, or joins strings, arrays or objects
+func add(value interface{}) (interface{}, error) {
+	elements, err := elementsOf(value)
+	if err != nil {
+		return nil, err
+	}
+	var sum interface{}
+	for _, element := range elements {
+		switch s := sum.(type) {
+		case nil:
+			sum = element
+			continue
+		case int64:
+			if e, ok := element.(int64); ok {
+				sum = s + e
+				continue
+			}
+		case string:
+			if e, ok := element.(string); ok {
+				sum = s + e
+				continue
+			}
+		case []interface{}:
+			if e, ok := element.([]interface{}); ok {
+				sum = append(append([]interface{}{}, s...), e...)
+				continue
+			}
+		case map[string]interface{}:
+			if e, ok := element.(map[string]interface{}); ok {
+				merged := make(map[string]interface{}, len(s)+len(e))
+				for k, v := range s {
+					merged[k] = v
+				}
+				for k, v := range e {
+					merged[k] = v
+				}
+				sum = merged
+				continue
+			}
+		}
+		if element == nil {
+			continue
+		}
+		a, aok := number(sum)
+		b, bok := number(element)
+		if !aok || !bok {
+			return nil, fmt.Errorf("cannot add %s and %s", typeName(sum), typeName(element))
+		}
+		sum = a + b
+	}
+	return sum, nil
+}
+
+func toEntries(value interface{}) (interface{}, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot list the entries of %s, only objects", typeName(value))
+	}
+	entries := make([]interface{}, 0, len(object))
+	for _, key := range sortedKeys(object) {
+		entries = append(entries, map[string]interface{}{"key": key, "value": object[key]})
+	}
+	return entries, nil
+}
+
+// truthy reports whether a value counts as true: everything but false and null
+func truthy(value interface{}) bool {
+	return value != nil && value != false
+}
+
+// typeName returns the jq name of the type of a value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// number returns a number as a float64
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// integer returns a number without a fraction as an int64
+func integer(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// compareValues orders values like jq: null, false, true, numbers, strings, arrays, objects
+func compareValues(a, b interface{}) int {
+	rank := func(value interface{}) int {
+		switch v := value.(type) {
+		case nil:
+			return 0
+		case bool:
+			if v {
+				return 2
+			}
+			return 1
+		case int64, float64:
+			return 3
+		case string:
+			return 4
+		case []interface{}:
+			return 5
+		case map[string]interface{}:
+			return 6
+		}
+		return 7
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			return order(x < y, x > y)
+		}
+		y, _ := number(b)
+		return order(float64(x) < y, float64(x) > y)
+	case float64:
+		y, _ := number(b)
+		return order(x < y, x > y)
+	case string:
+		y := b.(string)
+		return order(x < y, x > y)
+	case []interface{}:
+		y := b.([]interface{})
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareValues(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return order(len(x) < len(y), len(x) > len(y))
+	case map[string]interface{}:
+		y := b.(map[string]interface{})
+		xk, yk := sortedKeys(x), sortedKeys(y)
+		for i := 0; i < len(xk) && i < len(yk); i++ {
+			if xk[i] != yk[i] {
+				return order(xk[i] < yk[i], true)
+			}
+		}
+		if len(xk) != len(yk) {
+			return order(len(xk) < len(yk), true)
+		}
+		for _, key := range xk {
+			if c := compareValues(x[key], y[key]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,470 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filter evaluates an expression against an input value. Like jq, an expression
+// produces any number of values: .[] yields every element, select none or one.
+type filter func(input interface{}) ([]interface{}, error)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenDot
+	tokenRecurse
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenPunct // [ ] ( ) { } | , : ; ?
+	tokenOp    // == != < <= > >= //
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // of strings and numbers
+	pos   int
+}
+
+// describe returns a token as shown in error messages
+func (t token) describe() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q at position %d", t.text, t.pos+1)
+}
+
+// lex splits an expression into tokens
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	afterDot := false
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			afterDot = false
+			continue
+		case c == '.':
+			if i+1 < len(expr) && expr[i+1] == '.' {
+				tokens = append(tokens, token{kind: tokenRecurse, text: "..", pos: start})
+				i += 2
+				afterDot = false
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenDot, text: ".", pos: start})
+			i++
+			afterDot = true
+			continue
+		case isIdentStart(c):
+			i++
+			// Keys like app-name are common in configs, allow dashes after a dot
+			for i < len(expr) && (isIdentStart(expr[i]) || isDigit(expr[i]) || (afterDot && expr[i] == '-')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i], pos: start})
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.' || expr[i] == 'e' || expr[i] == 'E' ||
+				((expr[i] == '+' || expr[i] == '-') && (expr[i-1] == 'e' || expr[i-1] == 'E'))) {
+				i++
+			}
+			text := expr[start:i]
+			var value interface{}
+			if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+				value = n
+			} else if f, err := strconv.ParseFloat(text, 64); err == nil {
+				value = f
+			} else {
+				return nil, fmt.Errorf("invalid number %q at position %d", text, start+1)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, pos: start})
+		case c == '"':
+			i++
+			for i < len(expr) && expr[i] != '"' {
+				if expr[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			i++
+			var value string
+			if err := json.Unmarshal([]byte(expr[start:i]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s at position %d", expr[start:i], start+1)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expr[start:i], value: value, pos: start})
+		case strings.ContainsRune("[](){}|,:;?", rune(c)):
+			i++
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), pos: start})
+		default:
+			op := ""
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "//", "<", ">"} {
+				if strings.HasPrefix(expr[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at position %d", string(c), start+1)
+			}
+			i += len(op)
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: start})
+		}
+		afterDot = false
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser builds filters from tokens by recursive descent. From the loosest to the
+// tightest binding: |  ,  //  or  and  comparisons, then terms with their suffixes.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse compiles an expression into a filter
+func parse(expr string) (filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		expr = "."
+	}
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	f, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s", next.describe())
+	}
+	return f, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the punctuation or operator given
+func (p *parser) accept(text string) bool {
+	t := p.peek()
+	if (t.kind == tokenPunct || t.kind == tokenOp || t.kind == tokenIdent) && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return fmt.Errorf("expected %q, found %s", text, p.peek().describe())
+	}
+	return nil
+}
+
+func (p *parser) pipe() (filter, error) {
+	left, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.comma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipe(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) comma() (filter, error) {
+	left, err := p.alternative()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(",") {
+		right, err := p.alternative()
+		if err != nil {
+			return nil, err
+		}
+		left = concat(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) alternative() (filter, error) {
+	left, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("//") {
+		right, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		left = alternative(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) or() (filter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logical(left, right, true)
+	}
+	return left, nil
+}
+
+func (p *parser) and() (filter, error) {
+	left, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logical(left, right, false)
+	}
+	return left, nil
+}
+
+func (p *parser) comparison() (filter, error) {
+	left, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokenOp || t.text == "//" {
+		return left, nil
+	}
+	p.next()
+	right, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	return compare(left, right, t.text), nil
+}
+
+// postfix parses a term followed by any number of .key, [..] and ? suffixes
+func (p *parser) postfix() (filter, error) {
+	f, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenDot && p.tokens[p.pos+1].kind == tokenPunct && p.tokens[p.pos+1].text == "[":
+			// .a.[0] is the same as .a[0]
+			p.next()
+		case t.kind == tokenDot:
+			p.next()
+			key := p.next()
+			if key.kind != tokenIdent && key.kind != tokenString {
+				return nil, fmt.Errorf("expected a key after \".\", found %s", key.describe())
+			}
+			f = field(f, keyOf(key))
+		case t.kind == tokenPunct && t.text == "[":
+			p.next()
+			if f, err = p.brackets(f); err != nil {
+				return nil, err
+			}
+		case t.kind == tokenPunct && t.text == "?":
+			p.next()
+			f = try(f)
+		default:
+			return f, nil
+		}
+	}
+}
+
+// brackets parses what follows [ in base[], base[index] and base[from:to]
+func (p *parser) brackets(base filter) (filter, error) {
+	if p.accept("]") {
+		return iterate(base), nil
+	}
+	var from, to filter
+	var err error
+	if !p.accept(":") {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if p.accept("]") {
+			return index(base, from), nil
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+	}
+	if !p.accept("]") {
+		if to, err = p.pipe(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return slice(base, from, to), nil
+}
+
+func (p *parser) term() (filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenDot:
+		next := p.peek()
+		if (next.kind == tokenIdent || next.kind == tokenString) && next.pos == t.pos+1 {
+			p.next()
+			return field(identity, keyOf(next)), nil
+		}
+		return identity, nil
+	case tokenRecurse:
+		return recurse, nil
+	case tokenNumber, tokenString:
+		return literal(t.value), nil
+	case tokenPunct:
+		switch t.text {
+		case "(":
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return f, p.expect(")")
+		case "[":
+			if p.accept("]") {
+				return func(interface{}) ([]interface{}, error) {
+					return []interface{}{[]interface{}{}}, nil
+				}, nil
+			}
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return collect(f), p.expect("]")
+		case "{":
+			return p.object()
+		}
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literal(true), nil
+		case "false":
+			return literal(false), nil
+		case "null":
+			return literal(nil), nil
+		}
+		return p.call(t)
+	}
+	return nil, fmt.Errorf("unexpected %s", t.describe())
+}
+
+// object parses {key: value, key, "key": value, (expression): value} after the {
+func (p *parser) object() (filter, error) {
+	var keys, values []filter
+	if p.accept("}") {
+		return construct(nil, nil), nil
+	}
+	for {
+		t := p.next()
+		var key, value filter
+		switch {
+		case t.kind == tokenIdent || t.kind == tokenString:
+			key = literal(keyOf(t))
+			// {name} is short for {name: .name}
+			value = field(identity, keyOf(t))
+		case t.kind == tokenPunct && t.text == "(":
+			f, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			key = f
+		default:
+			return nil, fmt.Errorf("expected an object key, found %s", t.describe())
+		}
+		if p.accept(":") {
+			f, err := p.alternative()
+			if err != nil {
+				return nil, err
+			}
+			value = f
+		} else if value == nil {
+			return nil, fmt.Errorf("expected \":\" after a computed key, found %s", p.peek().describe())
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if p.accept("}") {
+			return construct(keys, values), nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// call parses a builtin function, with its argument in parentheses if it takes one
+func (p *parser) call(name token) (filter, error) {
+	if builtin, ok := builtins[name.text]; ok {
+		return builtin, nil
+	}
+	builtin, ok := builtinsWithArgument[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s, use a key like .%s to read a field", name.describe(), name.text)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, fmt.Errorf("%s takes an argument: %w", name.text, err)
+	}
+	argument, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return builtin(argument), nil
+}
+
+// keyOf returns the object key named by an identifier or a string token
+func keyOf(t token) string {
+	if t.kind == tokenString {
+		return t.value.(string)
+	}
+	return t.text
+}
@@ -0,0 +1,213 @@
+package data
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// formats maps file extensions to the formats the tool reads
+var formats = map[string]string{
+	".json":    "json",
+	".jsonl":   "json",
+	".ndjson":  "json",
+	".geojson": "json",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+	".toml":    "toml",
+	".csv":     "csv",
+	".tsv":     "tsv",
+}
+
+// detectFormat returns the format of a file from its extension
+func detectFormat(path string) string {
+	return formats[strings.ToLower(filepath.Ext(path))]
+}
+
+// load reads a file into the values the expressions work on: nil, bool, int64,
+// float64, string, []interface{} and map[string]interface{}
+func load(path, format string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var value interface{}
+	switch format {
+	case "json":
+		value, err = loadJSON(data)
+	case "yaml":
+		value, err = loadYAML(data)
+	case "toml":
+		var table map[string]interface{}
+		err = toml.Unmarshal(data, &table)
+		value = table
+	case "csv":
+		value, err = loadCSV(data, ',')
+	case "tsv":
+		value, err = loadCSV(data, '\t')
+	default:
+		return nil, fmt.Errorf("unknown format: %s, use json, yaml, toml, csv or tsv", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s as %s: %w", filepath.Base(path), format, err)
+	}
+	return normalize(value), nil
+}
+
+// loadJSON reads a JSON document. JSON lines files hold one document per line and
+// are read as an array of them.
+func loadJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	switch len(documents) {
+	case 0:
+		return nil, nil
+	case 1:
+		return documents[0], nil
+	}
+	return documents, nil
+}
+
+// loadYAML reads a YAML file, files with several documents are read as an array of them
+func loadYAML(data []byte) (interface{}, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var documents []interface{}
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	switch len(documents) {
+	case 0:
+		return nil, nil
+	case 1:
+		return documents[0], nil
+	}
+	return documents, nil
+}
+
+// loadCSV reads a table with a header row as an array of objects keyed by the header
+func loadCSV(data []byte, separator rune) (interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []interface{}{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[name] = cell(record[i])
+			} else {
+				row[name] = nil
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// cell converts a CSV field to a number when it reads back the same, so ids like
+// 007 and zip codes stay strings
+func cell(field string) interface{} {
+	if n, err := strconv.ParseInt(field, 10, 64); err == nil && strconv.FormatInt(n, 10) == field {
+		return n
+	}
+	if f, err := strconv.ParseFloat(field, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) &&
+		strconv.FormatFloat(f, 'f', -1, 64) == field {
+		return f
+	}
+	switch field {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return field
+}
+
+// normalize converts what the decoders return to the value types of the expressions
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, int64:
+		return v
+	case int:
+		return int64(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return float64(v)
+	case float64:
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		// YAML allows keys that are not strings
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[fmt.Sprint(key)] = normalize(item)
+		}
+		return object
+	case fmt.Stringer:
+		// TOML local dates and times
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
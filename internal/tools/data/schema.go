@@ -0,0 +1,168 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxSchemaDepth caps how deep the summary descends into nested values
+	maxSchemaDepth = 12
+
+	// maxSchemaKeys caps the keys listed for an object, maps keyed by ids can be huge
+	maxSchemaKeys = 100
+)
+
+// shape describes every value seen at one place in the data: the elements of an
+// array share a shape, so the summary of a list of records shows the record once
+type shape struct {
+	count   int             // values seen
+	types   map[string]bool // their type names
+	objects int             // how many of them were objects
+	fields  map[string]*shape
+	order   []string
+	element *shape // of the elements of arrays
+	minLen  int
+	maxLen  int
+	arrays  int // how many of them were arrays
+}
+
+func newShape() *shape {
+	return &shape{types: make(map[string]bool), fields: make(map[string]*shape)}
+}
+
+// add merges a value into the shape
+func (s *shape) add(value interface{}) {
+	s.count++
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.types["object"] = true
+		s.objects++
+		for _, key := range sortedKeys(v) {
+			child, ok := s.fields[key]
+			if !ok {
+				child = newShape()
+				s.fields[key] = child
+				s.order = append(s.order, key)
+			}
+			child.add(v[key])
+		}
+	case []interface{}:
+		s.types["array"] = true
+		if s.arrays == 0 || len(v) < s.minLen {
+			s.minLen = len(v)
+		}
+		if len(v) > s.maxLen {
+			s.maxLen = len(v)
+		}
+		s.arrays++
+		if s.element == nil {
+			s.element = newShape()
+		}
+		for _, element := range v {
+			s.element.add(element)
+		}
+	case int64:
+		s.types["integer"] = true
+	case float64:
+		s.types["number"] = true
+	default:
+		s.types[typeName(value)] = true
+	}
+}
+
+// describe returns the types of the shape, like "string", "integer|null" or "array[3] of object"
+func (s *shape) describe() string {
+	var names []string
+	for name := range s.types {
+		names = append(names, name)
+	}
+	// null last, it usually marks an optional value
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "null") != (names[j] == "null") {
+			return names[j] == "null"
+		}
+		return names[i] < names[j]
+	})
+
+	for i, name := range names {
+		if name != "array" {
+			continue
+		}
+		length := strconv.Itoa(s.minLen)
+		if s.maxLen != s.minLen {
+			length += "-" + strconv.Itoa(s.maxLen)
+		}
+		name = "array[" + length + "]"
+		if s.element != nil && s.element.count > 0 {
+			name += " of " + s.element.describe()
+		}
+		names[i] = name
+	}
+	if len(names) == 0 {
+		return "empty"
+	}
+	return strings.Join(names, "|")
+}
+
+// write prints the fields of the shape, and of the elements of its arrays, below it
+func (s *shape) write(b *strings.Builder, prefix string, depth int) {
+	if depth >= maxSchemaDepth {
+		if len(s.order) > 0 || (s.element != nil && len(s.element.order) > 0) {
+			b.WriteString(strings.Repeat("  ", depth) + "...\n")
+		}
+		return
+	}
+	// An array of objects lists the keys of its elements
+	if len(s.order) == 0 && s.element != nil {
+		if prefix == "" {
+			prefix = "."
+		}
+		s.element.write(b, prefix+"[]", depth)
+		return
+	}
+
+	for i, key := range s.order {
+		if i == maxSchemaKeys {
+			b.WriteString(fmt.Sprintf("%s[%d more keys]\n", strings.Repeat("  ", depth), len(s.order)-i))
+			break
+		}
+		child := s.fields[key]
+		path := prefix + "." + key
+		if !isPlainKey(key) {
+			path = prefix + "." + strconv.Quote(key)
+		}
+		line := fmt.Sprintf("%s%s: %s", strings.Repeat("  ", depth), path, child.describe())
+		if child.count < s.objects {
+			line += fmt.Sprintf(" (in %d of %d)", child.count, s.objects)
+		}
+		b.WriteString(line + "\n")
+		child.write(b, path, depth+1)
+	}
+}
+
+// summarize describes the keys and types of a value without its values
+func summarize(value interface{}) string {
+	s := newShape()
+	s.add(value)
+
+	var b strings.Builder
+	b.WriteString(". : " + s.describe() + "\n")
+	s.write(&b, "", 1)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// isPlainKey reports whether a key can be written as .key in an expression
+func isPlainKey(key string) bool {
+	if key == "" || !isIdentStart(key[0]) {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isIdentStart(key[i]) && !isDigit(key[i]) && key[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
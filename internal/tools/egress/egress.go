@@ -0,0 +1,446 @@
+package egress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxResponse caps the size of a response body when nothing is configured
+const DefaultMaxResponse = 10 * 1024 * 1024
+
+// maxRedirects is the number of redirects a request may follow
+const maxRedirects = 10
+
+// proxyFromEnvironment returns the proxy of a request, from HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+var proxyFromEnvironment = http.ProxyFromEnvironment
+
+// proxyKey is the context key of the address of the proxy a request is sent through
+type proxyKey struct{}
+
+// sharedRanges are address ranges that aren't private by the standard library's
+// definition but still don't belong on the public internet
+var sharedRanges = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+}
+
+// Policy decides which hosts network tools may connect to. Entries of the allow and
+// deny lists are domains, which match the domain and all its subdomains, or IP
+// addresses and CIDR ranges.
+type Policy struct {
+	allowDomains  []string
+	allowPrefixes []netip.Prefix
+	denyDomains   []string
+	denyPrefixes  []netip.Prefix
+	allowPrivate  bool
+	maxResponse   int64
+
+	// confirm asks the user before the first connection to a host, nil means no prompt
+	confirm     func(host string) bool
+	promptMutex sync.Mutex
+
+	mutex    sync.Mutex
+	trusted  map[string]bool // hosts configured by the user, like the search backend
+	approved map[string]bool // hosts the user allowed during this session
+	declined map[string]bool // hosts the user refused during this session
+}
+
+var (
+	currentPolicy = Default()
+	policyMutex   sync.RWMutex
+)
+
+// New creates a policy. When allow has domain entries, only those domains can be
+// reached. Private, loopback and link-local addresses are blocked unless allowPrivate
+// is set or the host is allowed explicitly. Response bodies are capped at maxResponse
+// bytes, DefaultMaxResponse when it is not positive.
+func New(allow, deny []string, allowPrivate bool, maxResponse int64) *Policy {
+	p := &Policy{
+		allowPrivate: allowPrivate,
+		maxResponse:  maxResponse,
+		trusted:      make(map[string]bool),
+		approved:     make(map[string]bool),
+		declined:     make(map[string]bool),
+	}
+	if p.maxResponse <= 0 {
+		p.maxResponse = DefaultMaxResponse
+	}
+
+	p.allowDomains, p.allowPrefixes = parseEntries(allow)
+	p.denyDomains, p.denyPrefixes = parseEntries(deny)
+	return p
+}
+
+// Default returns the policy used when nothing is configured
+func Default() *Policy {
+	return New(nil, nil, false, DefaultMaxResponse)
+}
+
+// Current returns the policy shared by all network tools
+func Current() *Policy {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return currentPolicy
+}
+
+// SetCurrent replaces the policy shared by all network tools
+func SetCurrent(p *Policy) {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	currentPolicy = p
+}
+
+// SetConfirm sets the function that asks the user before the first connection to a
+// host during the session. Hosts on the allow list are never prompted for.
+func (p *Policy) SetConfirm(confirm func(host string) bool) {
+	p.confirm = confirm
+}
+
+// Trust allows a host the user configured elsewhere, such as a self-hosted search
+// backend, without prompting and even when it has a private address
+func (p *Policy) Trust(host string) {
+	if host = normalizeHost(host); host != "" {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		p.trusted[host] = true
+	}
+}
+
+// MaxResponse returns the maximum size of a response body in bytes
+func (p *Policy) MaxResponse() int64 {
+	return p.maxResponse
+}
+
+// CheckURL validates that a request to u may be sent, asking the user the first time
+// a host is contacted. Addresses are checked again when connecting, see DialContext.
+func (p *Policy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("request blocked: unsupported URL scheme %q", u.Scheme)
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("request blocked: %s has no host", u.Redacted())
+	}
+
+	if entry, denied := p.deniedHost(host); denied {
+		return fmt.Errorf("request blocked: %s is denied by the network policy (%s)", host, entry)
+	}
+	if p.isTrusted(host) || p.explicitlyAllowed(host) {
+		return nil
+	}
+	if len(p.allowDomains) > 0 || len(p.allowPrefixes) > 0 {
+		return fmt.Errorf("request blocked: %s is not on the network allow list (tools.network.allow)", host)
+	}
+
+	// IP literals can be rejected before asking the user about them
+	if addr, err := netip.ParseAddr(host); err == nil && !p.allowPrivate && IsPrivate(addr) {
+		return fmt.Errorf("request blocked: %s is a private, loopback or link-local address", host)
+	}
+
+	return p.confirmHost(host)
+}
+
+// confirmHost asks the user about a host once per session
+func (p *Policy) confirmHost(host string) error {
+	if p.confirm == nil {
+		return nil
+	}
+
+	// One prompt at a time, a concurrent request for the same host waits for the answer
+	p.promptMutex.Lock()
+	defer p.promptMutex.Unlock()
+
+	p.mutex.Lock()
+	approved, declined := p.approved[host], p.declined[host]
+	p.mutex.Unlock()
+
+	if declined {
+		return fmt.Errorf("request blocked: the user declined connections to %s", host)
+	}
+	if approved {
+		return nil
+	}
+
+	allowed := p.confirm(host)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !allowed {
+		p.declined[host] = true
+		return fmt.Errorf("request blocked: the user declined connections to %s", host)
+	}
+	p.approved[host] = true
+	return nil
+}
+
+// CheckAddr validates that a connection to addr, which host resolved to, may be opened
+func (p *Policy) CheckAddr(host string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.denyPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("request blocked: %s (%s) is denied by the network policy (%s)", host, addr, prefix)
+		}
+	}
+
+	if !IsPrivate(addr) || p.allowPrivate || p.isTrusted(host) || p.explicitlyAllowed(host) {
+		return nil
+	}
+	for _, prefix := range p.allowPrefixes {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("request blocked: %s resolves to %s, a private, loopback or link-local address", host, addr)
+}
+
+// DialContext connects to an address after checking every address the host resolves
+// to. It dials the checked address itself, so DNS can't change the answer in between.
+// The proxy of a request is dialed without checks, it is configured by the user and
+// the target behind it was checked by CheckProxied.
+func (p *Policy) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if proxy, _ := ctx.Value(proxyKey{}).(string); proxy != "" && proxy == address {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, addr := range addrs {
+		if err := p.CheckAddr(normalizeHost(host), addr); err != nil {
+			lastErr = err
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
+
+// CheckProxied validates the target of a request sent through a proxy. The proxy opens
+// the connection, so the addresses the host resolves to are checked here instead of when
+// dialing. Hosts that don't resolve locally are left to the proxy, behind a proxy only
+// public names usually fail to resolve.
+func (p *Policy) CheckProxied(ctx context.Context, host string) error {
+	host = normalizeHost(host)
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := p.CheckAddr(host, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsPrivate reports whether addr is a private, loopback, link-local or otherwise
+// non-public address
+func IsPrivate(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range sharedRanges {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient creates an HTTP client that applies the current policy to every request,
+// including each redirect, and caps the size of response bodies
+func NewClient(timeout time.Duration) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFromEnvironment(req)
+	}
+	base.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return Current().DialContext(ctx, network, address)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &transport{base: base},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			// The transport checks the redirect target before sending it
+			return nil
+		},
+	}
+}
+
+// transport validates requests against the current policy before sending them
+type transport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip checks the request URL and limits the response body
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := Current()
+	if err := policy.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	proxy, err := proxyFromEnvironment(req)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		if err := policy.CheckProxied(req.Context(), req.URL.Hostname()); err != nil {
+			return nil, err
+		}
+		req = req.WithContext(context.WithValue(req.Context(), proxyKey{}, proxyAddress(proxy)))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ContentLength > policy.maxResponse {
+		resp.Body.Close()
+		return nil, fmt.Errorf("response blocked: %s is %d bytes, more than the %d byte limit", req.URL.Redacted(), resp.ContentLength, policy.maxResponse)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: policy.maxResponse, limit: policy.maxResponse}
+	return resp, nil
+}
+
+// limitedBody fails reads once more than limit bytes were read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(buf []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("response exceeds the %d byte limit", b.limit)
+	}
+	// Read one byte past the limit to tell a body of exactly limit bytes from a larger one
+	if int64(len(buf)) > b.remaining+1 {
+		buf = buf[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(buf)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), fmt.Errorf("response exceeds the %d byte limit", b.limit)
+	}
+	return n, err
+}
+
+// proxyAddress returns the host and port the transport dials for a proxy
+func proxyAddress(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		switch proxy.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// deniedHost reports whether host matches the deny list
+func (p *Policy) deniedHost(host string) (string, bool) {
+	for _, domain := range p.denyDomains {
+		if matchDomain(host, domain) {
+			return domain, true
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range p.denyPrefixes {
+			if prefix.Contains(addr.Unmap()) {
+				return prefix.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// explicitlyAllowed reports whether host matches an entry of the allow list
+func (p *Policy) explicitlyAllowed(host string) bool {
+	for _, domain := range p.allowDomains {
+		if matchDomain(host, domain) {
+			return true
+		}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		for _, prefix := range p.allowPrefixes {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Policy) isTrusted(host string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.trusted[host]
+}
+
+// parseEntries splits list entries into domains and address ranges
+func parseEntries(entries []string) ([]string, []netip.Prefix) {
+	var domains []string
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		} else if domain := normalizeHost(strings.TrimPrefix(entry, "*.")); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, prefixes
+}
+
+// matchDomain reports whether host is domain or one of its subdomains
+func matchDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// normalizeHost lowercases a host and strips brackets and a trailing dot
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.TrimSuffix(host, ".")
+}
@@ -0,0 +1,45 @@
+package egress
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxy(t *testing.T) {
+	// A proxy on the loopback address, which the policy would block as a target
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied "+r.URL.Host)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	previousProxy := proxyFromEnvironment
+	proxyFromEnvironment = http.ProxyURL(proxyURL)
+	defer func() { proxyFromEnvironment = previousProxy }()
+	previous := Current()
+	SetCurrent(New(nil, nil, false, 0))
+	defer SetCurrent(previous)
+
+	client := NewClient(5 * time.Second)
+
+	// Names that don't resolve locally are left to the proxy
+	resp, err := client.Get("http://kiwi-proxy-target.invalid/page")
+	if err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "proxied kiwi-proxy-target.invalid" {
+		t.Errorf("request should go through the proxy, got %q", body)
+	}
+
+	// Private targets are still blocked behind the proxy
+	if _, err := client.Get("http://localhost:9/"); err == nil || !strings.Contains(err.Error(), "private") {
+		t.Errorf("a private target should be blocked behind the proxy, got %v", err)
+	}
+}
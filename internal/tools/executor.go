@@ -23,23 +23,42 @@ func ExecuteToolWithFeedback(ctx context.Context, tool core.Tool, args map[strin
 	// Show a spinner while tool is executing
 	spinnerManager.StartToolSpinner(fmt.Sprintf("[Tool: %s] executing...", toolName))
 
+	// Ask the tool for a preview of the change it is about to make, if it supports one
+	diff := ""
+	var previewErr error
+	if previewer, ok := tool.(core.DiffPreviewer); ok {
+		diff, previewErr = previewer.PreviewDiff(args)
+	}
+
 	// Check if tool requires confirmation before execution
-	if tool.RequiresConfirmation() {
+	if requiresConfirmation(tool, args) {
 		// Stop the spinner to show the confirmation prompt
 		spinnerManager.TransitionToResponse()
 
 		// Show confirmation message
 		fmt.Println()
-		util.InfoColor.Printf("[Tool: %s] requires confirmation:\n", toolName)
-		if command, ok := args["command"].(string); ok {
-			util.OutputColor.Println(command)
+		prompt := "Do you want to execute this command? (y/N): "
+		if previewErr != nil {
+			// The change can't be shown, say why rather than dumping the raw parameters
+			util.InfoColor.Printf("[Tool: %s] requires confirmation:\n", toolName)
+			util.ErrorColor.Printf("Could not preview the change: %v\n", previewErr)
+			prompt = "Do you want to execute it anyway? (y/N): "
+		} else if diff != "" {
+			util.InfoColor.Printf("[Tool: %s] proposes the following change:\n", toolName)
+			fmt.Print(util.ColorizeDiff(diff))
+			prompt = "Do you want to apply this change? (y/N): "
 		} else {
-			util.OutputColor.Printf("Execute %s with params: %v\n", toolName, args)
+			util.InfoColor.Printf("[Tool: %s] requires confirmation:\n", toolName)
+			if command, ok := args["command"].(string); ok {
+				util.OutputColor.Println(command)
+			} else {
+				util.OutputColor.Printf("Execute %s with params: %v\n", toolName, args)
+			}
 		}
 		fmt.Println()
 
 		// Ask for confirmation
-		confirmed, err := util.PromptForConfirmation("Do you want to execute this command? (y/N): ")
+		confirmed, err := util.PromptForConfirmation(prompt)
 		if err != nil {
 			return "", fmt.Errorf("confirmation failed: %w", err)
 		}
@@ -50,6 +69,14 @@ func ExecuteToolWithFeedback(ctx context.Context, tool core.Tool, args map[strin
 
 		// Restart the spinner
 		spinnerManager.StartToolSpinner(fmt.Sprintf("[Tool: %s] executing...", toolName))
+	} else if diff != "" {
+		// No confirmation needed, but still show the user what is changing
+		spinnerManager.TransitionToResponse()
+		fmt.Println()
+		util.InfoColor.Printf("[Tool: %s] applying the following change:\n", toolName)
+		fmt.Print(util.ColorizeDiff(diff))
+		fmt.Println()
+		spinnerManager.StartToolSpinner(fmt.Sprintf("[Tool: %s] executing...", toolName))
 	}
 
 	// Start the execution timer
@@ -97,6 +124,10 @@ func ExecuteToolWithFeedback(ctx context.Context, tool core.Tool, args map[strin
 		util.ErrorColor.Printf("  → All %d attempts failed. Last error: %s\n", maxRetries, lastErr.Error())
 		return "", lastErr
 	}
+	if toolExecutionResult.Display != "" {
+		fmt.Println()
+		util.InfoColor.Println(toolExecutionResult.Display)
+	}
 	fmt.Println()
 	return toolExecutionResult.Output, nil
 }
@@ -104,7 +135,7 @@ func ExecuteToolWithFeedback(ctx context.Context, tool core.Tool, args map[strin
 // ExecuteTool executes a tool with no visual feedback
 func ExecuteTool(ctx context.Context, tool core.Tool, args map[string]interface{}) (string, error) {
 	// If the tool requires confirmation, we need to use the feedback version
-	if tool.RequiresConfirmation() {
+	if requiresConfirmation(tool, args) {
 		return ExecuteToolWithFeedback(ctx, tool, args)
 	}
 
@@ -115,3 +146,14 @@ func ExecuteTool(ctx context.Context, tool core.Tool, args map[string]interface{
 
 	return toolExecutionResult.Output, nil
 }
+
+// requiresConfirmation reports whether this particular call must be confirmed by the user
+func requiresConfirmation(tool core.Tool, args map[string]interface{}) bool {
+	if tool.RequiresConfirmation() {
+		return true
+	}
+	if checker, ok := tool.(core.ConfirmationChecker); ok {
+		return checker.NeedsConfirmation(args)
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+// textEdit is a single exact-string replacement in a file
+type textEdit struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
+// parseEdits reads either a batch of edits from 'edits' or a single edit from 'old_string'/'new_string'
+func parseEdits(args map[string]interface{}) ([]textEdit, error) {
+	if raw, ok := args["edits"]; ok && raw != nil {
+		var data []byte
+		var err error
+
+		// Some models send the batch as a JSON string rather than an array
+		if str, ok := raw.(string); ok {
+			data = []byte(str)
+		} else if data, err = json.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("edits must be an array of {old_string, new_string, replace_all} objects")
+		}
+
+		var edits []textEdit
+		if err := json.Unmarshal(data, &edits); err != nil {
+			return nil, fmt.Errorf("edits must be an array of {old_string, new_string, replace_all} objects: %w", err)
+		}
+		if len(edits) == 0 {
+			return nil, fmt.Errorf("edits must contain at least one edit")
+		}
+		return edits, nil
+	}
+
+	oldString, err := core.GetString(args, "old_string", "")
+	if err != nil {
+		return nil, err
+	}
+	newString, err := core.GetString(args, "new_string", "")
+	if err != nil {
+		return nil, err
+	}
+	replaceAll, err := core.GetBool(args, "replace_all", false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := args["old_string"]; !ok {
+		return nil, fmt.Errorf("old_string parameter is required for edit operation (or provide an 'edits' array)")
+	}
+
+	return []textEdit{{OldString: oldString, NewString: newString, ReplaceAll: replaceAll}}, nil
+}
+
+// applyEdits applies the edits in order, failing if any of them doesn't match exactly
+func applyEdits(content string, edits []textEdit) (string, error) {
+	for i, edit := range edits {
+		label := "edit"
+		if len(edits) > 1 {
+			label = fmt.Sprintf("edit %d", i+1)
+		}
+
+		if edit.OldString == "" {
+			return "", fmt.Errorf("%s: old_string must not be empty", label)
+		}
+		if edit.OldString == edit.NewString {
+			return "", fmt.Errorf("%s: old_string and new_string are identical", label)
+		}
+
+		count := strings.Count(content, edit.OldString)
+		switch {
+		case count == 0:
+			return "", fmt.Errorf("%s: old_string not found in file. Read the file again and copy the text exactly, including whitespace", label)
+		case count > 1 && !edit.ReplaceAll:
+			return "", fmt.Errorf("%s: old_string matches %d times. Include more surrounding context to make it unique, or set replace_all to true", label, count)
+		}
+
+		if edit.ReplaceAll {
+			content = strings.ReplaceAll(content, edit.OldString, edit.NewString)
+		} else {
+			content = strings.Replace(content, edit.OldString, edit.NewString, 1)
+		}
+	}
+
+	return content, nil
+}
+
+// planEdit works out the content of a file before and after the requested edits
+func (t *Tool) planEdit(path string, args map[string]interface{}) (string, string, error) {
+	if err := checkPath(path, workspace.Write); err != nil {
+		return "", "", err
+	}
+
+	edits, err := parseEdits(args)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", "", fmt.Errorf("file does not exist: %s. Use 'write' operation to create it", path)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	oldContent := string(data)
+	newContent, err := applyEdits(oldContent, edits)
+	if err != nil {
+		return "", "", err
+	}
+
+	return oldContent, newContent, nil
+}
+
+// editFile applies exact-string replacements to a file
+func (t *Tool) editFile(path string, args map[string]interface{}) (string, error) {
+	oldContent, newContent, err := t.planEdit(path, args)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkpointPath(path); err != nil {
+		return "", err
+	}
+	if err := writePreservingMode(path, newContent); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Successfully edited %s (%s)", path, describeChange(oldContent, newContent)), nil
+}
+
+// writePreservingMode replaces the content of a file while keeping its permissions
+func writePreservingMode(path string, content string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// describeChange summarizes how many lines a change added and removed
+func describeChange(oldContent, newContent string) string {
+	added, removed := 0, 0
+	for _, line := range strings.Split(diffText(oldContent, newContent), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return fmt.Sprintf("+%d -%d lines", added, removed)
+}
@@ -3,11 +3,15 @@ package filesystem
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/saurabh0719/kiwi/internal/checkpoint"
 	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+	"github.com/saurabh0719/kiwi/internal/util"
 )
 
 // Tool provides file system operations
@@ -15,6 +19,7 @@ type Tool struct {
 	name        string
 	description string
 	parameters  map[string]core.Parameter
+	safeMode    bool
 }
 
 // New creates a new FileSystemTool
@@ -22,28 +27,128 @@ func New() *Tool {
 	parameters := map[string]core.Parameter{
 		"operation": {
 			Type:        "string",
-			Description: "Operation to perform: 'list' (list directory contents), 'read' (read existing file), 'write' (write to file, creates it if doesn't exist), 'delete' (delete a file)",
+			Description: "Operation to perform: 'list' (list directory contents), 'read' (read existing file with line numbers), 'write' (write to file, creates it if doesn't exist), 'delete' (delete a file), 'edit' (replace exact text in a file), 'apply_patch' (apply a unified diff to a file), 'glob' (find files by name pattern), 'grep' (search file contents with a regular expression), 'tree' (recursive listing with sizes and file counts), 'mkdir' (create a directory and its parents), 'move' (move or rename to destination), 'copy' (copy a file or directory to destination), 'stat' (show type, size, permissions and modification time)",
 			Required:    true,
 		},
 		"path": {
 			Type:        "string",
-			Description: "Path to file or directory. For 'write' operations, this is the file to write to (will be created if it doesn't exist).",
+			Description: "Path to file or directory. For 'write' operations, this is the file to write to (will be created if it doesn't exist). For 'glob' and 'grep', the directory (or file) to search, e.g. '.'.",
 			Required:    true,
 		},
+		"destination": {
+			Type:        "string",
+			Description: "Target path (for move and copy operations only).",
+			Required:    false,
+		},
+		"overwrite": {
+			Type:        "boolean",
+			Description: "Replace the destination if it already exists (for move and copy operations only). Defaults to false.",
+			Required:    false,
+		},
 		"content": {
 			Type:        "string",
 			Description: "Content to write (for write operation only). For example, 'content': 'Hello, world!' will write that text to the file.",
 			Required:    false,
 		},
+		"old_string": {
+			Type:        "string",
+			Description: "Exact text to replace (for edit operation only). Must match the file exactly, including whitespace, and be unique unless replace_all is true.",
+			Required:    false,
+		},
+		"new_string": {
+			Type:        "string",
+			Description: "Text to replace old_string with (for edit operation only).",
+			Required:    false,
+		},
+		"replace_all": {
+			Type:        "boolean",
+			Description: "Replace every occurrence of old_string instead of requiring a unique match (for edit operation only). Defaults to false.",
+			Required:    false,
+		},
+		"edits": {
+			Type:        "array",
+			Description: "Batch of edits applied in order, all or nothing (for edit operation only, instead of old_string/new_string).",
+			Required:    false,
+			Items: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"old_string":  map[string]interface{}{"type": "string"},
+					"new_string":  map[string]interface{}{"type": "string"},
+					"replace_all": map[string]interface{}{"type": "boolean"},
+				},
+				"required": []string{"old_string", "new_string"},
+			},
+		},
+		"patch": {
+			Type:        "string",
+			Description: "Unified diff to apply to the file at path (for apply_patch operation only). Hunks are matched on their context lines.",
+			Required:    false,
+		},
+		"pattern": {
+			Type:        "string",
+			Description: "For glob: a file pattern like '*.go' (matches names at any depth) or 'src/**/*.ts' (matches paths). For grep: a regular expression (RE2 syntax) to search for.",
+			Required:    false,
+		},
+		"include": {
+			Type:        "string",
+			Description: "Only search files matching this glob, e.g. '*.{ts,tsx}' (for grep operation only).",
+			Required:    false,
+		},
+		"file_type": {
+			Type:        "string",
+			Description: "Only search files of this type, e.g. 'go', 'py', 'js', 'ts', 'rust', 'java', 'md' (for grep operation only).",
+			Required:    false,
+		},
+		"case_insensitive": {
+			Type:        "boolean",
+			Description: "Match the grep pattern case-insensitively. Defaults to false.",
+			Required:    false,
+		},
+		"context_lines": {
+			Type:        "integer",
+			Description: "Number of lines to show before and after each grep match (max 10). Defaults to 0.",
+			Required:    false,
+		},
+		"max_results": {
+			Type:        "integer",
+			Description: "Maximum number of files (glob, default 200) or matching lines (grep, default 100) to return.",
+			Required:    false,
+		},
+		"depth": {
+			Type:        "integer",
+			Description: "Number of directory levels to expand (for tree operation only). Defaults to 3.",
+			Required:    false,
+		},
+		"include_vendor": {
+			Type:        "boolean",
+			Description: "Expand vendor and node_modules directories (for tree operation only). Defaults to false.",
+			Required:    false,
+		},
+		"offset": {
+			Type:        "integer",
+			Description: "Line number to start reading from (for read operation only, 1-based). Defaults to 1.",
+			Required:    false,
+		},
+		"limit": {
+			Type:        "integer",
+			Description: "Maximum number of lines to read (for read operation only). Defaults to 2000. Large reads are also capped at 64KB; the output says which offset to use to read more.",
+			Required:    false,
+		},
 	}
 
 	return &Tool{
 		name:        "filesystem",
-		description: "Provides file system operations like listing files, reading from existing files, writing to files (creates files if they don't exist), and deleting files. For writing to files, use operation='write', path='filename.txt', and content='text to write'. Example: To create a file called notes.txt with content 'Meeting notes', use these parameters: {\"operation\": \"write\", \"path\": \"notes.txt\", \"content\": \"Meeting notes\"}.",
+		description: "Provides file system operations like listing files, reading from existing files (with line numbers, use offset/limit to read large files in parts), writing to files (creates files and parent directories if they don't exist), deleting files, editing files in place, creating, moving and copying files and directories, and searching for files by name (glob) or content (grep). Use 'tree' for a compact overview of a project. Files ignored by .gitignore or .kiwiignore are skipped when searching and listing trees. Use glob and grep instead of shell commands like find or grep. Prefer 'edit' (exact string replacement) or 'apply_patch' (unified diff) over 'write' when changing part of an existing file. For writing to files, use operation='write', path='filename.txt', and content='text to write'. Example: To create a file called notes.txt with content 'Meeting notes', use these parameters: {\"operation\": \"write\", \"path\": \"notes.txt\", \"content\": \"Meeting notes\"}.",
 		parameters:  parameters,
+		safeMode:    true,
 	}
 }
 
+// SetSafeMode controls whether edits must be confirmed by the user before they are applied
+func (t *Tool) SetSafeMode(enabled bool) {
+	t.safeMode = enabled
+}
+
 // Name returns the name of the tool
 func (t *Tool) Name() string {
 	return t.name
@@ -94,10 +199,10 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 
 	result.AddStep(fmt.Sprintf("Validating path: %s", path))
 
-	// Validate the path for safety
-	if !isPathSafe(path) {
+	// Validate the path against the workspace policy
+	if err := checkPath(path, operationAccess(operation)); err != nil {
 		result.AddStep(fmt.Sprintf("Path safety check failed for: %s", path))
-		return result, fmt.Errorf("path is not safe: %s", path)
+		return result, err
 	}
 
 	result.AddStep(fmt.Sprintf("Path safety check passed"))
@@ -118,6 +223,12 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 			result.AddStep("Error: Content must be a string")
 			return result, fmt.Errorf("content must be a string")
 		}
+	case "glob", "grep":
+		// Search operations need a pattern
+		if pattern, _ := core.GetString(args, "pattern", ""); pattern == "" {
+			result.AddStep(fmt.Sprintf("Error: Missing required 'pattern' parameter for %s operation", operation))
+			return result, fmt.Errorf("pattern parameter is required for %s operation", operation)
+		}
 	case "read":
 		// For read operations, check if the file exists first
 		if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -141,13 +252,21 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 			result.AddStep(fmt.Sprintf("Listed %d files/directories in %s", fileCount, path))
 		}
 	case "read":
+		var offset, limit int
+		if offset, err = core.GetInt(args, "offset", 1); err != nil {
+			return result, err
+		}
+		if limit, err = core.GetInt(args, "limit", defaultReadLimit); err != nil {
+			return result, err
+		}
 		result.AddStep(fmt.Sprintf("Reading file content: %s", path))
-		output, err = t.readFile(path)
+		var read readResult
+		read, err = t.readFile(path, offset, limit)
 		if err != nil {
 			result.AddStep(fmt.Sprintf("Error reading file: %v", err))
 		} else {
-			lineCount := strings.Count(output, "\n") + 1
-			result.AddStep(fmt.Sprintf("Successfully read %s (%d lines, %d bytes)", path, lineCount, len(output)))
+			output = read.content
+			result.AddStep(read.summary)
 		}
 	case "write":
 		content := args["content"].(string)
@@ -159,6 +278,101 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 		} else {
 			result.AddStep(fmt.Sprintf("Successfully wrote to file %s", path))
 		}
+	case "edit":
+		result.AddStep(fmt.Sprintf("Editing file: %s", path))
+		output, err = t.editFile(path, args)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error editing file: %v", err))
+		} else {
+			result.AddStep(output)
+		}
+	case "apply_patch":
+		patch, _ := core.GetString(args, "patch", "")
+		result.AddStep(fmt.Sprintf("Applying patch to file: %s", path))
+		output, err = t.patchFile(path, patch)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error applying patch: %v", err))
+		} else {
+			result.AddStep(output)
+		}
+	case "tree":
+		var depth int
+		var includeVendor bool
+		if depth, err = core.GetInt(args, "depth", defaultTreeDepth); err != nil {
+			return result, err
+		}
+		if includeVendor, err = core.GetBool(args, "include_vendor", false); err != nil {
+			return result, err
+		}
+		result.AddStep(fmt.Sprintf("Building tree of %s (depth %d)", path, depth))
+		output, err = t.treeFiles(path, depth, includeVendor)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error building tree: %v", err))
+		} else {
+			result.AddStep(fmt.Sprintf("Listed %d entries under %s", strings.Count(output, "\n")-1, path))
+		}
+	case "mkdir":
+		result.AddStep(fmt.Sprintf("Creating directory: %s", path))
+		output, err = t.makeDir(path)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error creating directory: %v", err))
+		} else {
+			result.AddStep(output)
+		}
+	case "move", "copy":
+		var destination string
+		var overwrite bool
+		if destination, err = core.GetString(args, "destination", ""); err != nil {
+			return result, err
+		}
+		if overwrite, err = core.GetBool(args, "overwrite", false); err != nil {
+			return result, err
+		}
+		result.AddStep(fmt.Sprintf("Validating destination: %s", destination))
+		if operation == "move" {
+			output, err = t.movePath(path, destination, overwrite)
+		} else {
+			output, err = t.copyTo(path, destination, overwrite)
+		}
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error during %s: %v", operation, err))
+		} else {
+			result.AddStep(output)
+		}
+	case "stat":
+		result.AddStep(fmt.Sprintf("Reading metadata: %s", path))
+		output, err = t.statPath(path)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error reading metadata: %v", err))
+		} else {
+			result.AddStep(fmt.Sprintf("Successfully read metadata for %s", path))
+		}
+	case "glob":
+		var maxResults, count int
+		if maxResults, err = core.GetInt(args, "max_results", defaultGlobLimit); err != nil {
+			return result, err
+		}
+		pattern, _ := core.GetString(args, "pattern", "")
+		result.AddStep(fmt.Sprintf("Finding files matching %q in %s", pattern, path))
+		output, count, err = t.globFiles(path, pattern, maxResults)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error finding files: %v", err))
+		} else {
+			result.AddStep(fmt.Sprintf("Found %d matching files", count))
+		}
+	case "grep":
+		var opts grepOptions
+		if opts, err = parseGrepOptions(args); err != nil {
+			return result, err
+		}
+		var count int
+		result.AddStep(fmt.Sprintf("Searching for %q in %s", opts.pattern, path))
+		output, count, err = t.grepFiles(path, opts)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error searching files: %v", err))
+		} else {
+			result.AddStep(fmt.Sprintf("Found %d matching lines", count))
+		}
 	case "delete":
 		result.AddStep(fmt.Sprintf("Deleting file: %s", path))
 		output, err = t.deleteFile(path)
@@ -169,7 +383,7 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 		}
 	default:
 		result.AddStep(fmt.Sprintf("Unknown operation requested: %s", operation))
-		err = fmt.Errorf("unknown operation: %s, supported operations are: list, read, write, delete", operation)
+		err = fmt.Errorf("unknown operation: %s, supported operations are: list, read, write, delete, edit, apply_patch, glob, grep, tree, mkdir, move, copy, stat", operation)
 	}
 
 	if err != nil {
@@ -180,6 +394,32 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 	return result, nil
 }
 
+// parseGrepOptions reads the grep parameters
+func parseGrepOptions(args map[string]interface{}) (grepOptions, error) {
+	var opts grepOptions
+	var err error
+
+	if opts.pattern, err = core.GetString(args, "pattern", ""); err != nil {
+		return opts, err
+	}
+	if opts.include, err = core.GetString(args, "include", ""); err != nil {
+		return opts, err
+	}
+	if opts.fileType, err = core.GetString(args, "file_type", ""); err != nil {
+		return opts, err
+	}
+	if opts.caseInsensitive, err = core.GetBool(args, "case_insensitive", false); err != nil {
+		return opts, err
+	}
+	if opts.contextLines, err = core.GetInt(args, "context_lines", 0); err != nil {
+		return opts, err
+	}
+	if opts.maxResults, err = core.GetInt(args, "max_results", defaultGrepLimit); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
 // listFiles lists the files in a directory
 func (t *Tool) listFiles(path string) (string, error) {
 	entries, err := os.ReadDir(path)
@@ -199,32 +439,20 @@ func (t *Tool) listFiles(path string) (string, error) {
 	return result.String(), nil
 }
 
-// readFile reads the content of a file
-func (t *Tool) readFile(path string) (string, error) {
+// writeFile writes content to a file
+func (t *Tool) writeFile(path string, content string) (string, error) {
 	// Validate the path for safety
-	if !isPathSafe(path) {
-		return "", fmt.Errorf("path is not safe: %s", path)
+	if err := checkPath(path, workspace.Write); err != nil {
+		return "", err
 	}
 
-	// Check if the file exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return "", fmt.Errorf("file does not exist: %s. Use 'write' operation first to create it", path)
+	if err := checkpointPath(path); err != nil {
+		return "", err
 	}
 
-	// Read the file
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
-	}
-
-	return string(data), nil
-}
-
-// writeFile writes content to a file
-func (t *Tool) writeFile(path string, content string) (string, error) {
-	// Validate the path for safety
-	if !isPathSafe(path) {
-		return "", fmt.Errorf("path is not safe: %s", path)
+	// Create the parent directory if it doesn't exist yet
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %w", err)
 	}
 
 	// Write the file
@@ -239,8 +467,8 @@ func (t *Tool) writeFile(path string, content string) (string, error) {
 // deleteFile deletes a file
 func (t *Tool) deleteFile(path string) (string, error) {
 	// Validate the path for safety
-	if !isPathSafe(path) {
-		return "", fmt.Errorf("path is not safe: %s", path)
+	if err := checkPath(path, workspace.Write); err != nil {
+		return "", err
 	}
 
 	// Check if the file exists
@@ -248,6 +476,10 @@ func (t *Tool) deleteFile(path string) (string, error) {
 		return "", fmt.Errorf("file does not exist: %s", path)
 	}
 
+	if err := checkpointPath(path); err != nil {
+		return "", err
+	}
+
 	// Delete the file
 	err := os.Remove(path)
 	if err != nil {
@@ -257,36 +489,81 @@ func (t *Tool) deleteFile(path string) (string, error) {
 	return fmt.Sprintf("Successfully deleted %s", path), nil
 }
 
-// isPathSafe checks if a path is safe to access
-func isPathSafe(path string) bool {
-	// Get absolute path
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return false
-	}
+// writeOperations are the operations that modify the path they are given
+var writeOperations = map[string]bool{
+	"write":       true,
+	"delete":      true,
+	"edit":        true,
+	"apply_patch": true,
+	"mkdir":       true,
+	"move":        true,
+}
 
-	// Allow temporary paths for testing
-	if strings.HasPrefix(absPath, os.TempDir()) {
-		return true
+// operationAccess returns the access an operation needs to its path
+func operationAccess(operation string) workspace.Access {
+	if writeOperations[operation] {
+		return workspace.Write
 	}
+	return workspace.Read
+}
 
-	// Get working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return false
-	}
+// checkPath validates a path against the shared workspace policy
+func checkPath(path string, access workspace.Access) error {
+	_, err := workspace.Current().Check(path, access)
+	return err
+}
 
-	// Ensure path is under working directory
-	rel, err := filepath.Rel(wd, absPath)
-	if err != nil {
-		return false
+// checkpointPath snapshots a path before it is modified so that the change can be undone
+func checkpointPath(path string) error {
+	if err := checkpoint.Snapshot(path); err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
 	}
+	return nil
+}
 
-	// Check if path tries to escape working directory
-	return !filepath.IsAbs(rel) && !strings.HasPrefix(rel, "..")
+// walkAllowed reports whether an entry found while walking a directory may be visited.
+// Symlinks are resolved so that they can't lead outside the workspace.
+func walkAllowed(path string, d fs.DirEntry) bool {
+	policy := workspace.Current()
+	if d.Type()&fs.ModeSymlink != 0 {
+		return policy.Allowed(path, workspace.Read)
+	}
+	return !policy.IsDenied(path)
 }
 
 // RequiresConfirmation returns whether this tool requires confirmation before execution
 func (t *Tool) RequiresConfirmation() bool {
 	return false
 }
+
+// NeedsConfirmation returns true for edits when safe mode is on, so the user can review the diff first
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	operation, _ := core.GetString(args, "operation", "")
+	return t.safeMode && (operation == "edit" || operation == "apply_patch")
+}
+
+// PreviewDiff returns the diff an edit or patch would make to the file
+func (t *Tool) PreviewDiff(args map[string]interface{}) (string, error) {
+	operation, _ := core.GetString(args, "operation", "")
+	if operation != "edit" && operation != "apply_patch" {
+		return "", nil
+	}
+	path, err := core.GetString(args, "path", "")
+	if err != nil || path == "" {
+		return "", fmt.Errorf("path parameter is required")
+	}
+
+	var oldContent, newContent string
+	switch operation {
+	case "edit":
+		oldContent, newContent, err = t.planEdit(path, args)
+	case "apply_patch":
+		patch, _ := core.GetString(args, "patch", "")
+		oldContent, newContent, err = t.planPatch(path, patch)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return util.UnifiedDiff("a/"+path, "b/"+path, oldContent, newContent), nil
+}
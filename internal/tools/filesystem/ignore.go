@@ -0,0 +1,238 @@
+package filesystem
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileNames are the files whose patterns are honored when walking a directory tree.
+// .kiwiignore uses the same syntax as .gitignore for files only kiwi should skip.
+var ignoreFileNames = []string{".gitignore", ".kiwiignore"}
+
+// ignoreRule is a single pattern from an ignore file
+type ignoreRule struct {
+	base    string // directory the ignore file lives in, relative to the walk root
+	prefix  string // path of the walk root relative to the ignore file, for parent directories
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher decides which paths are excluded by .gitignore style rules
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// newIgnoreMatcher creates a matcher for a walk rooted at root. Ignore files in the
+// parent directories up to the repository root are loaded as well, so searching a
+// subdirectory honors the same rules as searching the whole repository.
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+
+	if absRoot, err := filepath.Abs(root); err == nil {
+		if _, err := os.Stat(filepath.Join(absRoot, ".git")); err != nil {
+			// Collect parent directories until we find the repository root
+			var parents []string
+			found := false
+			for dir := filepath.Dir(absRoot); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+				parents = append(parents, dir)
+				if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+					found = true
+					break
+				}
+			}
+
+			// Load from the outermost directory inwards so that inner rules take precedence
+			for i := len(parents) - 1; found && i >= 0; i-- {
+				if prefix, err := filepath.Rel(parents[i], absRoot); err == nil {
+					m.loadRules(parents[i], "", filepath.ToSlash(prefix))
+				}
+			}
+		}
+	}
+
+	m.loadDir(root, "")
+	return m
+}
+
+// loadDir reads the ignore files in dir, whose path relative to the walk root is rel
+func (m *ignoreMatcher) loadDir(dir, rel string) {
+	m.loadRules(dir, rel, "")
+}
+
+// loadRules reads the ignore files in dir. Rules inside the walk are scoped to base;
+// rules from a parent of the walk root see paths with prefix prepended.
+func (m *ignoreMatcher) loadRules(dir, base, prefix string) {
+	for _, name := range ignoreFileNames {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreLine(scanner.Text(), base); ok {
+				rule.prefix = prefix
+				m.rules = append(m.rules, rule)
+			}
+		}
+		file.Close()
+	}
+}
+
+// parseIgnoreLine converts a line from an ignore file into a rule
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// A pattern with a slash anywhere but the end is relative to the ignore file,
+	// otherwise it matches a name at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if !anchored {
+		line = "**/" + line
+	}
+
+	pattern, err := regexp.Compile("^" + globToRegexp(line) + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// globToRegexp translates a glob with ** and {a,b} support into a regular expression
+func globToRegexp(glob string) string {
+	var re strings.Builder
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			re.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '{':
+			braces++
+			re.WriteString("(?:")
+		case c == '}' && braces > 0:
+			braces--
+			re.WriteString(")")
+		case c == ',' && braces > 0:
+			re.WriteString("|")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String()
+}
+
+// Match reports whether the path, relative to the walk root, is ignored
+func (m *ignoreMatcher) Match(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	ignored := false
+
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		target := rel
+		switch {
+		case rule.prefix != "":
+			target = rule.prefix + "/" + rel
+		case rule.base == "":
+		case strings.HasPrefix(rel, rule.base+"/"):
+			target = strings.TrimPrefix(rel, rule.base+"/")
+		default:
+			continue
+		}
+
+		if rule.pattern.MatchString(target) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// walkFiles walks the tree under root, skipping ignored files and directories and the .git directory.
+// The callback receives paths as they would be built from root, and paths relative to root.
+func walkFiles(root string, fn func(path, rel string, d fs.DirEntry) error) error {
+	matcher := newIgnoreMatcher(root)
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries instead of aborting the whole walk
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if path != root {
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if matcher.Match(rel, d.IsDir()) || !walkAllowed(path, d) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				matcher.loadDir(path, rel)
+			}
+		}
+
+		return fn(path, rel, d)
+	})
+}
@@ -0,0 +1,298 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+// makeDir creates a directory along with any missing parents
+func (t *Tool) makeDir(path string) (string, error) {
+	if err := checkPath(path, workspace.Write); err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s already exists and is not a directory", path)
+		}
+		return fmt.Sprintf("Directory %s already exists", path), nil
+	}
+
+	if err := checkpointPath(path); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return fmt.Sprintf("Successfully created directory %s", path), nil
+}
+
+// movePath moves or renames a file or directory
+func (t *Tool) movePath(source, destination string, overwrite bool) (string, error) {
+	if err := checkTransfer(source, destination, overwrite, true); err != nil {
+		return "", err
+	}
+
+	if err := checkpointPath(source); err != nil {
+		return "", err
+	}
+	if err := checkpointPath(destination); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	// Overwriting replaces the destination, a directory is never merged into
+	if overwrite {
+		if err := os.RemoveAll(destination); err != nil {
+			return "", fmt.Errorf("failed to replace %s: %w", destination, err)
+		}
+	}
+
+	err := os.Rename(source, destination)
+	if errors.Is(err, syscall.EXDEV) {
+		// Rename doesn't work across filesystems, fall back to copy and delete
+		err = moveAcross(source, destination)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to move: %w", err)
+	}
+
+	return fmt.Sprintf("Successfully moved %s to %s", source, destination), nil
+}
+
+// moveAcross moves source to another filesystem by copying it and removing the source.
+// A failed or incomplete copy is removed and the source kept.
+func moveAcross(source, destination string) error {
+	skipped, err := copyPath(source, destination)
+	if err == nil && len(skipped) > 0 {
+		// Removing the source would delete the entries that were not copied
+		err = fmt.Errorf("%s contains entries denied by the workspace policy: %s", source, strings.Join(skipped, ", "))
+	}
+	if err != nil {
+		os.RemoveAll(destination)
+		return err
+	}
+	if err := os.RemoveAll(source); err != nil {
+		return fmt.Errorf("copied to %s but failed to remove source: %w", destination, err)
+	}
+	return nil
+}
+
+// copyTo copies a file or a directory tree
+func (t *Tool) copyTo(source, destination string, overwrite bool) (string, error) {
+	if err := checkTransfer(source, destination, overwrite, false); err != nil {
+		return "", err
+	}
+
+	if err := checkpointPath(destination); err != nil {
+		return "", err
+	}
+
+	// Like move, overwriting replaces the destination so no stale files are left behind
+	if overwrite {
+		if err := os.RemoveAll(destination); err != nil {
+			return "", fmt.Errorf("failed to replace %s: %w", destination, err)
+		}
+	}
+
+	skipped, err := copyPath(source, destination)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy: %w", err)
+	}
+
+	output := fmt.Sprintf("Successfully copied %s to %s", source, destination)
+	if len(skipped) > 0 {
+		output += fmt.Sprintf("\nSkipped entries denied by the workspace policy: %s", strings.Join(skipped, ", "))
+	}
+	return output, nil
+}
+
+// checkTransfer validates the source and destination of a move or copy
+func checkTransfer(source, destination string, overwrite, move bool) error {
+	if destination == "" {
+		return fmt.Errorf("destination parameter is required")
+	}
+	// Moving removes the source, so it needs write access as well
+	sourceAccess := workspace.Read
+	if move {
+		sourceAccess = workspace.Write
+	}
+	if err := checkPath(source, sourceAccess); err != nil {
+		return err
+	}
+	if err := checkPath(destination, workspace.Write); err != nil {
+		return fmt.Errorf("invalid destination: %w", err)
+	}
+
+	srcInfo, err := os.Lstat(source)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("source does not exist: %s", source)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to access source: %w", err)
+	}
+
+	// Copying a directory into itself would never finish, and overwriting a file with
+	// itself would delete it
+	absSrc, _ := filepath.Abs(source)
+	absDst, _ := filepath.Abs(destination)
+	if absDst == absSrc || srcInfo.IsDir() && strings.HasPrefix(absDst, absSrc+string(filepath.Separator)) {
+		return fmt.Errorf("cannot copy or move %s into itself", source)
+	}
+
+	if dstInfo, err := os.Lstat(destination); err == nil {
+		if !overwrite {
+			return fmt.Errorf("destination already exists: %s. Set overwrite to true to replace it", destination)
+		}
+		if dstInfo.IsDir() != srcInfo.IsDir() {
+			return fmt.Errorf("cannot overwrite %s with %s: one is a file and the other a directory", destination, source)
+		}
+		// The destination is removed before the transfer, which would take the source with it
+		if strings.HasPrefix(absSrc, absDst+string(filepath.Separator)) {
+			return fmt.Errorf("cannot overwrite %s, it contains %s", destination, source)
+		}
+	}
+
+	return nil
+}
+
+// copyPath copies a file, symlink or directory tree, preserving permissions. Entries of a
+// directory denied by the workspace policy, like .git or .env, are not copied, their
+// paths relative to source are returned.
+func copyPath(source, destination string) ([]string, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return nil, copySymlink(source, destination)
+	case info.IsDir():
+		policy := workspace.Current()
+		var skipped []string
+		err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(source, path)
+			if err != nil {
+				return err
+			}
+			if path != source && policy.IsDenied(path) {
+				skipped = append(skipped, filepath.ToSlash(rel))
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			target := filepath.Join(destination, rel)
+
+			switch {
+			case d.IsDir():
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				return os.MkdirAll(target, info.Mode().Perm())
+			case d.Type()&os.ModeSymlink != 0:
+				return copySymlink(path, target)
+			default:
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				return copyFile(path, target, info.Mode().Perm())
+			}
+		})
+		return skipped, err
+	default:
+		return nil, copyFile(source, destination, info.Mode().Perm())
+	}
+}
+
+// copySymlink recreates a symlink with the same target
+func copySymlink(source, destination string) error {
+	target, err := os.Readlink(source)
+	if err != nil {
+		return err
+	}
+	os.Remove(destination)
+	return os.Symlink(target, destination)
+}
+
+// copyFile copies the content of a regular file
+func copyFile(source, destination string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// statPath describes a file or directory
+func (t *Tool) statPath(path string) (string, error) {
+	if err := checkPath(path, workspace.Read); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("path does not exist: %s", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat: %w", err)
+	}
+
+	absPath, _ := filepath.Abs(path)
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("path: %s\n", absPath))
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, _ := os.Readlink(path)
+		output.WriteString("type: symlink\n")
+		output.WriteString(fmt.Sprintf("target: %s\n", target))
+	case info.IsDir():
+		output.WriteString("type: directory\n")
+		if entries, err := os.ReadDir(path); err == nil {
+			output.WriteString(fmt.Sprintf("entries: %d\n", len(entries)))
+		}
+	default:
+		output.WriteString("type: file\n")
+		output.WriteString(fmt.Sprintf("size: %d bytes (%s)\n", info.Size(), formatSize(info.Size())))
+	}
+
+	output.WriteString(fmt.Sprintf("mode: %s\n", info.Mode().String()))
+	output.WriteString(fmt.Sprintf("modified: %s\n", info.ModTime().Format("2006-01-02 15:04:05 MST")))
+
+	return output.String(), nil
+}
@@ -0,0 +1,203 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+	"github.com/saurabh0719/kiwi/internal/util"
+)
+
+// hunkHeaderPattern matches a unified diff hunk header like "@@ -12,5 +12,7 @@"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunk is a single block of changes from a unified diff
+type hunk struct {
+	oldStart int
+	oldLines []string // context and removed lines
+	newLines []string // context and added lines
+}
+
+// parsePatch parses the hunks of a unified diff for a single file
+func parsePatch(patch string) ([]hunk, error) {
+	var hunks []hunk
+	var current *hunk
+	files := 0
+
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			// File header, the target is taken from the path parameter
+			files++
+			if files > 1 {
+				return nil, fmt.Errorf("patch modifies more than one file, apply it one file at a time")
+			}
+			current = nil
+		case strings.HasPrefix(line, "+++ ") && current == nil:
+			// Second half of the file header
+		case strings.HasPrefix(line, "@@"):
+			match := hunkHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("invalid hunk header: %s", line)
+			}
+			start, _ := strconv.Atoi(match[1])
+			hunks = append(hunks, hunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+		case current == nil:
+			// Ignore anything before the first hunk, such as "diff --git" lines
+		case strings.HasPrefix(line, " "):
+			current.oldLines = append(current.oldLines, line[1:])
+			current.newLines = append(current.newLines, line[1:])
+		case strings.HasPrefix(line, "-"):
+			current.oldLines = append(current.oldLines, line[1:])
+		case strings.HasPrefix(line, "+"):
+			current.newLines = append(current.newLines, line[1:])
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file"
+		case line == "":
+			// Some tools strip the leading space from empty context lines
+			current.oldLines = append(current.oldLines, "")
+			current.newLines = append(current.newLines, "")
+		default:
+			return nil, fmt.Errorf("unexpected line in patch: %q", line)
+		}
+	}
+
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch contains no hunks, expected a unified diff with @@ headers")
+	}
+
+	// Drop the empty context lines picked up from the trailing newline of the patch
+	last := &hunks[len(hunks)-1]
+	for len(last.oldLines) > 0 && len(last.newLines) > 0 &&
+		last.oldLines[len(last.oldLines)-1] == "" && last.newLines[len(last.newLines)-1] == "" {
+		last.oldLines = last.oldLines[:len(last.oldLines)-1]
+		last.newLines = last.newLines[:len(last.newLines)-1]
+	}
+
+	return hunks, nil
+}
+
+// applyHunks applies the hunks to the lines of a file. Each hunk is matched on its
+// context, searching outwards from the line number in its header so that patches
+// made against a slightly different version of the file still apply.
+func applyHunks(lines []string, hunks []hunk) ([]string, error) {
+	result := append([]string(nil), lines...)
+	shift := 0
+
+	for i, h := range hunks {
+		expected := h.oldStart - 1 + shift
+		if len(h.oldLines) == 0 {
+			// Pure insertion, the header gives the line after which to insert
+			expected = h.oldStart + shift
+		}
+
+		pos := findHunk(result, h.oldLines, expected)
+		if pos < 0 {
+			return nil, fmt.Errorf("hunk %d (@@ -%d) does not apply: context not found in file", i+1, h.oldStart)
+		}
+
+		updated := make([]string, 0, len(result)-len(h.oldLines)+len(h.newLines))
+		updated = append(updated, result[:pos]...)
+		updated = append(updated, h.newLines...)
+		updated = append(updated, result[pos+len(h.oldLines):]...)
+		result = updated
+		shift += len(h.newLines) - len(h.oldLines)
+	}
+
+	return result, nil
+}
+
+// findHunk returns the position of want in lines closest to expected, or -1
+func findHunk(lines, want []string, expected int) int {
+	if expected < 0 {
+		expected = 0
+	}
+	if expected > len(lines) {
+		expected = len(lines)
+	}
+
+	matches := func(pos int) bool {
+		if pos < 0 || pos+len(want) > len(lines) {
+			return false
+		}
+		for i, line := range want {
+			if lines[pos+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for delta := 0; delta <= len(lines); delta++ {
+		if matches(expected - delta) {
+			return expected - delta
+		}
+		if delta > 0 && matches(expected+delta) {
+			return expected + delta
+		}
+	}
+	return -1
+}
+
+// planPatch works out the content of a file before and after applying a unified diff
+func (t *Tool) planPatch(path string, patch string) (string, string, error) {
+	if err := checkPath(path, workspace.Write); err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(patch) == "" {
+		return "", "", fmt.Errorf("patch parameter is required for apply_patch operation")
+	}
+
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", "", err
+	}
+
+	// A patch against /dev/null creates a new file
+	oldContent := ""
+	data, err := os.ReadFile(path)
+	if err == nil {
+		oldContent = string(data)
+	} else if !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	lines, err := applyHunks(util.SplitLines(oldContent), hunks)
+	if err != nil {
+		return "", "", err
+	}
+
+	newContent := strings.Join(lines, "\n")
+	if len(lines) > 0 && (oldContent == "" || strings.HasSuffix(oldContent, "\n")) {
+		newContent += "\n"
+	}
+
+	return oldContent, newContent, nil
+}
+
+// patchFile applies a unified diff to a file
+func (t *Tool) patchFile(path string, patch string) (string, error) {
+	oldContent, newContent, err := t.planPatch(path, patch)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkpointPath(path); err != nil {
+		return "", err
+	}
+	if err := writePreservingMode(path, newContent); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Successfully patched %s (%s)", path, describeChange(oldContent, newContent)), nil
+}
+
+// diffText returns a unified diff between two versions of a file
+func diffText(oldContent, newContent string) string {
+	return util.UnifiedDiff("before", "after", oldContent, newContent)
+}
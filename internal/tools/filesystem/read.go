@@ -0,0 +1,267 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// defaultReadLimit is the number of lines returned when no limit is given
+	defaultReadLimit = 2000
+
+	// maxReadBytes caps the size of a single read so large files don't flood the context
+	maxReadBytes = 64 * 1024
+
+	// maxLineLength caps a single line, which matters for minified files
+	maxLineLength = 2000
+
+	// sniffLength is how much of the file is inspected to detect its encoding
+	sniffLength = 8000
+
+	// hexSummaryLength is how many bytes of a binary file are shown as a hex dump
+	hexSummaryLength = 256
+
+	// maxUTF16Size is the largest UTF-16 file we are willing to decode in memory
+	maxUTF16Size = 10 * 1024 * 1024
+)
+
+// fileEncoding describes how the content of a file is encoded
+type fileEncoding int
+
+const (
+	encodingUTF8 fileEncoding = iota
+	encodingUTF8BOM
+	encodingUTF16LE
+	encodingUTF16BE
+	encodingBinary
+)
+
+// readResult holds the output of a read along with a short summary of what was read
+type readResult struct {
+	content string
+	summary string
+}
+
+// detectEncoding inspects the start of a file and guesses its encoding
+func detectEncoding(head []byte) fileEncoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return encodingUTF8BOM
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return encodingUTF16LE
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return encodingUTF16BE
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return encodingBinary
+	}
+
+	// The sniffed block may end in the middle of a multi-byte rune, so
+	// only the bytes up to the last complete rune are validated
+	valid := head
+	for i := 0; i < utf8.UTFMax && len(valid) > 0; i++ {
+		if utf8.Valid(valid) {
+			return encodingUTF8
+		}
+		valid = valid[:len(valid)-1]
+	}
+	if len(head) == 0 {
+		return encodingUTF8
+	}
+	return encodingBinary
+}
+
+// readFile reads a range of lines from a text file and returns them with line numbers
+func (t *Tool) readFile(path string, offset, limit int) (readResult, error) {
+	// Validate the path for safety
+	if err := checkPath(path, workspace.Read); err != nil {
+		return readResult{}, err
+	}
+
+	if offset < 1 {
+		offset = 1
+	}
+	if limit <= 0 {
+		limit = defaultReadLimit
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return readResult{}, fmt.Errorf("file does not exist: %s. Use 'write' operation first to create it", path)
+	}
+	if err != nil {
+		return readResult{}, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return readResult{}, fmt.Errorf("%s is a directory, use the 'list' operation instead", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return readResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, sniffLength)
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return readResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	switch detectEncoding(head) {
+	case encodingBinary:
+		return summarizeBinary(path, info.Size(), head), nil
+	case encodingUTF8BOM:
+		reader.Discard(3)
+	case encodingUTF16LE, encodingUTF16BE:
+		if info.Size() > maxUTF16Size {
+			return readResult{}, fmt.Errorf("UTF-16 file %s is too large to read (%d bytes)", path, info.Size())
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return readResult{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		decoded := decodeUTF16(data)
+		return readLines(bufio.NewReader(strings.NewReader(decoded)), path, offset, limit)
+	}
+
+	return readLines(reader, path, offset, limit)
+}
+
+// readLines numbers and collects the requested range of lines, stopping at the byte cap
+func readLines(reader *bufio.Reader, path string, offset, limit int) (readResult, error) {
+	var output strings.Builder
+	lineNum := 0
+	lastLine := 0
+	truncatedBytes := false
+
+	for {
+		line, lineTruncated, err := readLine(reader, maxLineLength)
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return readResult{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		lineNum++
+
+		if lineNum >= offset && lineNum < offset+limit && !truncatedBytes {
+			if lineTruncated {
+				line += " ... [line truncated]"
+			}
+			formatted := fmt.Sprintf("%6d\t%s\n", lineNum, line)
+			if output.Len()+len(formatted) > maxReadBytes {
+				truncatedBytes = true
+			} else {
+				output.WriteString(formatted)
+				lastLine = lineNum
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	totalLines := lineNum
+	if totalLines == 0 {
+		return readResult{content: "", summary: fmt.Sprintf("%s is empty", path)}, nil
+	}
+	if offset > totalLines {
+		return readResult{}, fmt.Errorf("offset %d is beyond the end of %s (%d lines)", offset, path, totalLines)
+	}
+
+	summary := fmt.Sprintf("Read lines %d-%d of %d from %s", offset, lastLine, totalLines, path)
+	if lastLine < totalLines {
+		reason := "line limit reached"
+		if truncatedBytes {
+			reason = fmt.Sprintf("output capped at %d bytes", maxReadBytes)
+		}
+		output.WriteString(fmt.Sprintf("\n[Showing lines %d-%d of %d (%s). Use offset=%d to read more.]\n",
+			offset, lastLine, totalLines, reason, lastLine+1))
+	}
+
+	return readResult{content: output.String(), summary: summary}, nil
+}
+
+// readLine reads a single line without its line ending, keeping at most max bytes of it
+func readLine(reader *bufio.Reader, max int) (string, bool, error) {
+	var buf []byte
+	total := 0
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		total += len(chunk)
+		if room := max + 2 - len(buf); room > 0 {
+			if len(chunk) > room {
+				chunk = chunk[:room]
+			}
+			buf = append(buf, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		ending := 0
+		if bytes.HasSuffix(buf, []byte("\n")) {
+			ending = 1
+			if bytes.HasSuffix(buf, []byte("\r\n")) {
+				ending = 2
+			}
+		}
+		length := total - ending
+		line := buf[:len(buf)-ending]
+		truncated := length > max
+		if len(line) > max {
+			line = line[:max]
+		}
+		return strings.ToValidUTF8(string(line), "�"), truncated, err
+	}
+}
+
+// decodeUTF16 converts UTF-16 data with a byte order mark into a UTF-8 string
+func decodeUTF16(data []byte) string {
+	bigEndian := bytes.HasPrefix(data, []byte{0xFE, 0xFF})
+	data = data[2:]
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+
+	return string(utf16.Decode(units))
+}
+
+// summarizeBinary describes a binary file instead of returning its raw content
+func summarizeBinary(path string, size int64, head []byte) readResult {
+	contentType := http.DetectContentType(head)
+	sample := head
+	if len(sample) > hexSummaryLength {
+		sample = sample[:hexSummaryLength]
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s is a binary file (%s, %d bytes) and cannot be read as text.\n", path, contentType, size))
+	output.WriteString(fmt.Sprintf("First %d bytes:\n", len(sample)))
+	output.WriteString(hex.Dump(sample))
+
+	return readResult{
+		content: output.String(),
+		summary: fmt.Sprintf("Detected binary file %s (%s), returned hex summary", path, contentType),
+	}
+}
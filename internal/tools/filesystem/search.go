@@ -0,0 +1,329 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+const (
+	// defaultGlobLimit is the number of paths returned by glob when no limit is given
+	defaultGlobLimit = 200
+
+	// defaultGrepLimit is the number of matches returned by grep when no limit is given
+	defaultGrepLimit = 100
+
+	// maxGrepContext caps the number of context lines around each match
+	maxGrepContext = 10
+
+	// maxGrepFileSize skips files that are too large to be worth searching
+	maxGrepFileSize = 10 * 1024 * 1024
+
+	// maxGrepLineLength truncates long matching lines such as minified code
+	maxGrepLineLength = 500
+)
+
+// fileTypeExtensions maps the file types accepted by grep to their extensions
+var fileTypeExtensions = map[string][]string{
+	"go":   {".go"},
+	"py":   {".py", ".pyi"},
+	"js":   {".js", ".jsx", ".mjs", ".cjs"},
+	"ts":   {".ts", ".tsx", ".mts", ".cts"},
+	"java": {".java"},
+	"rust": {".rs"},
+	"c":    {".c", ".h"},
+	"cpp":  {".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx", ".h"},
+	"rb":   {".rb"},
+	"php":  {".php"},
+	"sh":   {".sh", ".bash", ".zsh"},
+	"md":   {".md", ".markdown"},
+	"json": {".json"},
+	"yaml": {".yaml", ".yml"},
+	"toml": {".toml"},
+	"html": {".html", ".htm"},
+	"css":  {".css", ".scss", ".less"},
+	"sql":  {".sql"},
+}
+
+// grepOptions controls a content search
+type grepOptions struct {
+	pattern         string
+	include         string
+	fileType        string
+	caseInsensitive bool
+	contextLines    int
+	maxResults      int
+}
+
+// compileGlob compiles a glob for matching paths relative to the search root.
+// Patterns without a slash match file names at any depth.
+func compileGlob(pattern string) (*regexp.Regexp, bool, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	matchName := !strings.Contains(pattern, "/")
+
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return re, matchName, nil
+}
+
+// globFiles finds files under root whose path matches the glob pattern
+func (t *Tool) globFiles(root, pattern string, maxResults int) (string, int, error) {
+	if maxResults <= 0 {
+		maxResults = defaultGlobLimit
+	}
+
+	re, matchName, err := compileGlob(pattern)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var matches []string
+	err = walkFiles(root, func(path, rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		target := rel
+		if matchName {
+			target = d.Name()
+		}
+		if re.MatchString(target) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to search directory: %w", err)
+	}
+
+	if len(matches) == 0 {
+		return fmt.Sprintf("No files matching %q found in %s", pattern, root), 0, nil
+	}
+
+	sort.Strings(matches)
+	total := len(matches)
+	if total > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	output := strings.Join(matches, "\n") + "\n"
+	if total > maxResults {
+		output += fmt.Sprintf("\n[Showing first %d of %d files. Use a more specific pattern or path to narrow the results.]\n", maxResults, total)
+	}
+	return output, total, nil
+}
+
+// grepFiles searches the content of the files under root for a regular expression
+func (t *Tool) grepFiles(root string, opts grepOptions) (string, int, error) {
+	if opts.maxResults <= 0 {
+		opts.maxResults = defaultGrepLimit
+	}
+	if opts.contextLines < 0 {
+		opts.contextLines = 0
+	}
+	if opts.contextLines > maxGrepContext {
+		opts.contextLines = maxGrepContext
+	}
+
+	expr := opts.pattern
+	if opts.caseInsensitive {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid regular expression %q: %w", opts.pattern, err)
+	}
+
+	var include *regexp.Regexp
+	includeName := false
+	if opts.include != "" {
+		if include, includeName, err = compileGlob(opts.include); err != nil {
+			return "", 0, err
+		}
+	}
+
+	var extensions []string
+	if opts.fileType != "" {
+		var ok bool
+		if extensions, ok = fileTypeExtensions[strings.ToLower(opts.fileType)]; !ok {
+			types := make([]string, 0, len(fileTypeExtensions))
+			for name := range fileTypeExtensions {
+				types = append(types, name)
+			}
+			sort.Strings(types)
+			return "", 0, fmt.Errorf("unknown file type %q, supported types are: %s", opts.fileType, strings.Join(types, ", "))
+		}
+	}
+
+	var output strings.Builder
+	matchCount := 0
+	fileCount := 0
+	limitReached := false
+
+	searchFile := func(path, rel, name string) error {
+		if include != nil {
+			target := rel
+			if includeName {
+				target = name
+			}
+			if !include.MatchString(target) {
+				return nil
+			}
+		}
+		if extensions != nil && !hasExtension(name, extensions) {
+			return nil
+		}
+
+		count, err := grepFile(path, re, opts.contextLines, opts.maxResults-matchCount, &output)
+		if err != nil {
+			// Unreadable and binary files are skipped rather than failing the search
+			return nil
+		}
+		if count > 0 {
+			fileCount++
+			matchCount += count
+		}
+		if matchCount >= opts.maxResults {
+			limitReached = true
+			return fs.SkipAll
+		}
+		return nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to access %s: %w", root, err)
+	}
+	if info.IsDir() {
+		err = walkFiles(root, func(path, rel string, d fs.DirEntry) error {
+			if d.IsDir() {
+				return nil
+			}
+			return searchFile(path, rel, d.Name())
+		})
+	} else {
+		err = searchFile(root, filepath.Base(root), filepath.Base(root))
+		if err == fs.SkipAll {
+			err = nil
+		}
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to search: %w", err)
+	}
+
+	if matchCount == 0 {
+		return fmt.Sprintf("No matches for %q found in %s", opts.pattern, root), 0, nil
+	}
+
+	if limitReached {
+		output.WriteString(fmt.Sprintf("\n[Stopped after %d matches. Use a more specific pattern, path, include or file_type to narrow the results.]\n", matchCount))
+	} else {
+		output.WriteString(fmt.Sprintf("\n[%d matches in %d files]\n", matchCount, fileCount))
+	}
+	return output.String(), matchCount, nil
+}
+
+// grepFile writes the matching lines of a single file, with context, in grep's format
+func grepFile(path string, re *regexp.Regexp, contextLines, maxMatches int, output *strings.Builder) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > maxGrepFileSize {
+		return 0, fmt.Errorf("file too large")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	head, _ := reader.Peek(sniffLength)
+	if detectEncoding(head) == encodingBinary {
+		return 0, fmt.Errorf("binary file")
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var before []string // ring of previous lines for leading context
+	lineNum := 0
+	lastPrinted := 0
+	afterRemaining := 0
+	matches := 0
+
+	printLine := func(num int, sep string, text string) {
+		if len(text) > maxGrepLineLength {
+			text = core.Truncate(text, maxGrepLineLength) + " ... [line truncated]"
+		}
+		output.WriteString(fmt.Sprintf("%s%s%d%s%s\n", path, sep, num, sep, text))
+		lastPrinted = num
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if re.MatchString(line) {
+			if matches >= maxMatches {
+				break
+			}
+			matches++
+
+			// Separate non-adjacent groups like grep does
+			first := lineNum - len(before)
+			if lastPrinted > 0 && first > lastPrinted+1 {
+				output.WriteString("--\n")
+			}
+			for i, text := range before {
+				printLine(first+i, "-", text)
+			}
+			before = before[:0]
+			printLine(lineNum, ":", line)
+			afterRemaining = contextLines
+			continue
+		}
+
+		if afterRemaining > 0 {
+			printLine(lineNum, "-", line)
+			afterRemaining--
+			continue
+		}
+
+		if contextLines > 0 {
+			before = append(before, line)
+			if len(before) > contextLines {
+				before = before[1:]
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil && matches == 0 {
+		return 0, err
+	}
+	if matches > 0 && contextLines > 0 {
+		output.WriteString("--\n")
+	}
+	return matches, nil
+}
+
+// hasExtension reports whether the file name ends in one of the extensions
+func hasExtension(name string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
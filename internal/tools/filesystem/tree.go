@@ -0,0 +1,245 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultTreeDepth is how many directory levels are expanded when no depth is given
+	defaultTreeDepth = 3
+
+	// maxTreeEntries caps the number of lines in a tree listing
+	maxTreeEntries = 500
+
+	// maxTreeVisited caps the number of entries read while building a tree listing
+	maxTreeVisited = 20000
+)
+
+// defaultSkippedDirs are dependency directories that are listed but never expanded
+var defaultSkippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// treeNode is a file or directory in a tree listing
+type treeNode struct {
+	name       string
+	isDir      bool
+	size       int64 // total size of the files below a directory
+	modTime    time.Time
+	files      int  // number of files below a directory
+	dirs       int  // number of directories below a directory
+	partial    bool // some directories below were not walked, so the totals are lower bounds
+	skipped    bool
+	unexpanded bool // below the depth limit, only the direct entries are counted
+	children   []*treeNode
+}
+
+// treeWalk collects a tree listing down to a depth, reading at most maxTreeVisited entries
+type treeWalk struct {
+	matcher       *ignoreMatcher
+	includeVendor bool
+	depth         int
+	visited       int
+}
+
+// build collects the tree below path, honoring ignore rules and skipping dependency
+// directories. Symlinks are listed but never followed, so a link to a parent directory
+// can't make the walk loop.
+func (w *treeWalk) build(path, rel string, level int) (*treeNode, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &treeNode{name: filepath.Base(path), isDir: info.IsDir(), modTime: info.ModTime()}
+	if !node.isDir {
+		node.size = info.Size()
+		return node, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	node.unexpanded = level >= w.depth
+
+	for _, entry := range entries {
+		if w.visited >= maxTreeVisited {
+			node.partial = true
+			break
+		}
+		w.visited++
+
+		childRel := entry.Name()
+		if rel != "" {
+			childRel = rel + "/" + entry.Name()
+		}
+		childPath := filepath.Join(path, entry.Name())
+
+		if entry.Name() == ".git" || w.matcher.Match(childRel, entry.IsDir()) || !walkAllowed(childPath, entry) {
+			continue
+		}
+
+		if node.unexpanded {
+			// Below the depth limit, directories are counted but not walked
+			if entry.IsDir() {
+				node.dirs++
+				node.partial = true
+			} else if info, err := entry.Info(); err == nil {
+				node.files++
+				node.size += info.Size()
+			}
+			continue
+		}
+
+		if entry.IsDir() && !w.includeVendor && defaultSkippedDirs[entry.Name()] {
+			child := &treeNode{name: entry.Name(), isDir: true, skipped: true}
+			if info, err := entry.Info(); err == nil {
+				child.modTime = info.ModTime()
+			}
+			node.children = append(node.children, child)
+			node.dirs++
+			continue
+		}
+
+		if entry.IsDir() {
+			w.matcher.loadDir(childPath, childRel)
+		}
+
+		child, err := w.build(childPath, childRel, level+1)
+		if err != nil {
+			// Skip entries we can't read rather than failing the whole listing
+			continue
+		}
+
+		node.children = append(node.children, child)
+		node.size += child.size
+		node.partial = node.partial || child.partial
+		if child.isDir {
+			node.dirs += child.dirs + 1
+			node.files += child.files
+		} else {
+			node.files++
+		}
+	}
+
+	// Directories first, then files, each in alphabetical order
+	sort.Slice(node.children, func(i, j int) bool {
+		a, b := node.children[i], node.children[j]
+		if a.isDir != b.isDir {
+			return a.isDir
+		}
+		return a.name < b.name
+	})
+
+	return node, nil
+}
+
+// treeFiles renders a recursive listing of path down to the given depth
+func (t *Tool) treeFiles(path string, depth int, includeVendor bool) (string, error) {
+	if depth <= 0 {
+		depth = defaultTreeDepth
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	walk := &treeWalk{matcher: newIgnoreMatcher(path), includeVendor: includeVendor, depth: depth}
+	root, err := walk.build(path, "", 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s/ (%s)\n", strings.TrimSuffix(path, "/"), describeDir(root)))
+
+	lines := 0
+	truncated := false
+	var render func(node *treeNode, prefix string, level int)
+	render = func(node *treeNode, prefix string, level int) {
+		for i, child := range node.children {
+			if lines >= maxTreeEntries {
+				truncated = true
+				return
+			}
+			lines++
+
+			connector, indent := "├── ", "│   "
+			if i == len(node.children)-1 {
+				connector, indent = "└── ", "    "
+			}
+
+			switch {
+			case child.skipped:
+				output.WriteString(fmt.Sprintf("%s%s%s/ [dependencies, not expanded]\n", prefix, connector, child.name))
+			case child.unexpanded && child.files+child.dirs > 0:
+				output.WriteString(fmt.Sprintf("%s%s%s/ (%s) ...\n", prefix, connector, child.name, describeDir(child)))
+			case child.isDir:
+				output.WriteString(fmt.Sprintf("%s%s%s/ (%s)\n", prefix, connector, child.name, describeDir(child)))
+				render(child, prefix+indent, level+1)
+			default:
+				output.WriteString(fmt.Sprintf("%s%s%s (%s, %s)\n", prefix, connector, child.name,
+					formatSize(child.size), child.modTime.Format("2006-01-02 15:04")))
+			}
+		}
+	}
+	render(root, "", 1)
+
+	if walk.visited >= maxTreeVisited {
+		output.WriteString(fmt.Sprintf("\n[Stopped reading after %d entries, counts are partial. Use a subdirectory path to see more.]\n", maxTreeVisited))
+	}
+	if truncated {
+		output.WriteString(fmt.Sprintf("\n[Listing truncated at %d entries. Use a smaller depth or a subdirectory path to see more.]\n", maxTreeEntries))
+	}
+
+	return output.String(), nil
+}
+
+// describeDir summarizes the contents of a directory
+func describeDir(node *treeNode) string {
+	files := "files"
+	if node.files == 1 {
+		files = "file"
+	}
+	parts := []string{fmt.Sprintf("%d %s", node.files, files)}
+	if node.partial {
+		parts[0] = "at least " + parts[0]
+	}
+	if node.dirs > 0 {
+		dirs := "dirs"
+		if node.dirs == 1 {
+			dirs = "dir"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", node.dirs, dirs))
+	}
+	parts = append(parts, formatSize(node.size))
+	if !node.modTime.IsZero() {
+		parts = append(parts, "modified "+node.modTime.Format("2006-01-02 15:04"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSize formats a byte count for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,640 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// defaultLogCount and maxLogCount bound the number of commits listed by log
+	defaultLogCount = 20
+	maxLogCount     = 200
+
+	// gitTimeout bounds a single git command
+	gitTimeout = 30 * time.Second
+)
+
+// readOnlyOperations never change the repository and run without confirmation
+var readOnlyOperations = map[string]bool{
+	"status": true, "diff": true, "log": true, "show": true, "blame": true, "branches": true,
+}
+
+// Tool runs git operations on the repository in the workspace
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new git tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform. Read-only: 'status' (branch and changed files), 'diff' (changes in the working tree, the index with staged, or against ref), 'log' (commit history), 'show' (a commit with its diff), 'blame' (last commit of each line of path), 'branches' (local and remote branches). Changing the repository, confirmed by the user: 'add' (stage paths), 'commit' (commit staged changes with message), 'checkout' (switch to the branch or commit ref, or create it), 'stash' (push, pop, apply, drop or list stashed changes)",
+			Required:    true,
+		},
+		"repo": {
+			Type:        "string",
+			Description: "Directory inside the repository (default: current directory)",
+			Required:    false,
+		},
+		"paths": {
+			Type:        "array",
+			Description: "Files or directories to limit diff, log, show and stash to, or to stage with add",
+			Required:    false,
+			Items:       map[string]interface{}{"type": "string"},
+		},
+		"path": {
+			Type:        "string",
+			Description: "File to blame",
+			Required:    false,
+		},
+		"ref": {
+			Type:        "string",
+			Description: "Commit, branch, tag or range like main..feature. For diff: compare against it. For log: history of it. For show: the commit to show (default HEAD). For blame: the revision to blame. For checkout: the branch or commit to switch to. For stash pop, apply and drop: the stash, like stash@{1}",
+			Required:    false,
+		},
+		"staged": {
+			Type:        "boolean",
+			Description: "Show staged changes instead of unstaged ones (for diff only). Defaults to false.",
+			Required:    false,
+		},
+		"max_count": {
+			Type:        "integer",
+			Description: fmt.Sprintf("Number of commits to list (for log only, default %d, at most %d)", defaultLogCount, maxLogCount),
+			Required:    false,
+		},
+		"start_line": {
+			Type:        "integer",
+			Description: "First line to blame (for blame only)",
+			Required:    false,
+		},
+		"end_line": {
+			Type:        "integer",
+			Description: "Last line to blame (for blame only)",
+			Required:    false,
+		},
+		"message": {
+			Type:        "string",
+			Description: "Commit message (required for commit), or stash message",
+			Required:    false,
+		},
+		"all": {
+			Type:        "boolean",
+			Description: "Stage all modified and deleted tracked files before committing (for commit only). Defaults to false.",
+			Required:    false,
+		},
+		"create": {
+			Type:        "boolean",
+			Description: "Create the branch ref before switching to it (for checkout only). Defaults to false.",
+			Required:    false,
+		},
+		"action": {
+			Type:        "string",
+			Description: "Stash action: 'push' (default), 'pop', 'apply', 'drop' or 'list'",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "git",
+		description: "Inspects and changes git repositories without going through the shell: status, diff, log, show, blame and branches run without confirmation, add, commit, checkout and stash ask the user first",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, only operations that change the repository are confirmed
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// NeedsConfirmation returns true for operations that change the repository
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	operation, _ := core.GetString(args, "operation", "")
+	if operation == "stash" {
+		action, _ := core.GetString(args, "action", "push")
+		return action != "list"
+	}
+	return !readOnlyOperations[operation]
+}
+
+// Execute runs the git operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	repo, err := core.GetString(args, "repo", ".")
+	if err != nil {
+		return result, err
+	}
+	access := workspace.Read
+	if !readOnlyOperations[operation] {
+		access = workspace.Write
+	}
+	dir, err := workspace.Current().Check(repo, access)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Repository path check failed: %v", err))
+		return result, err
+	}
+	paths, err := t.checkPaths(dir, args, access)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Path check failed: %v", err))
+		return result, err
+	}
+	result.AddStep(fmt.Sprintf("Using repository at %s", dir))
+
+	var output string
+	switch operation {
+	case "status":
+		output, err = t.status(ctx, dir)
+	case "diff":
+		output, err = t.diff(ctx, dir, args, paths)
+	case "log":
+		output, err = t.log(ctx, dir, args, paths)
+	case "show":
+		output, err = t.show(ctx, dir, args, paths)
+	case "blame":
+		output, err = t.blame(ctx, dir, args)
+	case "branches":
+		output, err = t.branches(ctx, dir)
+	case "add":
+		output, err = t.add(ctx, dir, paths)
+	case "commit":
+		output, err = t.commit(ctx, dir, args)
+	case "checkout":
+		output, err = t.checkout(ctx, dir, args)
+	case "stash":
+		output, err = t.stash(ctx, dir, args, paths)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+
+	output = result.TruncateOutput(output, "narrow it down with paths, ref or max_count")
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
+
+// checkPaths validates the paths parameter against the workspace policy. Paths stay
+// relative to the repository directory, where git runs.
+func (t *Tool) checkPaths(dir string, args map[string]interface{}, access workspace.Access) ([]string, error) {
+	paths, err := core.GetStringSlice(args, "paths")
+	if err != nil {
+		return nil, err
+	}
+	if path, err := core.GetString(args, "path", ""); err != nil {
+		return nil, err
+	} else if path != "" {
+		paths = append(paths, path)
+	}
+
+	policy := workspace.Current()
+	for _, path := range paths {
+		full := path
+		if !filepath.IsAbs(full) {
+			full = filepath.Join(dir, path)
+		}
+		if _, err := policy.Check(full, access); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// baseArgs come before every git command. The repository config can name programs git
+// runs, so the file system monitor and signature checks are turned off: read-only
+// operations run without confirmation and must not run code from an untrusted repository.
+var baseArgs = []string{"--no-pager", "-c", "core.quotepath=off", "-c", "color.ui=never", "-c", "core.fsmonitor=false", "-c", "log.showSignature=false"}
+
+// Run runs git in dir and returns its standard output. Other tools use it to read the
+// state of a repository with the same safeguards as the read-only operations.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	overrides := filterOverrides(ctx, dir)
+	return command(ctx, dir, append(overrides, args...), args[0])
+}
+
+// command runs git with baseArgs in dir, naming it after operation in errors
+func command(ctx context.Context, dir string, args []string, operation string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append(append([]string(nil), baseArgs...), args...)...)
+	cmd.Dir = dir
+	// Never wait for credentials or an editor, and don't take locks just to refresh the index
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true", "GIT_OPTIONAL_LOCKS=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("git is not installed")
+		}
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.String())
+		}
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", operation, message)
+	}
+	return stdout.String(), nil
+}
+
+// filterOverrides returns the config that turns off the filter drivers configured by the
+// repository itself. git runs the clean command of a driver on modified files for status
+// and diff, so a repository could otherwise run its own code. Drivers from the user's
+// global or system config, like git-lfs, are kept.
+func filterOverrides(ctx context.Context, dir string) []string {
+	output, err := command(ctx, dir, []string{"config", "--list", "--show-scope"}, "config")
+	scoped := err == nil
+	if !scoped {
+		// git before 2.26 can't show scopes, so every driver is turned off
+		output, _ = command(ctx, dir, []string{"config", "--list"}, "config")
+	}
+
+	seen := map[string]bool{}
+	var overrides []string
+	for _, line := range strings.Split(output, "\n") {
+		if scoped {
+			scope, entry, ok := strings.Cut(line, "\t")
+			if !ok || scope == "global" || scope == "system" {
+				continue
+			}
+			line = entry
+		}
+		key, _, _ := strings.Cut(line, "=")
+		name, ok := strings.CutPrefix(key, "filter.")
+		dot := strings.LastIndex(name, ".")
+		if !ok || dot <= 0 || seen[name[:dot]] {
+			continue
+		}
+		switch name[dot+1:] {
+		case "clean", "smudge", "process":
+		default:
+			continue
+		}
+		name = name[:dot]
+		seen[name] = true
+		for _, setting := range []string{"clean=", "smudge=", "process=", "required=false"} {
+			overrides = append(overrides, "-c", "filter."+name+"."+setting)
+		}
+	}
+	return overrides
+}
+
+// checkRef rejects refs that git would parse as options
+func checkRef(ref string) error {
+	if strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n\x00") {
+		return fmt.Errorf("invalid ref: %q", ref)
+	}
+	return nil
+}
+
+// withPaths appends a path filter after the "--" separator
+func withPaths(args []string, paths []string) []string {
+	if len(paths) == 0 {
+		return args
+	}
+	return append(append(args, "--"), paths...)
+}
+
+// diff shows changes of the working tree, the index or against a ref
+func (t *Tool) diff(ctx context.Context, dir string, args map[string]interface{}, paths []string) (string, error) {
+	staged, err := core.GetBool(args, "staged", false)
+	if err != nil {
+		return "", err
+	}
+	ref, err := core.GetString(args, "ref", "")
+	if err != nil {
+		return "", err
+	}
+
+	// External diff programs and textconv filters configured by the repository are never run
+	gitArgs := []string{"diff", "--patch-with-stat", "--no-ext-diff", "--no-textconv"}
+	if staged {
+		gitArgs = append(gitArgs, "--cached")
+	}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+		gitArgs = append(gitArgs, ref)
+	}
+
+	output, err := Run(ctx, dir, withPaths(gitArgs, paths)...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		switch {
+		case staged:
+			return "No staged changes", nil
+		case ref != "":
+			return fmt.Sprintf("No changes against %s", ref), nil
+		default:
+			return "No unstaged changes (use staged for changes added to the index)", nil
+		}
+	}
+	return output, nil
+}
+
+// log lists commits, one per line
+func (t *Tool) log(ctx context.Context, dir string, args map[string]interface{}, paths []string) (string, error) {
+	count, err := core.GetInt(args, "max_count", defaultLogCount)
+	if err != nil {
+		return "", err
+	}
+	count = max(1, min(count, maxLogCount))
+	ref, err := core.GetString(args, "ref", "")
+	if err != nil {
+		return "", err
+	}
+
+	gitArgs := []string{"log", "-n", strconv.Itoa(count), "--date=short", "--format=%h%x1f%ad%x1f%an%x1f%D%x1f%s"}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+		gitArgs = append(gitArgs, ref)
+	}
+
+	output, err := Run(ctx, dir, withPaths(gitArgs, paths)...)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	commits := 0
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		commits++
+		result.WriteString(fmt.Sprintf("%s %s %s: %s", fields[0], fields[1], fields[2], fields[4]))
+		if fields[3] != "" {
+			result.WriteString(fmt.Sprintf(" (%s)", fields[3]))
+		}
+		result.WriteString("\n")
+	}
+	if commits == 0 {
+		return "No commits", nil
+	}
+	if commits == count {
+		result.WriteString(fmt.Sprintf("\nShowing the last %d commits, use max_count to see more\n", count))
+	}
+	return result.String(), nil
+}
+
+// show shows a commit with its message and diff
+func (t *Tool) show(ctx context.Context, dir string, args map[string]interface{}, paths []string) (string, error) {
+	ref, err := core.GetString(args, "ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := checkRef(ref); err != nil {
+		return "", err
+	}
+	return Run(ctx, dir, withPaths([]string{"show", "--patch-with-stat", "--no-ext-diff", "--no-textconv", "--format=fuller", ref}, paths)...)
+}
+
+// branches lists local and remote branches with their upstream and last commit
+func (t *Tool) branches(ctx context.Context, dir string) (string, error) {
+	output, err := Run(ctx, dir, "for-each-ref",
+		"--format=%(HEAD)%1f%(refname)%1f%(refname:short)%1f%(objectname:short)%1f%(upstream:short)%1f%(upstream:track)%1f%(committerdate:short)%1f%(contents:subject)",
+		"refs/heads", "refs/remotes")
+	if err != nil {
+		return "", err
+	}
+
+	var local, remote strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 8 || strings.HasSuffix(fields[1], "/HEAD") {
+			continue
+		}
+		marker := "  "
+		if fields[0] == "*" {
+			marker = "* "
+		}
+		entry := fmt.Sprintf("%s%s %s", marker, fields[2], fields[3])
+		if fields[4] != "" {
+			tracking := fields[4]
+			if track := strings.Trim(fields[5], "[]"); track != "" {
+				tracking += ": " + track
+			}
+			entry += " [" + tracking + "]"
+		}
+		entry += fmt.Sprintf(" %s %s\n", fields[6], fields[7])
+
+		if strings.HasPrefix(fields[1], "refs/remotes/") {
+			remote.WriteString(entry)
+		} else {
+			local.WriteString(entry)
+		}
+	}
+
+	if local.Len() == 0 && remote.Len() == 0 {
+		return "No branches (the repository has no commits yet)", nil
+	}
+	result := "Local branches:\n" + local.String()
+	if remote.Len() > 0 {
+		result += "\nRemote branches:\n" + remote.String()
+	}
+	return result, nil
+}
+
+// add stages paths and shows the resulting status
+func (t *Tool) add(ctx context.Context, dir string, paths []string) (string, error) {
+	if len(paths) == 0 {
+		return "", fmt.Errorf("paths parameter is required for add operation")
+	}
+	if _, err := Run(ctx, dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return "", err
+	}
+
+	status, err := t.status(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Staged %s\n\n%s", strings.Join(paths, ", "), status), nil
+}
+
+// commit commits the staged changes
+func (t *Tool) commit(ctx context.Context, dir string, args map[string]interface{}) (string, error) {
+	message, err := core.GetString(args, "message", "")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("message parameter is required for commit operation")
+	}
+	all, err := core.GetBool(args, "all", false)
+	if err != nil {
+		return "", err
+	}
+
+	gitArgs := []string{"commit", "-m", message}
+	if all {
+		gitArgs = append(gitArgs, "--all")
+	}
+	return Run(ctx, dir, gitArgs...)
+}
+
+// checkout switches to a branch or commit, creating the branch if asked to
+func (t *Tool) checkout(ctx context.Context, dir string, args map[string]interface{}) (string, error) {
+	ref, err := core.GetString(args, "ref", "")
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		return "", fmt.Errorf("ref parameter is required for checkout operation")
+	}
+	if err := checkRef(ref); err != nil {
+		return "", err
+	}
+	create, err := core.GetBool(args, "create", false)
+	if err != nil {
+		return "", err
+	}
+
+	gitArgs := []string{"checkout", ref, "--"}
+	if create {
+		gitArgs = []string{"checkout", "-b", ref}
+	}
+	// checkout reports on stderr, so describe the result ourselves
+	if _, err := Run(ctx, dir, gitArgs...); err != nil {
+		return "", err
+	}
+	status, err := t.status(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if create {
+		return fmt.Sprintf("Created and switched to branch %s\n\n%s", ref, status), nil
+	}
+	return fmt.Sprintf("Switched to %s\n\n%s", ref, status), nil
+}
+
+// stash saves, restores or lists stashed changes
+func (t *Tool) stash(ctx context.Context, dir string, args map[string]interface{}, paths []string) (string, error) {
+	action, err := core.GetString(args, "action", "push")
+	if err != nil {
+		return "", err
+	}
+	ref, err := core.GetString(args, "ref", "")
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+	}
+
+	switch action {
+	case "", "push":
+		gitArgs := []string{"stash", "push", "--include-untracked"}
+		if message, _ := core.GetString(args, "message", ""); message != "" {
+			gitArgs = append(gitArgs, "-m", message)
+		}
+		output, err := Run(ctx, dir, withPaths(gitArgs, paths)...)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(output), nil
+	case "pop", "apply", "drop":
+		gitArgs := []string{"stash", action}
+		if ref != "" {
+			gitArgs = append(gitArgs, ref)
+		}
+		output, err := Run(ctx, dir, gitArgs...)
+		if err != nil {
+			return "", err
+		}
+		if action == "drop" {
+			return strings.TrimSpace(output), nil
+		}
+		status, err := t.status(ctx, dir)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Applied %s\n\n%s", firstNonEmpty(ref, "the latest stash"), status), nil
+	case "list":
+		output, err := Run(ctx, dir, "stash", "list", "--format=%gd%x1f%cs%x1f%gs")
+		if err != nil {
+			return "", err
+		}
+		var result strings.Builder
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			fields := strings.Split(line, "\x1f")
+			if len(fields) == 3 {
+				result.WriteString(fmt.Sprintf("%s %s %s\n", fields[0], fields[1], fields[2]))
+			}
+		}
+		if result.Len() == 0 {
+			return "No stashes", nil
+		}
+		return result.String(), nil
+	default:
+		return "", fmt.Errorf("unknown stash action: %s (use push, pop, apply, drop or list)", action)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,250 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+// changeNames describes the change codes of git status
+var changeNames = map[byte]string{
+	'M': "modified", 'T': "type changed", 'A': "added", 'D': "deleted",
+	'R': "renamed", 'C': "copied", 'U': "unmerged",
+}
+
+// statusEntry is a changed file reported by git status
+type statusEntry struct {
+	change string
+	path   string
+}
+
+// status reports the branch, its upstream and the staged, unstaged, untracked and conflicting files
+func (t *Tool) status(ctx context.Context, dir string) (string, error) {
+	output, err := Run(ctx, dir, "status", "--porcelain=v2", "--branch", "-z")
+	if err != nil {
+		return "", err
+	}
+
+	var head, upstream, aheadBehind string
+	var staged, unstaged, untracked, conflicts []statusEntry
+
+	// With -z, entries end with NUL and renames carry their original path as an extra entry
+	records := strings.Split(output, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		switch {
+		case strings.HasPrefix(record, "# branch.head "):
+			head = strings.TrimPrefix(record, "# branch.head ")
+		case strings.HasPrefix(record, "# branch.upstream "):
+			upstream = strings.TrimPrefix(record, "# branch.upstream ")
+		case strings.HasPrefix(record, "# branch.ab "):
+			aheadBehind = strings.TrimPrefix(record, "# branch.ab ")
+		case strings.HasPrefix(record, "1 "), strings.HasPrefix(record, "2 "):
+			// 1 XY sub mH mI mW hH hI path, type 2 adds a similarity score before the path
+			fieldCount := 9
+			if record[0] == '2' {
+				fieldCount = 10
+			}
+			fields := strings.SplitN(record, " ", fieldCount)
+			if len(fields) < fieldCount {
+				continue
+			}
+			path := fields[fieldCount-1]
+			if record[0] == '2' && i+1 < len(records) {
+				i++
+				path = records[i] + " -> " + path
+			}
+			xy := fields[1]
+			if xy[0] != '.' {
+				staged = append(staged, statusEntry{changeNames[xy[0]], path})
+			}
+			if xy[1] != '.' {
+				unstaged = append(unstaged, statusEntry{changeNames[xy[1]], path})
+			}
+		case strings.HasPrefix(record, "u "):
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) == 11 {
+				conflicts = append(conflicts, statusEntry{conflictName(fields[1]), fields[10]})
+			}
+		case strings.HasPrefix(record, "? "):
+			untracked = append(untracked, statusEntry{path: strings.TrimPrefix(record, "? ")})
+		}
+	}
+
+	var result strings.Builder
+	switch head {
+	case "(detached)":
+		result.WriteString("Branch: detached HEAD")
+	case "":
+		result.WriteString("Branch: unknown")
+	default:
+		result.WriteString("Branch: " + head)
+	}
+	if upstream != "" {
+		result.WriteString(" (tracking " + upstream)
+		if ahead, behind, ok := strings.Cut(aheadBehind, " "); ok {
+			ahead, behind = strings.TrimPrefix(ahead, "+"), strings.TrimPrefix(behind, "-")
+			if ahead != "0" || behind != "0" {
+				result.WriteString(fmt.Sprintf(", ahead %s, behind %s", ahead, behind))
+			} else {
+				result.WriteString(", up to date")
+			}
+		}
+		result.WriteString(")")
+	}
+	result.WriteString("\n")
+
+	if len(staged)+len(unstaged)+len(untracked)+len(conflicts) == 0 {
+		result.WriteString("Working tree clean\n")
+		return result.String(), nil
+	}
+	writeEntries(&result, "Conflicts", conflicts)
+	writeEntries(&result, "Staged", staged)
+	writeEntries(&result, "Not staged", unstaged)
+	writeEntries(&result, "Untracked", untracked)
+	return result.String(), nil
+}
+
+func writeEntries(result *strings.Builder, title string, entries []statusEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	result.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(entries)))
+	for _, entry := range entries {
+		if entry.change == "" {
+			result.WriteString(fmt.Sprintf("  %s\n", entry.path))
+		} else {
+			result.WriteString(fmt.Sprintf("  %s: %s\n", entry.change, entry.path))
+		}
+	}
+}
+
+// conflictName describes the unmerged states of git status
+func conflictName(xy string) string {
+	switch xy {
+	case "DD":
+		return "both deleted"
+	case "AU":
+		return "added by us"
+	case "UD":
+		return "deleted by them"
+	case "UA":
+		return "added by them"
+	case "DU":
+		return "deleted by us"
+	case "AA":
+		return "both added"
+	default:
+		return "both modified"
+	}
+}
+
+// blameCommit is what blame reports about a commit the first time it appears
+type blameCommit struct {
+	author string
+	date   string
+}
+
+// blame shows the commit, author and date that last changed each line of a file
+func (t *Tool) blame(ctx context.Context, dir string, args map[string]interface{}) (string, error) {
+	path, err := core.GetString(args, "path", "")
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("path parameter is required for blame operation")
+	}
+	startLine, err := core.GetInt(args, "start_line", 0)
+	if err != nil {
+		return "", err
+	}
+	endLine, err := core.GetInt(args, "end_line", 0)
+	if err != nil {
+		return "", err
+	}
+	ref, err := core.GetString(args, "ref", "")
+	if err != nil {
+		return "", err
+	}
+
+	gitArgs := []string{"blame", "--porcelain", "--no-textconv"}
+	if startLine > 0 || endLine > 0 {
+		lineRange := strconv.Itoa(max(startLine, 1)) + ","
+		if endLine > 0 {
+			lineRange += strconv.Itoa(endLine)
+		}
+		gitArgs = append(gitArgs, "-L", lineRange)
+	}
+	if ref != "" {
+		if err := checkRef(ref); err != nil {
+			return "", err
+		}
+		gitArgs = append(gitArgs, ref)
+	}
+	output, err := Run(ctx, dir, append(gitArgs, "--", path)...)
+	if err != nil {
+		return "", err
+	}
+
+	// Each line starts with a header "<sha> <original line> <final line> [<group size>]",
+	// followed by the commit details the first time a commit appears, then the line itself
+	commits := make(map[string]*blameCommit)
+	var result strings.Builder
+	var sha, lineNumber string
+	for _, line := range strings.Split(output, "\n") {
+		if content, ok := strings.CutPrefix(line, "\t"); ok {
+			commit := commits[sha]
+			short := sha
+			if len(short) > 8 {
+				short = short[:8]
+			}
+			if strings.Trim(sha, "0") == "" {
+				short = "uncommitted"
+			}
+			result.WriteString(fmt.Sprintf("%-8s %-16s %s %5s| %s\n", short, truncate(commit.author, 16), commit.date, lineNumber, content))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && (len(fields[0]) == 40 || len(fields[0]) == 64) && isHex(fields[0]) {
+			sha, lineNumber = fields[0], fields[2]
+			if commits[sha] == nil {
+				commits[sha] = &blameCommit{}
+			}
+			continue
+		}
+		if author, ok := strings.CutPrefix(line, "author "); ok && commits[sha] != nil {
+			commits[sha].author = author
+		}
+		if timestamp, ok := strings.CutPrefix(line, "author-time "); ok && commits[sha] != nil {
+			if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+				commits[sha].date = time.Unix(seconds, 0).UTC().Format("2006-01-02")
+			}
+		}
+	}
+	if result.Len() == 0 {
+		return fmt.Sprintf("No lines to blame in %s", path), nil
+	}
+	return result.String(), nil
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
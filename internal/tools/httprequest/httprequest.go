@@ -0,0 +1,540 @@
+package httprequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
+)
+
+const (
+	// maxResponseBody caps how much of a response body is read
+	maxResponseBody = 1024 * 1024
+
+	// maxOutputBody caps how much of the response body is returned to the model
+	maxOutputBody = 16000
+)
+
+// secretPattern matches secret references like {{secret:github_token}}
+var secretPattern = regexp.MustCompile(`\{\{\s*secret:([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// shownHeaders are the response headers worth returning to the model
+var shownHeaders = []string{
+	"Content-Type", "Content-Length", "Location", "ETag", "Last-Modified", "Cache-Control",
+	"Retry-After", "Link", "WWW-Authenticate", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+}
+
+// safeMethods don't change anything on the server and run without confirmation
+var safeMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"OPTIONS": true,
+}
+
+// Tool sends HTTP requests to APIs
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+	httpClient  *http.Client
+	secrets     map[string]string
+}
+
+// New creates a new HTTP request tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"method": {
+			Type:        "string",
+			Description: "HTTP method: GET, POST, PUT, PATCH, DELETE, HEAD or OPTIONS. Defaults to GET. Methods other than GET, HEAD and OPTIONS, and requests that use secrets, need the user's confirmation.",
+			Required:    false,
+		},
+		"url": {
+			Type:        "string",
+			Description: "URL to send the request to",
+			Required:    true,
+		},
+		"headers": {
+			Type:        "object",
+			Description: "Request headers as an object of name to value",
+			Required:    false,
+		},
+		"query": {
+			Type:        "object",
+			Description: "Query parameters as an object of name to value, added to those already in the URL",
+			Required:    false,
+		},
+		"json": {
+			Type:        "object",
+			Description: "JSON request body, sent with Content-Type: application/json",
+			Required:    false,
+		},
+		"form": {
+			Type:        "object",
+			Description: "Form fields as an object of name to value, sent URL-encoded",
+			Required:    false,
+		},
+		"body": {
+			Type:        "string",
+			Description: "Raw request body, for other content types. Set the Content-Type header to match.",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "http",
+		description: "Send HTTP requests to APIs with any method, headers, query parameters and a JSON, form or raw body. Returns the status, the relevant response headers and the body, with JSON pretty-printed. Never put credentials in requests: reference secrets configured by the user as {{secret:name}} in the URL, headers, query or body, and they are filled in when the request is sent.",
+		parameters:  parameters,
+		httpClient:  egress.NewClient(30 * time.Second),
+	}
+}
+
+// SetSecrets sets the secrets that requests can reference by name. Values starting
+// with "env:" are read from the named environment variable when they are used.
+func (t *Tool) SetSecrets(secrets map[string]string) {
+	t.secrets = make(map[string]string, len(secrets))
+	for name, value := range secrets {
+		// Config keys are case-insensitive, so secret names are too
+		t.secrets[strings.ToLower(name)] = value
+	}
+}
+
+// SetHTTPClient sets the client requests are sent with
+func (t *Tool) SetHTTPClient(client *http.Client) {
+	t.httpClient = client
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// Execute sends the request and returns the response
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{}
+
+	method, err := requestMethod(args)
+	if err != nil {
+		return result, err
+	}
+	result.ToolMethod = strings.ToLower(method)
+
+	rawURL, err := core.GetString(args, "url", "")
+	if err != nil {
+		return result, err
+	}
+	if rawURL == "" {
+		return result, fmt.Errorf("url must be a non-empty string")
+	}
+	result.AddStep(fmt.Sprintf("%s %s", method, rawURL))
+
+	// Secrets are only filled in here, steps and errors show the references
+	used := map[string]string{}
+	req, err := t.buildRequest(ctx, method, rawURL, args, used)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Invalid request: %v", err))
+		return result, err
+	}
+	if len(used) > 0 {
+		result.AddStep(fmt.Sprintf("Using secrets: %s", strings.Join(sortedKeys(used), ", ")))
+	}
+
+	client := t.httpClient
+	if len(used) > 0 {
+		client = dropSecretsOnRedirect(client, used)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("%s", redact(err.Error(), used))
+		result.AddStep(fmt.Sprintf("Request failed: %v", err))
+		return result, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody+1))
+	if err != nil {
+		return result, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := len(body) > maxResponseBody
+	if truncated {
+		body = body[:maxResponseBody]
+	}
+
+	result.AddStep(fmt.Sprintf("Received %s in %dms (%d bytes)", resp.Status, time.Since(start).Milliseconds(), len(body)))
+
+	// Error statuses are results for the model to act on, not tool failures
+	result.Output = redact(formatResponse(resp, body, truncated), used)
+	return result, nil
+}
+
+// requestMethod returns the upper-cased method parameter, GET by default
+func requestMethod(args map[string]interface{}) (string, error) {
+	method, err := core.GetString(args, "method", "GET")
+	if err != nil {
+		return "", err
+	}
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method == "" {
+		method = "GET"
+	}
+
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE":
+		return method, nil
+	default:
+		return "", fmt.Errorf("unsupported method %q, supported methods are GET, HEAD, OPTIONS, POST, PUT, PATCH and DELETE", method)
+	}
+}
+
+// buildRequest creates the request from the parameters, filling in secret references.
+// The secrets it uses are recorded in used so they can be redacted from the output.
+func (t *Tool) buildRequest(ctx context.Context, method, rawURL string, args map[string]interface{}, used map[string]string) (*http.Request, error) {
+	expand := func(s string) (string, error) {
+		return t.expandSecrets(s, used)
+	}
+
+	target, err := expand(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q", rawURL)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q, use http or https", parsed.Scheme)
+	}
+
+	query, err := stringMap(args, "query")
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		values := parsed.Query()
+		for key, value := range query {
+			if value, err = expand(value); err != nil {
+				return nil, err
+			}
+			values.Add(key, value)
+		}
+		parsed.RawQuery = values.Encode()
+	}
+
+	body, contentType, err := requestBody(args, expand)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, parsed.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", "kiwi")
+
+	headers, err := stringMap(args, "headers")
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		if value, err = expand(value); err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// requestBody builds the body from the json, form or body parameter, of which only one may be set
+func requestBody(args map[string]interface{}, expand func(string) (string, error)) (io.Reader, string, error) {
+	set := 0
+	for _, name := range []string{"json", "form", "body"} {
+		if args[name] != nil && args[name] != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, "", fmt.Errorf("only one of json, form and body can be set")
+	}
+
+	if value, ok := args["json"]; ok && value != nil {
+		// Some models send the JSON body as a string
+		if s, ok := value.(string); ok {
+			if !json.Valid([]byte(s)) {
+				return nil, "", fmt.Errorf("json must be an object or valid JSON text")
+			}
+			value = json.RawMessage(s)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid json body: %w", err)
+		}
+		expanded, err := expandJSON(string(data), expand)
+		if err != nil {
+			return nil, "", err
+		}
+		return strings.NewReader(expanded), "application/json", nil
+	}
+
+	form, err := stringMap(args, "form")
+	if err != nil {
+		return nil, "", err
+	}
+	if len(form) > 0 {
+		values := url.Values{}
+		for key, value := range form {
+			if value, err = expand(value); err != nil {
+				return nil, "", err
+			}
+			values.Add(key, value)
+		}
+		return strings.NewReader(values.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	raw, err := core.GetString(args, "body", "")
+	if err != nil {
+		return nil, "", err
+	}
+	if raw != "" {
+		if raw, err = expand(raw); err != nil {
+			return nil, "", err
+		}
+		return strings.NewReader(raw), "", nil
+	}
+	return nil, "", nil
+}
+
+// expandJSON fills in secret references inside serialized JSON, escaping the values
+func expandJSON(data string, expand func(string) (string, error)) (string, error) {
+	var expandErr error
+	expanded := secretPattern.ReplaceAllStringFunc(data, func(ref string) string {
+		value, err := expand(ref)
+		if err != nil {
+			expandErr = err
+			return ref
+		}
+		quoted, _ := json.Marshal(value)
+		return string(quoted[1 : len(quoted)-1])
+	})
+	return expanded, expandErr
+}
+
+// expandSecrets replaces secret references in s with their values
+func (t *Tool) expandSecrets(s string, used map[string]string) (string, error) {
+	var expandErr error
+	expanded := secretPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := strings.ToLower(secretPattern.FindStringSubmatch(ref)[1])
+		value, ok := t.secrets[name]
+		if !ok {
+			expandErr = fmt.Errorf("unknown secret %q, configure it with: kiwi config set tools.http.secrets.%s <value>", name, name)
+			return ref
+		}
+		if env, ok := strings.CutPrefix(value, "env:"); ok {
+			value = os.Getenv(env)
+			if value == "" {
+				expandErr = fmt.Errorf("secret %q reads the environment variable %s, which is not set", name, env)
+				return ref
+			}
+		}
+		used[name] = value
+		return value
+	})
+	return expanded, expandErr
+}
+
+// redact replaces the values of used secrets with their references
+func redact(text string, used map[string]string) string {
+	for name, value := range used {
+		if value != "" {
+			text = strings.ReplaceAll(text, value, "{{secret:"+name+"}}")
+			if escaped := url.QueryEscape(value); escaped != value {
+				text = strings.ReplaceAll(text, escaped, "{{secret:"+name+"}}")
+			}
+		}
+	}
+	return text
+}
+
+// formatResponse renders the status line, the relevant headers and the body
+func formatResponse(resp *http.Response, body []byte, truncated bool) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s %s\n", resp.Proto, resp.Status))
+	for _, name := range shownHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			output.WriteString(fmt.Sprintf("%s: %s\n", name, value))
+		}
+	}
+	output.WriteString("\n")
+
+	if len(body) == 0 {
+		output.WriteString("(empty body)\n")
+		return output.String()
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	text, ok := formatBody(body, contentType)
+	if !ok {
+		output.WriteString(fmt.Sprintf("(binary body of %d bytes, %s)\n", len(body), orUnknown(contentType)))
+		return output.String()
+	}
+
+	if len(text) > maxOutputBody {
+		text = core.Truncate(text, maxOutputBody)
+		truncated = true
+	}
+	output.WriteString(text)
+	if truncated {
+		output.WriteString("\n...\n[Body truncated due to length]")
+	}
+	return output.String()
+}
+
+// formatBody returns the body as text, pretty-printing JSON. It reports false for binary bodies.
+func formatBody(body []byte, contentType string) (string, bool) {
+	if strings.Contains(contentType, "json") || json.Valid(body) {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "", "  "); err == nil {
+			return pretty.String(), true
+		}
+	}
+	if !utf8.Valid(body) && !strings.HasPrefix(contentType, "text/") {
+		return "", false
+	}
+	return strings.TrimSpace(string(body)), true
+}
+
+// stringMap reads an object parameter whose values are converted to strings
+func stringMap(args map[string]interface{}, name string) (map[string]string, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	// Some models send objects as JSON text
+	if s, ok := value.(string); ok {
+		if strings.TrimSpace(s) == "" {
+			return nil, nil
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+			return nil, fmt.Errorf("%s must be an object", name)
+		}
+		value = decoded
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an object", name)
+	}
+
+	values := make(map[string]string, len(object))
+	for key, v := range object {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown type"
+	}
+	return s
+}
+
+// RequiresConfirmation returns false, only requests that change something are confirmed
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// NeedsConfirmation returns true for methods other than GET, HEAD and OPTIONS, and for
+// requests that reference secrets, so a secret is never sent to a host the user didn't see
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	if usesSecrets(args) {
+		return true
+	}
+	method, err := requestMethod(args)
+	if err != nil {
+		// Let Execute report the error
+		return false
+	}
+	return !safeMethods[method]
+}
+
+// usesSecrets returns true if any parameter references a secret
+func usesSecrets(args map[string]interface{}) bool {
+	data, err := json.Marshal(args)
+	if err != nil {
+		// Parameters that can't be serialized can't be checked, so have them confirmed
+		return true
+	}
+	return secretPattern.Match(data)
+}
+
+// dropSecretsOnRedirect returns a copy of client that removes the headers carrying used
+// secrets when a redirect leaves the host the request was sent to. The standard library
+// only does this for Authorization and Cookie, not for headers like X-API-Key.
+func dropSecretsOnRedirect(client *http.Client, used map[string]string) *http.Client {
+	copied := *client
+	next := client.CheckRedirect
+	copied.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			for name, values := range req.Header {
+				for _, value := range values {
+					if redact(value, used) != value {
+						req.Header.Del(name)
+						break
+					}
+				}
+			}
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &copied
+}
@@ -0,0 +1,204 @@
+package memorytool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/memory"
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+// Tool saves and recalls facts across sessions, for the current project or globally
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+	store       *memory.Store
+}
+
+// New creates a new memory tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'save' (remember content), 'list' (memories of the project and global ones), 'search' (memories containing words of query), 'delete' (forget the memory with id, of the project or a global one). save and delete need the user's confirmation",
+			Required:    true,
+		},
+		"content": {
+			Type:        "string",
+			Description: fmt.Sprintf("A short, self-contained fact to remember, like 'Run make lint instead of golangci-lint directly' (for save only, at most %d characters)", memory.MaxContentLength),
+			Required:    false,
+		},
+		"scope": {
+			Type:        "string",
+			Description: "'project' for facts about the current project (default), 'global' for preferences that apply everywhere (for save and list)",
+			Required:    false,
+		},
+		"query": {
+			Type:        "string",
+			Description: "Words to look for (for search only)",
+			Required:    false,
+		},
+		"id": {
+			Type:        "string",
+			Description: "ID of the memory, as shown in brackets (for delete only)",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "memory",
+		description: "Remembers short facts across sessions, such as project conventions, commands and user preferences, scoped to the current project (its git repository) or global. Saved memories are shown at the start of every new session",
+		parameters:  parameters,
+	}
+}
+
+// SetStore sets where memories are kept
+func (t *Tool) SetStore(store *memory.Store) {
+	t.store = store
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, only the operations that change memories are confirmed
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// NeedsConfirmation returns true for save and delete. Memories are added to the system
+// prompt of every later session, so the user approves each one, or a fact injected by a
+// web page or file would persist across sessions.
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	operation, _ := core.GetString(args, "operation", "")
+	return operation == "save" || operation == "delete"
+}
+
+// Execute runs the memory operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	if t.store == nil {
+		return result, fmt.Errorf("memory store is not available")
+	}
+	scope, err := core.GetString(args, "scope", "")
+	if err != nil {
+		return result, err
+	}
+	if scope != "" && scope != "project" && scope != "global" {
+		return result, fmt.Errorf("unknown scope: %s, use 'project' or 'global'", scope)
+	}
+	project := memory.CurrentProject()
+	result.AddStep(fmt.Sprintf("Project: %s", project))
+
+	var output string
+	switch operation {
+	case "save":
+		content, err := core.GetString(args, "content", "")
+		if err != nil {
+			return result, err
+		}
+		target := project
+		if scope == "global" {
+			target = ""
+		}
+		m, err := t.store.Add(target, content)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error: %v", err))
+			return result, err
+		}
+		output = fmt.Sprintf("Saved memory [%s] for %s: %s", m.ID, scopeLabel(m), m.Content)
+	case "list":
+		var memories []memory.Memory
+		switch scope {
+		case "project":
+			memories, err = t.store.List(project)
+		case "global":
+			memories, err = t.store.List("")
+		default:
+			memories, err = t.store.Relevant(project)
+		}
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error: %v", err))
+			return result, err
+		}
+		output = format(memories, "No memories saved")
+	case "search":
+		query, err := core.GetString(args, "query", "")
+		if err != nil {
+			return result, err
+		}
+		memories, err := t.store.Search(project, query)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error: %v", err))
+			return result, err
+		}
+		output = format(memories, fmt.Sprintf("No memories match %q", query))
+	case "delete":
+		id, err := core.GetString(args, "id", "")
+		if err != nil {
+			return result, err
+		}
+		if id == "" {
+			return result, fmt.Errorf("id parameter is required for delete")
+		}
+		m, err := t.store.Delete(project, strings.Trim(id, "[] "))
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error: %v", err))
+			return result, err
+		}
+		output = fmt.Sprintf("Deleted memory [%s]: %s", m.ID, m.Content)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
+
+// format lists memories with their IDs and scopes
+func format(memories []memory.Memory, empty string) string {
+	if len(memories) == 0 {
+		return empty
+	}
+	var b strings.Builder
+	for _, m := range memories {
+		b.WriteString(fmt.Sprintf("- [%s] %s (%s, %s)\n", m.ID, m.Content, scopeLabel(m), m.CreatedAt.Format("2006-01-02")))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func scopeLabel(m memory.Memory) string {
+	if m.Project == "" {
+		return "global"
+	}
+	return "project"
+}
@@ -0,0 +1,166 @@
+package plantool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/saurabh0719/kiwi/internal/plan"
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+// Tool keeps the todo list of a multi-step request, shown to the user as a checklist
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new plan tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'create' (start a new plan from items, replacing the current one), 'add' (append items), 'update' (set the status of item), 'show' (the current plan)",
+			Required:    true,
+		},
+		"goal": {
+			Type:        "string",
+			Description: "One line describing what the plan achieves (for create only)",
+			Required:    false,
+		},
+		"items": {
+			Type:        "array",
+			Description: fmt.Sprintf("Short, concrete steps in the order they will be done (for create and add, at most %d in a plan)", plan.MaxItems),
+			Required:    false,
+			Items:       map[string]interface{}{"type": "string"},
+		},
+		"item": {
+			Type:        "integer",
+			Description: "Number of the item to update, starting at 1 (for update only)",
+			Required:    false,
+		},
+		"status": {
+			Type:        "string",
+			Description: "New status of item: 'pending', 'in_progress' or 'done' (for update only)",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "plan",
+		description: "Keeps a todo list for multi-step requests, shown to the user as a live checklist and saved in the session. Create it before starting, mark each item in_progress when starting it and done when finished",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, the plan only changes the session
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// Execute runs the plan operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	var change func(*plan.Plan) (*plan.Plan, error)
+	switch operation {
+	case "create":
+		goal, err := core.GetString(args, "goal", "")
+		if err != nil {
+			return result, err
+		}
+		items, err := core.GetStringSlice(args, "items")
+		if err != nil {
+			return result, err
+		}
+		change = func(*plan.Plan) (*plan.Plan, error) {
+			return plan.New(goal, items)
+		}
+	case "add":
+		items, err := core.GetStringSlice(args, "items")
+		if err != nil {
+			return result, err
+		}
+		change = func(p *plan.Plan) (*plan.Plan, error) {
+			if p == nil {
+				return plan.New("", items)
+			}
+			return p, p.Add(items)
+		}
+	case "update":
+		number, err := core.GetInt(args, "item", 0)
+		if err != nil {
+			return result, err
+		}
+		statusName, err := core.GetString(args, "status", "")
+		if err != nil {
+			return result, err
+		}
+		status, err := plan.ParseStatus(statusName)
+		if err != nil {
+			return result, err
+		}
+		change = func(p *plan.Plan) (*plan.Plan, error) {
+			if p == nil {
+				return nil, fmt.Errorf("there is no plan yet, create one first")
+			}
+			if err := p.Update(number, status); err != nil {
+				return nil, err
+			}
+			return p, nil
+		}
+		result.AddStep(fmt.Sprintf("Item %d: %s", number, status))
+	case "show":
+		p := plan.Current()
+		if p == nil {
+			result.AddStep("No plan yet")
+			result.Output = "There is no plan yet, create one with the create operation"
+			return result, nil
+		}
+		result.AddStep("Successfully completed show")
+		result.Output = p.String()
+		return result, nil
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+
+	p, err := plan.Apply(change)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = p.String()
+	result.Display = p.Checklist()
+	return result, nil
+}
@@ -0,0 +1,392 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+// maxCellWidth caps the width of a column in table output
+const maxCellWidth = 60
+
+// readOnlyKeywords start statements that never change the database
+var readOnlyKeywords = map[string]bool{
+	"select": true, "with": true, "explain": true, "values": true, "pragma": true,
+}
+
+// skipSpaceAndComments returns the statement without leading whitespace and comments
+func skipSpaceAndComments(statement string) string {
+	for {
+		statement = strings.TrimLeft(statement, " \t\r\n")
+		switch {
+		case strings.HasPrefix(statement, "--"):
+			end := strings.Index(statement, "\n")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+1:]
+		case strings.HasPrefix(statement, "/*"):
+			end := strings.Index(statement, "*/")
+			if end < 0 {
+				return ""
+			}
+			statement = statement[end+2:]
+		default:
+			return statement
+		}
+	}
+}
+
+// isReadOnly reports whether a statement only reads, judging by its first keyword. A
+// PRAGMA that sets a value writes. Read-only connections enforce it either way, this
+// decides how the database is opened and whether the user is asked.
+func isReadOnly(statement string) bool {
+	statement = skipSpaceAndComments(statement)
+	if statement == "" {
+		return true
+	}
+	end := strings.IndexFunc(statement, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	keyword := statement
+	if end >= 0 {
+		keyword = statement[:end]
+	}
+	keyword = strings.ToLower(keyword)
+	if keyword == "pragma" && strings.Contains(statement, "=") {
+		return false
+	}
+	return readOnlyKeywords[keyword]
+}
+
+// hasMultipleStatements reports whether anything but comments follows the first statement
+func hasMultipleStatements(statement string) bool {
+	var quote byte
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '[':
+			quote = ']'
+		case c == '-' && strings.HasPrefix(statement[i:], "--"):
+			end := strings.Index(statement[i:], "\n")
+			if end < 0 {
+				return false
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 3
+		case c == ';':
+			rest := skipSpaceAndComments(statement[i+1:])
+			return strings.Trim(rest, "; \t\r\n") != ""
+		}
+	}
+	return false
+}
+
+// query runs a statement, returning its rows or, for writes, the number of rows changed
+func (t *Tool) query(ctx context.Context, db *sql.DB, args map[string]interface{}, statement string, write bool) (string, error) {
+	if strings.TrimSpace(statement) == "" {
+		return "", fmt.Errorf("sql parameter is required for query operation")
+	}
+	if hasMultipleStatements(statement) {
+		return "", fmt.Errorf("run one statement at a time")
+	}
+	limit, err := core.GetInt(args, "limit", defaultLimit)
+	if err != nil {
+		return "", err
+	}
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+	format, err := core.GetString(args, "format", "table")
+	if err != nil {
+		return "", err
+	}
+	if format != "table" && format != "json" {
+		return "", fmt.Errorf("unknown format: %s, use 'table' or 'json'", format)
+	}
+
+	if write {
+		res, err := db.ExecContext(ctx, statement)
+		if err != nil {
+			return "", fmt.Errorf("statement failed: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "Statement executed", nil
+		}
+		return fmt.Sprintf("Statement executed, %d rows changed", affected), nil
+	}
+
+	columns, rows, more, err := fetch(ctx, db, limit, statement)
+	if err != nil {
+		return "", fmt.Errorf("query failed: %w", err)
+	}
+	if format == "json" {
+		return formatJSON(columns, rows, more, limit)
+	}
+
+	var b strings.Builder
+	if len(columns) > 0 {
+		b.WriteString(formatTable(columns, rows))
+	}
+	switch {
+	case more:
+		b.WriteString(fmt.Sprintf("\n(first %d rows, raise limit or narrow the query to see more)\n", limit))
+	case len(rows) == 1:
+		b.WriteString("\n(1 row)\n")
+	default:
+		b.WriteString(fmt.Sprintf("\n(%d rows)\n", len(rows)))
+	}
+	return b.String(), nil
+}
+
+// fetch runs a query and reads up to limit rows, or all of them for a negative limit,
+// reporting whether there were more
+func fetch(ctx context.Context, db *sql.DB, limit int, statement string, args ...interface{}) ([]string, [][]interface{}, bool, error) {
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	var values [][]interface{}
+	more := false
+	for rows.Next() {
+		if len(values) == limit {
+			more = true
+			break
+		}
+		row := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, false, err
+		}
+		values = append(values, row)
+	}
+	return columns, values, more, rows.Err()
+}
+
+// formatValue formats a value for table output
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return fmt.Sprintf("<blob %d bytes>", len(v))
+	case time.Time:
+		return formatTime(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatTime formats values of DATE, DATETIME and TIMESTAMP columns, which the driver parses
+func formatTime(t time.Time) string {
+	if t.Equal(t.Truncate(24 * time.Hour)) {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatTable aligns rows under their column names
+func formatTable(columns []string, rows [][]interface{}) string {
+	cells := make([][]string, len(rows))
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = min(utf8.RuneCountInString(column), maxCellWidth)
+	}
+	for r, row := range rows {
+		cells[r] = make([]string, len(row))
+		for i, value := range row {
+			// Keep each row on one line
+			cell := strings.Join(strings.Fields(formatValue(value)), " ")
+			if utf8.RuneCountInString(cell) > maxCellWidth {
+				cell = string([]rune(cell)[:maxCellWidth-1]) + "…"
+			}
+			cells[r][i] = cell
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString(" | ")
+			}
+			if i == len(row)-1 {
+				b.WriteString(cell)
+			} else {
+				b.WriteString(cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+			}
+		}
+		b.WriteString("\n")
+	}
+	header := make([]string, len(columns))
+	separator := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column
+		if utf8.RuneCountInString(column) > maxCellWidth {
+			header[i] = string([]rune(column)[:maxCellWidth-1]) + "…"
+		}
+		separator[i] = strings.Repeat("-", widths[i])
+	}
+	writeRow(header)
+	writeRow(separator)
+	for _, row := range cells {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// formatJSON writes rows as an array of objects, keeping the order of the columns
+func formatJSON(columns []string, rows [][]interface{}, more bool, limit int) (string, error) {
+	var b strings.Builder
+	b.WriteString("[")
+	for r, row := range rows {
+		if r > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, value := range row {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			switch v := value.(type) {
+			case []byte:
+				if utf8.Valid(v) {
+					value = string(v)
+				} else {
+					value = fmt.Sprintf("<blob %d bytes>", len(v))
+				}
+			case time.Time:
+				value = formatTime(v)
+			}
+			key, err := json.Marshal(columns[i])
+			if err != nil {
+				return "", err
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(string(key) + ": " + string(encoded))
+		}
+		b.WriteString("}")
+	}
+	if len(rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	if more {
+		b.WriteString(fmt.Sprintf("(first %d rows, raise limit or narrow the query to see more)\n", limit))
+	}
+	return b.String(), nil
+}
+
+// tables lists the tables and views with their row and column counts
+func tables(ctx context.Context, db *sql.DB, name string) (string, error) {
+	_, objects, _, err := fetch(ctx, db, -1, `SELECT type, name FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY type, name`)
+	if err != nil {
+		return "", fmt.Errorf("failed to list tables: %w", err)
+	}
+	if len(objects) == 0 {
+		return fmt.Sprintf("%s has no tables", name), nil
+	}
+
+	var rows [][]interface{}
+	tableCount := 0
+	for _, object := range objects {
+		kind, objectName := formatValue(object[0]), formatValue(object[1])
+		columns := 0
+		if err := db.QueryRowContext(ctx, "SELECT count(*) FROM pragma_table_info(?)", objectName).Scan(&columns); err != nil {
+			return "", fmt.Errorf("failed to describe %s: %w", objectName, err)
+		}
+		var count interface{} = ""
+		if kind == "table" {
+			tableCount++
+			var n int64
+			if err := db.QueryRowContext(ctx, "SELECT count(*) FROM "+quoteIdentifier(objectName)).Scan(&n); err != nil {
+				return "", fmt.Errorf("failed to count the rows of %s: %w", objectName, err)
+			}
+			count = n
+		}
+		rows = append(rows, []interface{}{objectName, kind, count, columns})
+	}
+
+	header := fmt.Sprintf("%s: %d tables, %d views\n\n", name, tableCount, len(objects)-tableCount)
+	return header + formatTable([]string{"name", "type", "rows", "columns"}, rows), nil
+}
+
+// schema shows the CREATE statements of a table, its indexes and triggers, and its
+// columns, or the CREATE statements of the whole database
+func schema(ctx context.Context, db *sql.DB, table string) (string, error) {
+	if table == "" {
+		_, statements, _, err := fetch(ctx, db, -1, `SELECT sql FROM sqlite_master
+			WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+			ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 1 WHEN 'index' THEN 2 ELSE 3 END, tbl_name, name`)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the schema: %w", err)
+		}
+		if len(statements) == 0 {
+			return "The database is empty", nil
+		}
+		var b strings.Builder
+		for _, statement := range statements {
+			b.WriteString(formatValue(statement[0]) + ";\n\n")
+		}
+		return b.String(), nil
+	}
+
+	_, statements, _, err := fetch(ctx, db, -1, `SELECT sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND tbl_name = ? COLLATE NOCASE
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 0 WHEN 'index' THEN 1 ELSE 2 END, name`, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the schema of %s: %w", table, err)
+	}
+	if len(statements) == 0 {
+		return "", fmt.Errorf("no table or view named %s", table)
+	}
+
+	var b strings.Builder
+	for _, statement := range statements {
+		b.WriteString(formatValue(statement[0]) + ";\n\n")
+	}
+	columns, rows, _, err := fetch(ctx, db, -1, `SELECT name, type, "notnull" AS not_null, dflt_value AS "default", pk AS primary_key
+		FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the columns of %s: %w", table, err)
+	}
+	b.WriteString("Columns:\n")
+	b.WriteString(formatTable(columns, rows))
+	return b.String(), nil
+}
@@ -0,0 +1,234 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+
+	// Pure Go driver, so release builds need no C toolchain
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// defaultLimit and maxLimit bound the number of rows returned by a query
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	// queryTimeout bounds a single statement
+	queryTimeout = 30 * time.Second
+)
+
+// Tool queries SQLite databases in the workspace
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+	allowWrites bool
+}
+
+// New creates a new SQLite tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"operation": {
+			Type:        "string",
+			Description: "Operation to perform: 'tables' (tables and views with their row and column counts), 'schema' (CREATE statements and columns of table, or of the whole database), 'query' (run the sql statement and return its rows)",
+			Required:    true,
+		},
+		"database": {
+			Type:        "string",
+			Description: "Path to the SQLite database file",
+			Required:    true,
+		},
+		"table": {
+			Type:        "string",
+			Description: "Table or view to describe (for schema only, default: all)",
+			Required:    false,
+		},
+		"sql": {
+			Type:        "string",
+			Description: "A single SQL statement (for query only). SELECT, WITH, EXPLAIN and PRAGMA are read-only, other statements are rejected unless the user enabled writes",
+			Required:    false,
+		},
+		"limit": {
+			Type:        "integer",
+			Description: fmt.Sprintf("Maximum number of rows to return (for query only, default %d, at most %d)", defaultLimit, maxLimit),
+			Required:    false,
+		},
+		"format": {
+			Type:        "string",
+			Description: "Format of the rows: 'table' (aligned columns, default) or 'json'",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "sqlite",
+		description: "Reads SQLite databases: lists tables, shows schemas and runs SELECT queries with a row limit, returning aligned tables or JSON. Databases are opened read-only, write statements are rejected unless enabled by the user and then need confirmation",
+		parameters:  parameters,
+	}
+}
+
+// SetAllowWrites controls whether statements that change the database may run, after confirmation
+func (t *Tool) SetAllowWrites(enabled bool) {
+	t.allowWrites = enabled
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns false, only write statements are confirmed
+func (t *Tool) RequiresConfirmation() bool {
+	return false
+}
+
+// NeedsConfirmation returns true for write statements when writes are enabled. When
+// they are disabled the statement is rejected, so there is nothing to confirm.
+func (t *Tool) NeedsConfirmation(args map[string]interface{}) bool {
+	operation, _ := core.GetString(args, "operation", "")
+	statement, _ := core.GetString(args, "sql", "")
+	return t.allowWrites && operation == "query" && !isReadOnly(statement)
+}
+
+// Execute runs the SQLite operation
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	operation, err := core.GetString(args, "operation", "")
+	if err != nil {
+		return result, err
+	}
+	if operation == "" {
+		return result, fmt.Errorf("operation parameter is required")
+	}
+	result.ToolMethod = operation
+	result.AddStep(fmt.Sprintf("Requested operation: %s", operation))
+
+	database, err := core.GetString(args, "database", "")
+	if err != nil {
+		return result, err
+	}
+	if database == "" {
+		return result, fmt.Errorf("database parameter is required")
+	}
+
+	table, err := core.GetString(args, "table", "")
+	if err != nil {
+		return result, err
+	}
+	statement, err := core.GetString(args, "sql", "")
+	if err != nil {
+		return result, err
+	}
+	write := operation == "query" && !isReadOnly(statement)
+	if write && !t.allowWrites {
+		result.AddStep("Rejected a write statement")
+		return result, fmt.Errorf("only read-only statements (SELECT, WITH, EXPLAIN, PRAGMA) are allowed, the user can enable writes with 'kiwi config set tools.sqlite.allow_writes true'")
+	}
+
+	access := workspace.Read
+	if write {
+		access = workspace.Write
+	}
+	path, err := workspace.Current().Check(database, access)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Database path check failed: %v", err))
+		return result, err
+	}
+	if info, err := os.Stat(path); err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, fmt.Errorf("failed to access %s: %w", database, err)
+	} else if info.IsDir() {
+		return result, fmt.Errorf("%s is a directory, not a database", database)
+	}
+
+	db, err := open(path, write)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+	defer db.Close()
+	if write {
+		result.AddStep(fmt.Sprintf("Opened %s for writing", path))
+	} else {
+		result.AddStep(fmt.Sprintf("Opened %s read-only", path))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var output string
+	switch operation {
+	case "tables":
+		output, err = tables(ctx, db, filepath.Base(path))
+	case "schema":
+		output, err = schema(ctx, db, table)
+	case "query":
+		output, err = t.query(ctx, db, args, statement, write)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown operation: %s", operation))
+		return result, fmt.Errorf("unknown operation: %s", operation)
+	}
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+
+	output = result.TruncateOutput(output, "select fewer columns or lower the limit")
+	result.AddStep(fmt.Sprintf("Successfully completed %s", operation))
+	result.Output = output
+	return result, nil
+}
+
+// open opens a database file. Read-only connections can't write even through a
+// statement that looks read-only, like a PRAGMA with a value.
+func open(path string, write bool) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(5000)")
+	if write {
+		query.Set("mode", "rw")
+	} else {
+		query.Set("mode", "ro")
+		query.Add("_pragma", "query_only(1)")
+	}
+	dsn := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path), RawQuery: query.Encode()}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// A single connection keeps the pragmas and lets a query see its own writes
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+// quoteIdentifier quotes a table name for use in a statement
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,24 @@
+package sysinfo
+
+import "syscall"
+
+// usage is the space on a filesystem, in bytes
+type usage struct {
+	total     uint64
+	free      uint64
+	available uint64 // free space usable by unprivileged users
+}
+
+// diskUsage returns the space on the filesystem mounted at path
+func diskUsage(path string) (usage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return usage{}, err
+	}
+	size := uint64(stat.Bsize)
+	return usage{
+		total:     stat.Blocks * size,
+		free:      stat.Bfree * size,
+		available: stat.Bavail * size,
+	}, nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package sysinfo
+
+import "fmt"
+
+// usage is the space on a filesystem, in bytes
+type usage struct {
+	total     uint64
+	free      uint64
+	available uint64 // free space usable by unprivileged users
+}
+
+// diskUsage returns the space on the filesystem mounted at path
+func diskUsage(path string) (usage, error) {
+	return usage{}, fmt.Errorf("disk usage is only available on Linux")
+}
@@ -0,0 +1,529 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procRoot is where the proc filesystem is read from, tests point it at fixtures
+var procRoot = "/proc"
+
+// sampleInterval is how long CPU usage is measured over
+var sampleInterval = 500 * time.Millisecond
+
+// maxTopProcesses caps the number of processes listed by the top type
+const maxTopProcesses = 10
+
+// pseudoFilesystems are the mount types that don't hold files on a disk
+var pseudoFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "efivarfs": true,
+	"fusectl": true, "hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true,
+	"pstore": true, "ramfs": true, "rpc_pipefs": true, "securityfs": true, "selinuxfs": true,
+	"squashfs": true, "sysfs": true, "tmpfs": true, "tracefs": true,
+}
+
+// memInfo holds the host memory figures from /proc/meminfo, in bytes
+type memInfo struct {
+	total     uint64
+	available uint64
+	swapTotal uint64
+	swapFree  uint64
+}
+
+// loadAvg holds the load averages and process counts from /proc/loadavg
+type loadAvg struct {
+	one, five, fifteen float64
+	running, total     int
+}
+
+// cpuTimes holds the time a CPU spent idle and in total, in clock ticks
+type cpuTimes struct {
+	name  string
+	idle  uint64
+	total uint64
+}
+
+// procStat holds what /proc/<pid>/stat says about a process
+type procStat struct {
+	pid      int
+	comm     string
+	state    string
+	ppid     int
+	cpuTicks uint64
+	rss      uint64 // bytes
+}
+
+// mount is a mounted filesystem from /proc/mounts
+type mount struct {
+	device string
+	path   string
+	fstype string
+}
+
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{procRoot}, elem...)...)
+}
+
+// readMemInfo parses /proc/meminfo
+func readMemInfo() (memInfo, error) {
+	file, err := os.Open(procPath("meminfo"))
+	if err != nil {
+		return memInfo{}, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return memInfo{}, err
+	}
+
+	if _, ok := values["MemTotal"]; !ok {
+		return memInfo{}, fmt.Errorf("MemTotal missing from %s", procPath("meminfo"))
+	}
+
+	info := memInfo{
+		total:     values["MemTotal"],
+		available: values["MemAvailable"],
+		swapTotal: values["SwapTotal"],
+		swapFree:  values["SwapFree"],
+	}
+	if _, ok := values["MemAvailable"]; !ok {
+		// Kernels before 3.14 don't estimate available memory
+		info.available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	return info, nil
+}
+
+// readLoadAvg parses /proc/loadavg
+func readLoadAvg() (loadAvg, error) {
+	data, err := os.ReadFile(procPath("loadavg"))
+	if err != nil {
+		return loadAvg{}, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 4 {
+		return loadAvg{}, fmt.Errorf("unexpected format of %s", procPath("loadavg"))
+	}
+
+	var load loadAvg
+	for i, target := range []*float64{&load.one, &load.five, &load.fifteen} {
+		if *target, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return loadAvg{}, fmt.Errorf("unexpected format of %s: %w", procPath("loadavg"), err)
+		}
+	}
+	running, total, _ := strings.Cut(fields[3], "/")
+	load.running, _ = strconv.Atoi(running)
+	load.total, _ = strconv.Atoi(total)
+	return load, nil
+}
+
+// readUptime returns how long the host has been running, from /proc/uptime
+func readUptime() (time.Duration, error) {
+	data, err := os.ReadFile(procPath("uptime"))
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected format of %s", procPath("uptime"))
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected format of %s: %w", procPath("uptime"), err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// readCPUTimes parses the cpu lines of /proc/stat, the total of all CPUs first
+func readCPUTimes() ([]cpuTimes, error) {
+	file, err := os.Open(procPath("stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var cpus []cpuTimes
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		times := cpuTimes{name: fields[0]}
+		// user nice system idle iowait irq softirq steal, guest time is already counted in user
+		for i, field := range fields[1:min(len(fields), 9)] {
+			value, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected format of %s: %w", procPath("stat"), err)
+			}
+			times.total += value
+			if i == 3 || i == 4 {
+				times.idle += value
+			}
+		}
+		cpus = append(cpus, times)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cpus) == 0 {
+		return nil, fmt.Errorf("no CPUs found in %s", procPath("stat"))
+	}
+	return cpus, nil
+}
+
+// cpuUsage returns the percentage of time a CPU was busy between two samples
+func cpuUsage(before, after cpuTimes) float64 {
+	total := float64(after.total) - float64(before.total)
+	if total <= 0 {
+		return 0
+	}
+	idle := float64(after.idle) - float64(before.idle)
+	return max(0, min(100, 100*(total-idle)/total))
+}
+
+// readProcStat parses /proc/<pid>/stat
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile(procPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+
+	// The command name is in parentheses and may itself contain spaces and parentheses
+	line := string(data)
+	start, end := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+	if start < 0 || end < start {
+		return procStat{}, fmt.Errorf("unexpected format of %s", procPath(strconv.Itoa(pid), "stat"))
+	}
+	fields := strings.Fields(line[end+1:])
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("unexpected format of %s", procPath(strconv.Itoa(pid), "stat"))
+	}
+
+	stat := procStat{pid: pid, comm: line[start+1 : end], state: fields[0]}
+	stat.ppid, _ = strconv.Atoi(fields[1])
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	stat.cpuTicks = utime + stime
+	rssPages, _ := strconv.ParseUint(fields[21], 10, 64)
+	stat.rss = rssPages * uint64(os.Getpagesize())
+	return stat, nil
+}
+
+// readProcesses reads the stat of every process, skipping those that exit while being read
+func readProcesses() ([]procStat, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []procStat
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		stat, err := readProcStat(pid)
+		if err != nil {
+			continue
+		}
+		processes = append(processes, stat)
+	}
+	return processes, nil
+}
+
+// readMounts returns the mounted disk filesystems from /proc/mounts, one per device
+func readMounts() ([]mount, error) {
+	file, err := os.Open(procPath("mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var mounts []mount
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || pseudoFilesystems[fields[2]] {
+			continue
+		}
+		// Bind mounts show the same device more than once
+		if seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		mounts = append(mounts, mount{device: fields[0], path: unescapeMountPath(fields[1]), fstype: fields[2]})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes /proc/mounts uses for spaces and tabs
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if code, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				result.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		result.WriteByte(path[i])
+	}
+	return result.String()
+}
+
+// cpuSample is a snapshot of the CPU time used by the host and its processes
+type cpuSample struct {
+	cpus      []cpuTimes
+	processes map[int]procStat
+}
+
+func takeCPUSample(withProcesses bool) (cpuSample, error) {
+	cpus, err := readCPUTimes()
+	if err != nil {
+		return cpuSample{}, err
+	}
+	sample := cpuSample{cpus: cpus}
+	if withProcesses {
+		processes, err := readProcesses()
+		if err != nil {
+			return cpuSample{}, err
+		}
+		sample.processes = make(map[int]procStat, len(processes))
+		for _, process := range processes {
+			sample.processes[process.pid] = process
+		}
+	}
+	return sample, nil
+}
+
+// sampleCPU takes two CPU samples sampleInterval apart
+func sampleCPU(ctx context.Context, withProcesses bool) (cpuSample, cpuSample, error) {
+	before, err := takeCPUSample(withProcesses)
+	if err != nil {
+		return cpuSample{}, cpuSample{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return cpuSample{}, cpuSample{}, ctx.Err()
+	case <-time.After(sampleInterval):
+	}
+
+	after, err := takeCPUSample(withProcesses)
+	if err != nil {
+		return cpuSample{}, cpuSample{}, err
+	}
+	return before, after, nil
+}
+
+// getHostMemoryInfo reports host memory and swap usage
+func getHostMemoryInfo() (string, error) {
+	mem, err := readMemInfo()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	used := mem.total - min(mem.available, mem.total)
+	result.WriteString(fmt.Sprintf("total: %s\n", formatBytes(mem.total)))
+	result.WriteString(fmt.Sprintf("available: %s\n", formatBytes(mem.available)))
+	result.WriteString(fmt.Sprintf("used: %s (%s)\n", formatBytes(used), formatPercent(used, mem.total)))
+	if mem.swapTotal == 0 {
+		result.WriteString("swap: none\n")
+	} else {
+		swapUsed := mem.swapTotal - min(mem.swapFree, mem.swapTotal)
+		result.WriteString(fmt.Sprintf("swap_total: %s\n", formatBytes(mem.swapTotal)))
+		result.WriteString(fmt.Sprintf("swap_used: %s (%s)\n", formatBytes(swapUsed), formatPercent(swapUsed, mem.swapTotal)))
+	}
+	return result.String(), nil
+}
+
+// getCPUInfo reports the load average and the usage of each CPU
+func getCPUInfo(ctx context.Context) (string, error) {
+	load, err := readLoadAvg()
+	if err != nil {
+		return "", err
+	}
+	before, after, err := sampleCPU(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("load_average: %.2f %.2f %.2f (1, 5, 15 min)\n", load.one, load.five, load.fifteen))
+	result.WriteString(fmt.Sprintf("processes: %d running, %d total\n", load.running, load.total))
+	result.WriteString(fmt.Sprintf("cpu_usage (over %s):\n", sampleInterval))
+	for i, cpu := range after.cpus {
+		if i >= len(before.cpus) || before.cpus[i].name != cpu.name {
+			continue
+		}
+		name := cpu.name
+		if name == "cpu" {
+			name = "all"
+		}
+		result.WriteString(fmt.Sprintf("  %s: %.1f%%\n", name, cpuUsage(before.cpus[i], cpu)))
+	}
+	return result.String(), nil
+}
+
+// getDiskInfo reports the usage of every mounted disk filesystem
+func getDiskInfo() (string, error) {
+	mounts, err := readMounts()
+	if err != nil {
+		return "", err
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("%-16s %-8s %10s %10s %10s %5s  %s\n", "DEVICE", "TYPE", "SIZE", "USED", "AVAIL", "USE%", "MOUNT"))
+	for _, m := range mounts {
+		usage, err := diskUsage(m.path)
+		if err != nil || usage.total == 0 {
+			continue // Mounts we can't access, like other users' FUSE mounts
+		}
+		used := usage.total - usage.free
+		result.WriteString(fmt.Sprintf("%-16s %-8s %10s %10s %10s %5s  %s\n",
+			m.device, m.fstype, formatBytes(usage.total), formatBytes(used), formatBytes(usage.available),
+			formatPercent(used, used+usage.available), m.path))
+	}
+	return result.String(), nil
+}
+
+// processUsage is the CPU and memory used by a process during a sample
+type processUsage struct {
+	procStat
+	cpu float64 // percent of one CPU
+	mem float64 // percent of host memory
+}
+
+// getTopProcesses reports the processes using the most CPU and memory
+func getTopProcesses(ctx context.Context) (string, error) {
+	mem, err := readMemInfo()
+	if err != nil {
+		return "", err
+	}
+	before, after, err := sampleCPU(ctx, true)
+	if err != nil {
+		return "", err
+	}
+
+	// Process CPU time is compared to the time that passed on one CPU, like top does
+	elapsed := float64(after.cpus[0].total) - float64(before.cpus[0].total)
+	if len(after.cpus) > 1 {
+		elapsed /= float64(len(after.cpus) - 1)
+	}
+
+	var usages []processUsage
+	for pid, process := range after.processes {
+		usage := processUsage{procStat: process}
+		if previous, ok := before.processes[pid]; ok && elapsed > 0 && process.cpuTicks >= previous.cpuTicks {
+			usage.cpu = 100 * float64(process.cpuTicks-previous.cpuTicks) / elapsed
+		}
+		if mem.total > 0 {
+			usage.mem = 100 * float64(process.rss) / float64(mem.total)
+		}
+		usages = append(usages, usage)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Top processes by CPU (over %s):\n", sampleInterval))
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].cpu != usages[j].cpu {
+			return usages[i].cpu > usages[j].cpu
+		}
+		return usages[i].rss > usages[j].rss
+	})
+	writeProcessTable(&result, usages)
+
+	result.WriteString("\nTop processes by memory:\n")
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].rss != usages[j].rss {
+			return usages[i].rss > usages[j].rss
+		}
+		return usages[i].pid < usages[j].pid
+	})
+	writeProcessTable(&result, usages)
+
+	return result.String(), nil
+}
+
+func writeProcessTable(result *strings.Builder, usages []processUsage) {
+	result.WriteString(fmt.Sprintf("%8s %6s %6s %10s %5s  %s\n", "PID", "CPU%", "MEM%", "RSS", "STATE", "NAME"))
+	for _, usage := range usages[:min(len(usages), maxTopProcesses)] {
+		result.WriteString(fmt.Sprintf("%8d %6.1f %6.1f %10s %5s  %s\n",
+			usage.pid, usage.cpu, usage.mem, formatBytes(usage.rss), usage.state, usage.comm))
+	}
+}
+
+// formatBytes formats a size in bytes for display
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatPercent(part, whole uint64) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", 100*float64(part)/float64(whole))
+}
+
+// formatUptime formats an uptime like "3 days, 4h12m"
+func formatUptime(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	days := int(d / (24 * time.Hour))
+	rest := d % (24 * time.Hour)
+	clock := fmt.Sprintf("%dh%02dm", int(rest/time.Hour), int(rest%time.Hour/time.Minute))
+	switch days {
+	case 0:
+		return clock
+	case 1:
+		return "1 day, " + clock
+	default:
+		return fmt.Sprintf("%d days, %s", days, clock)
+	}
+}
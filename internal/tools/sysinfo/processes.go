@@ -0,0 +1,479 @@
+package sysinfo
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+const (
+	// maxListedProcesses caps the number of processes listed by the processes type
+	maxListedProcesses = 200
+
+	// maxCommandLine caps the length of a command line in process listings
+	maxCommandLine = 200
+
+	// kernelThreadParent is the PID of kthreadd, the parent of all kernel threads
+	kernelThreadParent = 2
+)
+
+// tcpStates maps the socket states of /proc/net/tcp to their names
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED", "02": "SYN_SENT", "03": "SYN_RECV", "04": "FIN_WAIT1", "05": "FIN_WAIT2",
+	"06": "TIME_WAIT", "07": "CLOSE", "08": "CLOSE_WAIT", "09": "LAST_ACK", "0A": "LISTEN", "0B": "CLOSING",
+}
+
+// process is a process with everything the process listings show about it
+type process struct {
+	procStat
+	uid     string
+	command string
+}
+
+// socket is an entry of /proc/net/tcp, tcp6, udp or udp6
+type socket struct {
+	proto      string
+	localIP    net.IP
+	localPort  int
+	remoteIP   net.IP
+	remotePort int
+	state      string
+	uid        string
+	inode      string
+}
+
+// readProcessDetails reads the stat, owner and command line of every process
+func readProcessDetails() ([]process, error) {
+	stats, err := readProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make([]process, 0, len(stats))
+	for _, stat := range stats {
+		p := process{procStat: stat, uid: readProcUID(stat.pid), command: readCommandLine(stat.pid)}
+		if p.command == "" {
+			// Kernel threads and zombies have no command line
+			p.command = "[" + stat.comm + "]"
+		}
+		processes = append(processes, p)
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].pid < processes[j].pid })
+	return processes, nil
+}
+
+// readCommandLine returns the command line of a process with its arguments separated by spaces
+func readCommandLine(pid int) string {
+	data, err := os.ReadFile(procPath(strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+}
+
+// readProcUID returns the real user ID of a process from /proc/<pid>/status
+func readProcUID(pid int) string {
+	file, err := os.Open(procPath(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "Uid:"); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				return fields[0]
+			}
+		}
+	}
+	return ""
+}
+
+// readSockets parses the sockets of one of the /proc/net tables
+func readSockets(proto string) ([]socket, error) {
+	file, err := os.Open(procPath("net", proto))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []socket
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // Skip the header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localIP, localPort, err := parseSocketAddress(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected format of %s: %w", procPath("net", proto), err)
+		}
+		remoteIP, remotePort, err := parseSocketAddress(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected format of %s: %w", procPath("net", proto), err)
+		}
+		sockets = append(sockets, socket{
+			proto:      proto,
+			localIP:    localIP,
+			localPort:  localPort,
+			remoteIP:   remoteIP,
+			remotePort: remotePort,
+			state:      fields[3],
+			uid:        fields[7],
+			inode:      fields[9],
+		})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseSocketAddress decodes an address like 0100007F:1F90. The kernel prints the
+// address as 32-bit words in host byte order, which is little endian on every
+// architecture kiwi is built for.
+func parseSocketAddress(s string) (net.IP, int, error) {
+	hexIP, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	raw, err := hex.DecodeString(hexIP)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+
+	ip := make(net.IP, len(raw))
+	for word := 0; word < len(raw); word += 4 {
+		for i := 0; i < 4; i++ {
+			ip[word+i] = raw[word+3-i]
+		}
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return ip, int(port), nil
+}
+
+// socketOwners maps socket inodes to the processes that have them open. Only the
+// open files of our own processes are readable unless kiwi runs as root.
+func socketOwners(processes []process) map[string]int {
+	owners := make(map[string]int)
+	for _, p := range processes {
+		dir := procPath(strconv.Itoa(p.pid), "fd")
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			target, err := os.Readlink(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			if inode, ok := strings.CutPrefix(target, "socket:["); ok {
+				owners[strings.TrimSuffix(inode, "]")] = p.pid
+			}
+		}
+	}
+	return owners
+}
+
+// readAllSockets reads the TCP and UDP sockets of both IP versions
+func readAllSockets() ([]socket, error) {
+	var all []socket
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		sockets, err := readSockets(proto)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // IPv6 may be disabled
+			}
+			return nil, err
+		}
+		all = append(all, sockets...)
+	}
+	if all == nil {
+		if _, err := os.Stat(procPath("net")); err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+// listening reports whether a socket accepts connections or datagrams from anyone
+func (s socket) listening() bool {
+	if strings.HasPrefix(s.proto, "tcp") {
+		return s.state == "0A"
+	}
+	return s.remotePort == 0
+}
+
+// stateName returns the name of the socket state
+func (s socket) stateName() string {
+	if strings.HasPrefix(s.proto, "udp") {
+		if s.remotePort == 0 {
+			return "UNCONN"
+		}
+		return "CONNECTED"
+	}
+	if name, ok := tcpStates[s.state]; ok {
+		return name
+	}
+	return s.state
+}
+
+// getProcessList lists processes with their command lines, optionally only those
+// whose name or command line contains filter
+func getProcessList(filter string) (string, error) {
+	processes, err := readProcessDetails()
+	if err != nil {
+		return "", err
+	}
+
+	names := newUserNames()
+	needle := strings.ToLower(filter)
+	var result strings.Builder
+	var zombies []process
+	listed, matched := 0, 0
+	result.WriteString(fmt.Sprintf("%8s %8s %-12s %5s %10s  %s\n", "PID", "PPID", "USER", "STATE", "RSS", "COMMAND"))
+	for _, p := range processes {
+		if needle != "" && !strings.Contains(strings.ToLower(p.comm), needle) && !strings.Contains(strings.ToLower(p.command), needle) {
+			continue
+		}
+		matched++
+		if p.state == "Z" {
+			zombies = append(zombies, p)
+		}
+		if listed >= maxListedProcesses {
+			continue
+		}
+		listed++
+		result.WriteString(fmt.Sprintf("%8d %8d %-12s %5s %10s  %s\n",
+			p.pid, p.ppid, names.lookup(p.uid), p.state, formatBytes(p.rss), truncateCommand(p.command)))
+	}
+
+	if matched == 0 {
+		return fmt.Sprintf("No processes match %q\n", filter), nil
+	}
+	if matched > listed {
+		result.WriteString(fmt.Sprintf("\nShowing %d of %d processes, use filter to narrow the list\n", listed, matched))
+	}
+	for _, z := range zombies {
+		result.WriteString(fmt.Sprintf("\nZombie: %d (%s) has exited but was not reaped by its parent %d", z.pid, z.comm, z.ppid))
+	}
+	if len(zombies) > 0 {
+		result.WriteString("\n")
+	}
+	return result.String(), nil
+}
+
+// getProcessTree renders the parent/child tree of all processes, or the ancestors
+// and descendants of one process when pid is not zero
+func getProcessTree(root int) (string, error) {
+	processes, err := readProcessDetails()
+	if err != nil {
+		return "", err
+	}
+
+	byPID := make(map[int]process, len(processes))
+	children := make(map[int][]int)
+	for _, p := range processes {
+		byPID[p.pid] = p
+	}
+	for _, p := range processes {
+		// Processes whose parent is gone or invisible are shown as roots
+		if _, ok := byPID[p.ppid]; ok && p.ppid != p.pid {
+			children[p.ppid] = append(children[p.ppid], p.pid)
+		}
+	}
+
+	names := newUserNames()
+	var result strings.Builder
+	var walk func(pid int, prefix, branch string)
+	walk = func(pid int, prefix, branch string) {
+		p := byPID[pid]
+		line := fmt.Sprintf("%d %s [%s]", p.pid, truncateCommand(p.command), names.lookup(p.uid))
+		if p.state == "Z" {
+			line += " <zombie>"
+		}
+		if pid == kernelThreadParent && p.comm == "kthreadd" && len(children[pid]) > 0 && pid != root {
+			// Kernel threads say nothing about user space and would fill the whole tree
+			result.WriteString(fmt.Sprintf("%s%s%s (%d kernel threads hidden)\n", prefix, branch, line, len(children[pid])))
+			return
+		}
+		result.WriteString(prefix + branch + line + "\n")
+
+		childPrefix := prefix
+		switch branch {
+		case "├─ ":
+			childPrefix += "│  "
+		case "└─ ":
+			childPrefix += "   "
+		}
+		kids := children[pid]
+		for i, child := range kids {
+			if i == len(kids)-1 {
+				walk(child, childPrefix, "└─ ")
+			} else {
+				walk(child, childPrefix, "├─ ")
+			}
+		}
+	}
+
+	if root == 0 {
+		for _, p := range processes {
+			if _, ok := byPID[p.ppid]; !ok || p.ppid == p.pid {
+				walk(p.pid, "", "")
+			}
+		}
+		return result.String(), nil
+	}
+
+	target, ok := byPID[root]
+	if !ok {
+		return "", fmt.Errorf("no process with PID %d", root)
+	}
+
+	// List the ancestors from the top down, then the process and its descendants
+	var ancestors []process
+	seen := map[int]bool{target.pid: true}
+	for parent, ok := byPID[target.ppid]; ok && !seen[parent.pid]; parent, ok = byPID[parent.ppid] {
+		seen[parent.pid] = true
+		ancestors = append([]process{parent}, ancestors...)
+	}
+	if len(ancestors) > 0 {
+		result.WriteString("Parents:\n")
+		for _, a := range ancestors {
+			result.WriteString(fmt.Sprintf("  %d %s [%s]\n", a.pid, truncateCommand(a.command), names.lookup(a.uid)))
+		}
+		result.WriteString("\n")
+	}
+	result.WriteString("Process and children:\n")
+	walk(root, "", "")
+	return result.String(), nil
+}
+
+// getPorts lists the listening TCP and bound UDP sockets with the processes that own them,
+// optionally only those on one port
+func getPorts(port int) (string, error) {
+	return getSockets(port, true)
+}
+
+// getConnections lists the TCP connections and connected UDP sockets with the processes
+// that own them, optionally only those using one port on either end
+func getConnections(port int) (string, error) {
+	return getSockets(port, false)
+}
+
+func getSockets(port int, listening bool) (string, error) {
+	sockets, err := readAllSockets()
+	if err != nil {
+		return "", err
+	}
+	processes, err := readProcessDetails()
+	if err != nil {
+		return "", err
+	}
+	owners := socketOwners(processes)
+	byPID := make(map[int]process, len(processes))
+	for _, p := range processes {
+		byPID[p.pid] = p
+	}
+
+	var selected []socket
+	for _, s := range sockets {
+		if s.listening() != listening {
+			continue
+		}
+		if port != 0 && s.localPort != port && (listening || s.remotePort != port) {
+			continue
+		}
+		selected = append(selected, s)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].localPort != selected[j].localPort {
+			return selected[i].localPort < selected[j].localPort
+		}
+		return selected[i].proto < selected[j].proto
+	})
+
+	kind := "connections"
+	if listening {
+		kind = "listening ports"
+	}
+	if len(selected) == 0 {
+		if port != 0 {
+			return fmt.Sprintf("No %s on port %d\n", kind, port), nil
+		}
+		return fmt.Sprintf("No %s\n", kind), nil
+	}
+
+	names := newUserNames()
+	var result strings.Builder
+	unknown := 0
+	if listening {
+		result.WriteString(fmt.Sprintf("%-5s %-28s %-12s %8s  %s\n", "PROTO", "ADDRESS", "USER", "PID", "PROCESS"))
+	} else {
+		result.WriteString(fmt.Sprintf("%-5s %-28s %-28s %-12s %-12s %8s  %s\n", "PROTO", "LOCAL", "REMOTE", "STATE", "USER", "PID", "PROCESS"))
+	}
+	for _, s := range selected {
+		pidText, name := "-", "-"
+		if pid, ok := owners[s.inode]; ok && s.inode != "0" {
+			pidText, name = strconv.Itoa(pid), truncateCommand(byPID[pid].command)
+		} else if s.state != "06" {
+			unknown++ // Sockets in TIME_WAIT belong to no process anymore
+		}
+
+		local := net.JoinHostPort(s.localIP.String(), strconv.Itoa(s.localPort))
+		if listening {
+			result.WriteString(fmt.Sprintf("%-5s %-28s %-12s %8s  %s\n", s.proto, local, names.lookup(s.uid), pidText, name))
+		} else {
+			remote := net.JoinHostPort(s.remoteIP.String(), strconv.Itoa(s.remotePort))
+			result.WriteString(fmt.Sprintf("%-5s %-28s %-28s %-12s %-12s %8s  %s\n",
+				s.proto, local, remote, s.stateName(), names.lookup(s.uid), pidText, name))
+		}
+	}
+	if unknown > 0 && os.Geteuid() != 0 {
+		result.WriteString(fmt.Sprintf("\nThe owner of %d sockets isn't visible, only root can see the open files of other users' processes\n", unknown))
+	}
+	return result.String(), nil
+}
+
+// userNames resolves user IDs to names, remembering the answers
+type userNames map[string]string
+
+func newUserNames() userNames {
+	return make(userNames)
+}
+
+func (u userNames) lookup(uid string) string {
+	if uid == "" {
+		return "?"
+	}
+	if name, ok := u[uid]; ok {
+		return name
+	}
+	name := uid
+	if found, err := user.LookupId(uid); err == nil {
+		name = found.Username
+	}
+	u[uid] = name
+	return name
+}
+
+func truncateCommand(command string) string {
+	if len(command) <= maxCommandLine {
+		return command
+	}
+	return core.Truncate(command, maxCommandLine) + "..."
+}
@@ -0,0 +1,609 @@
+package sysinfo
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/git"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxProjectFiles caps how many files are looked at to count languages
+	maxProjectFiles = 20000
+
+	// maxProjectDepth caps how deep the language count looks into the project
+	maxProjectDepth = 6
+
+	// gitTimeout bounds the git commands used to report the repository state
+	gitTimeout = 5 * time.Second
+)
+
+// skippedDirs are directories of dependencies, build output and tool state that
+// don't say anything about the languages of the project itself
+var skippedDirs = map[string]bool{
+	".git": true, ".hg": true, ".svn": true, ".idea": true, ".vscode": true,
+	"node_modules": true, "vendor": true, "dist": true, "build": true, "target": true, "out": true,
+	".venv": true, "venv": true, "__pycache__": true, ".tox": true, ".mypy_cache": true, ".pytest_cache": true,
+	".next": true, ".gradle": true, "bin": true, "obj": true,
+}
+
+// languages maps file extensions to the language they are written in
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript", ".jsx": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".rs": "Rust", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin",
+	".scala": "Scala", ".rb": "Ruby", ".php": "PHP", ".cs": "C#", ".fs": "F#", ".swift": "Swift", ".m": "Objective-C",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++", ".zig": "Zig",
+	".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang", ".hs": "Haskell", ".ml": "OCaml", ".clj": "Clojure",
+	".dart": "Dart", ".lua": "Lua", ".r": "R", ".jl": "Julia", ".pl": "Perl", ".sh": "Shell", ".bash": "Shell",
+	".sql": "SQL", ".vue": "Vue", ".svelte": "Svelte", ".html": "HTML", ".css": "CSS", ".scss": "CSS",
+	".tf": "Terraform", ".proto": "Protocol Buffers",
+}
+
+// ciFiles are the configuration files of CI services, directories list every file inside them
+var ciFiles = []struct {
+	path    string
+	service string
+}{
+	{".github/workflows", "GitHub Actions"},
+	{".gitlab-ci.yml", "GitLab CI"},
+	{".circleci/config.yml", "CircleCI"},
+	{"Jenkinsfile", "Jenkins"},
+	{".travis.yml", "Travis CI"},
+	{"azure-pipelines.yml", "Azure Pipelines"},
+	{"bitbucket-pipelines.yml", "Bitbucket Pipelines"},
+	{".buildkite", "Buildkite"},
+	{".drone.yml", "Drone"},
+	{".woodpecker.yml", "Woodpecker"},
+}
+
+// makeTarget matches a Makefile rule, excluding variable assignments and special targets
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=]|$)`)
+
+// project is what was found out about a project directory
+type project struct {
+	dir        string
+	languages  map[string]int
+	truncated  bool
+	buildFiles []buildFile
+	commands   []projectCommand
+	git        string
+	ci         []string
+}
+
+// buildFile is a build or dependency file with a short description of what it says
+type buildFile struct {
+	name   string
+	detail string
+}
+
+// projectCommand is a command used to work on the project, and the file it comes from
+type projectCommand struct {
+	kind    string // install, build, test, lint or run
+	command string
+	source  string
+}
+
+// getProjectInfo inspects a directory and reports the languages, build files,
+// commands, git state and CI configuration of the project in it
+func getProjectInfo(ctx context.Context, path string) (string, error) {
+	dir, err := workspace.Current().Check(path, workspace.Read)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	p := &project{dir: dir, languages: make(map[string]int)}
+	p.countLanguages()
+	p.detectBuildFiles()
+	p.detectCI()
+	p.git = gitState(ctx, dir)
+
+	return p.format(), nil
+}
+
+// countLanguages counts the source files of each language, skipping dependencies and build output
+func (p *project) countLanguages() {
+	policy := workspace.Current()
+	files := 0
+	filepath.WalkDir(p.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what can't be read
+		}
+		if path != p.dir && policy.IsDenied(path) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			rel, _ := filepath.Rel(p.dir, path)
+			if path != p.dir && (skippedDirs[entry.Name()] || strings.Count(rel, string(filepath.Separator)) >= maxProjectDepth) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		files++
+		if files > maxProjectFiles {
+			p.truncated = true
+			return filepath.SkipAll
+		}
+		if language, ok := languages[strings.ToLower(filepath.Ext(entry.Name()))]; ok {
+			p.languages[language]++
+		}
+		return nil
+	})
+}
+
+// detectBuildFiles looks for the build and dependency files of common ecosystems
+// and the commands they imply
+func (p *project) detectBuildFiles() {
+	if data, ok := p.read("go.mod"); ok {
+		detail := "Go module"
+		if module := goModDirective(data, "module"); module != "" {
+			detail += " " + module
+		}
+		if version := goModDirective(data, "go"); version != "" {
+			detail += fmt.Sprintf(" (go %s)", version)
+		}
+		p.addBuildFile("go.mod", detail)
+		p.addCommand("build", "go build ./...", "go.mod")
+		p.addCommand("test", "go test ./...", "go.mod")
+		p.addCommand("lint", "go vet ./...", "go.mod")
+		if p.exists(".golangci.yml") || p.exists(".golangci.yaml") {
+			p.addCommand("lint", "golangci-lint run", ".golangci.yml")
+		}
+	}
+
+	if data, ok := p.read("package.json"); ok {
+		p.detectPackageJSON(data)
+	}
+
+	if data, ok := p.readAny("Makefile", "makefile", "GNUmakefile"); ok {
+		targets := makeTargets(data)
+		p.addBuildFile("Makefile", describeList("targets", targets))
+		for _, target := range targets {
+			switch target {
+			case "install", "build", "test", "lint", "run":
+				p.addCommand(target, "make "+target, "Makefile")
+			case "check":
+				p.addCommand("test", "make check", "Makefile")
+			}
+		}
+	}
+
+	p.detectPython()
+
+	if data, ok := p.read("Cargo.toml"); ok {
+		detail := "Rust crate"
+		if strings.Contains(data, "[workspace]") {
+			detail = "Rust workspace"
+		}
+		p.addBuildFile("Cargo.toml", detail)
+		p.addCommand("build", "cargo build", "Cargo.toml")
+		p.addCommand("test", "cargo test", "Cargo.toml")
+		p.addCommand("lint", "cargo clippy", "Cargo.toml")
+	}
+
+	if p.exists("CMakeLists.txt") {
+		p.addBuildFile("CMakeLists.txt", "CMake project")
+		p.addCommand("build", "cmake -B build && cmake --build build", "CMakeLists.txt")
+		p.addCommand("test", "ctest --test-dir build", "CMakeLists.txt")
+	}
+
+	if p.exists("pom.xml") {
+		p.addBuildFile("pom.xml", "Maven project")
+		p.addCommand("build", "mvn package", "pom.xml")
+		p.addCommand("test", "mvn test", "pom.xml")
+	}
+
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		if p.exists(name) {
+			gradle := "gradle"
+			if p.exists("gradlew") {
+				gradle = "./gradlew"
+			}
+			p.addBuildFile(name, "Gradle project")
+			p.addCommand("build", gradle+" build", name)
+			p.addCommand("test", gradle+" test", name)
+			break
+		}
+	}
+
+	if p.exists("Gemfile") {
+		p.addBuildFile("Gemfile", "Ruby dependencies (Bundler)")
+		p.addCommand("install", "bundle install", "Gemfile")
+		if p.exists("Rakefile") {
+			p.addCommand("test", "bundle exec rake test", "Rakefile")
+		} else if p.exists("spec") {
+			p.addCommand("test", "bundle exec rspec", "Gemfile")
+		}
+	}
+
+	if p.exists("composer.json") {
+		p.addBuildFile("composer.json", "PHP dependencies (Composer)")
+		p.addCommand("install", "composer install", "composer.json")
+	}
+
+	if p.exists("Dockerfile") {
+		p.addBuildFile("Dockerfile", "container image")
+		p.addCommand("build", "docker build .", "Dockerfile")
+	}
+	if name, ok := p.firstExisting("compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"); ok {
+		p.addBuildFile(name, "Docker Compose services")
+		p.addCommand("run", "docker compose up", name)
+	}
+}
+
+// detectPackageJSON reads the package manager and scripts of a Node.js project
+func (p *project) detectPackageJSON(data string) {
+	var pkg struct {
+		Name           string            `json:"name"`
+		Scripts        map[string]string `json:"scripts"`
+		PackageManager string            `json:"packageManager"`
+	}
+	if err := json.Unmarshal([]byte(data), &pkg); err != nil {
+		p.addBuildFile("package.json", fmt.Sprintf("invalid JSON: %v", err))
+		return
+	}
+
+	manager := "npm"
+	switch {
+	case strings.HasPrefix(pkg.PackageManager, "pnpm"), p.exists("pnpm-lock.yaml"):
+		manager = "pnpm"
+	case strings.HasPrefix(pkg.PackageManager, "yarn"), p.exists("yarn.lock"):
+		manager = "yarn"
+	case strings.HasPrefix(pkg.PackageManager, "bun"), p.exists("bun.lockb"), p.exists("bun.lock"):
+		manager = "bun"
+	}
+
+	scripts := make([]string, 0, len(pkg.Scripts))
+	for script := range pkg.Scripts {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+
+	detail := "Node.js package"
+	if pkg.Name != "" {
+		detail += " " + pkg.Name
+	}
+	detail += fmt.Sprintf(" (%s), %s", manager, describeList("scripts", scripts))
+	p.addBuildFile("package.json", detail)
+
+	p.addCommand("install", manager+" install", "package.json")
+	for _, script := range scripts {
+		kind := script
+		switch script {
+		case "start", "dev":
+			kind = "run"
+		case "build", "test", "lint":
+		default:
+			continue
+		}
+		command := manager + " run " + script
+		if script == "test" || script == "start" {
+			command = manager + " " + script
+		}
+		p.addCommand(kind, command, "package.json")
+	}
+}
+
+// detectPython reads the build backend and test runner of a Python project
+func (p *project) detectPython() {
+	pyproject, hasPyproject := p.read("pyproject.toml")
+	runner := ""
+	switch {
+	case p.exists("uv.lock"):
+		runner = "uv run "
+	case strings.Contains(pyproject, "[tool.poetry"):
+		runner = "poetry run "
+	case p.exists("Pipfile"):
+		runner = "pipenv run "
+	}
+
+	if hasPyproject {
+		detail := "Python project"
+		switch {
+		case runner == "uv run ":
+			detail += " (uv)"
+		case runner == "poetry run ":
+			detail += " (Poetry)"
+		case strings.Contains(pyproject, "hatchling"):
+			detail += " (Hatch)"
+		case strings.Contains(pyproject, "setuptools"):
+			detail += " (setuptools)"
+		}
+		p.addBuildFile("pyproject.toml", detail)
+		switch runner {
+		case "uv run ":
+			p.addCommand("install", "uv sync", "uv.lock")
+		case "poetry run ":
+			p.addCommand("install", "poetry install", "pyproject.toml")
+		default:
+			p.addCommand("install", "pip install -e .", "pyproject.toml")
+		}
+	}
+	if p.exists("setup.py") {
+		p.addBuildFile("setup.py", "Python package (setuptools)")
+		if !hasPyproject {
+			p.addCommand("install", "pip install -e .", "setup.py")
+		}
+	}
+	if p.exists("requirements.txt") {
+		p.addBuildFile("requirements.txt", "Python dependencies")
+		p.addCommand("install", "pip install -r requirements.txt", "requirements.txt")
+	}
+	if p.exists("Pipfile") {
+		p.addBuildFile("Pipfile", "Python dependencies (Pipenv)")
+		p.addCommand("install", "pipenv install", "Pipfile")
+	}
+
+	if !hasPyproject && !p.exists("setup.py") && !p.exists("requirements.txt") && !p.exists("Pipfile") {
+		return
+	}
+	switch {
+	case p.exists("tox.ini"):
+		p.addBuildFile("tox.ini", "tox environments")
+		p.addCommand("test", "tox", "tox.ini")
+	case p.exists("pytest.ini"), p.exists("conftest.py"), strings.Contains(pyproject, "[tool.pytest"):
+		p.addCommand("test", runner+"pytest", "pytest configuration")
+	case p.exists("tests"), p.exists("test"):
+		p.addCommand("test", runner+"python -m pytest", "tests directory")
+	}
+	if strings.Contains(pyproject, "[tool.ruff") || p.exists("ruff.toml") {
+		p.addCommand("lint", runner+"ruff check .", "ruff configuration")
+	}
+}
+
+// detectCI lists the CI configuration files of the project
+func (p *project) detectCI() {
+	for _, ci := range ciFiles {
+		path := filepath.Join(p.dir, filepath.FromSlash(ci.path))
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			p.ci = append(p.ci, fmt.Sprintf("%s (%s)", ci.path, ci.service))
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				p.ci = append(p.ci, fmt.Sprintf("%s/%s (%s)", ci.path, entry.Name(), ci.service))
+			}
+		}
+	}
+}
+
+// gitState reports the branch of the repository the directory is in and whether it has changes
+func gitState(ctx context.Context, dir string) string {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+
+	// Through the git tool, so the repository config can't make git run its own programs
+	output, err := git.Run(ctx, dir, "status", "--porcelain=v1", "--branch")
+	if err != nil {
+		if _, lookErr := exec.LookPath("git"); lookErr != nil {
+			return "git is not installed"
+		}
+		return "not a git repository"
+	}
+
+	var branch string
+	staged, modified, untracked := 0, 0, 0
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "## "); ok {
+			branch = header
+			continue
+		}
+		if len(line) < 2 {
+			continue
+		}
+		if strings.HasPrefix(line, "??") {
+			untracked++
+			continue
+		}
+		if line[0] != ' ' {
+			staged++
+		}
+		if line[1] != ' ' {
+			modified++
+		}
+	}
+
+	// "main...origin/main [ahead 1]" or "No commits yet on main"
+	branch = strings.TrimPrefix(branch, "No commits yet on ")
+	name, tracking, _ := strings.Cut(branch, "...")
+	state := "branch " + name
+	if strings.HasPrefix(name, "HEAD (no branch)") {
+		state = "detached HEAD"
+	}
+	if start := strings.Index(tracking, "["); start >= 0 {
+		state += ", " + strings.Trim(tracking[start:], "[]") + " of " + strings.TrimSpace(tracking[:start])
+	}
+
+	if staged+modified+untracked == 0 {
+		return state + ", clean"
+	}
+	var changes []string
+	if staged > 0 {
+		changes = append(changes, fmt.Sprintf("%d staged", staged))
+	}
+	if modified > 0 {
+		changes = append(changes, fmt.Sprintf("%d modified", modified))
+	}
+	if untracked > 0 {
+		changes = append(changes, fmt.Sprintf("%d untracked", untracked))
+	}
+	return state + ", dirty (" + strings.Join(changes, ", ") + ")"
+}
+
+// format renders the project report
+func (p *project) format() string {
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Project: %s\n", p.dir))
+
+	type languageCount struct {
+		name  string
+		count int
+	}
+	var counts []languageCount
+	for name, count := range p.languages {
+		counts = append(counts, languageCount{name, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].name < counts[j].name
+	})
+	if len(counts) == 0 {
+		result.WriteString("\nLanguages: none detected\n")
+	} else {
+		parts := make([]string, len(counts))
+		for i, c := range counts {
+			if c.count == 1 {
+				parts[i] = c.name + " (1 file)"
+			} else {
+				parts[i] = fmt.Sprintf("%s (%d files)", c.name, c.count)
+			}
+		}
+		result.WriteString("\nLanguages: " + strings.Join(parts, ", "))
+		if p.truncated {
+			result.WriteString(fmt.Sprintf(" (counted the first %d files)", maxProjectFiles))
+		}
+		result.WriteString("\n")
+	}
+
+	if len(p.buildFiles) == 0 {
+		result.WriteString("\nBuild files: none found\n")
+	} else {
+		result.WriteString("\nBuild files:\n")
+		for _, file := range p.buildFiles {
+			result.WriteString(fmt.Sprintf("  %s: %s\n", file.name, file.detail))
+		}
+	}
+
+	if len(p.commands) > 0 {
+		result.WriteString("\nCommands:\n")
+		for _, kind := range []string{"install", "build", "test", "lint", "run"} {
+			for _, command := range p.commands {
+				if command.kind == kind {
+					result.WriteString(fmt.Sprintf("  %s: %s (from %s)\n", kind, command.command, command.source))
+				}
+			}
+		}
+	}
+
+	result.WriteString(fmt.Sprintf("\nGit: %s\n", p.git))
+
+	if len(p.ci) == 0 {
+		result.WriteString("\nCI: none found\n")
+	} else {
+		result.WriteString("\nCI:\n")
+		for _, ci := range p.ci {
+			result.WriteString("  " + ci + "\n")
+		}
+	}
+	return result.String()
+}
+
+func (p *project) addBuildFile(name, detail string) {
+	p.buildFiles = append(p.buildFiles, buildFile{name: name, detail: detail})
+}
+
+func (p *project) addCommand(kind, command, source string) {
+	for _, existing := range p.commands {
+		if existing.kind == kind && existing.command == command {
+			return
+		}
+	}
+	p.commands = append(p.commands, projectCommand{kind: kind, command: command, source: source})
+}
+
+func (p *project) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(p.dir, name))
+	return err == nil
+}
+
+func (p *project) firstExisting(names ...string) (string, bool) {
+	for _, name := range names {
+		if p.exists(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (p *project) read(name string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func (p *project) readAny(names ...string) (string, bool) {
+	for _, name := range names {
+		if data, ok := p.read(name); ok {
+			return data, true
+		}
+	}
+	return "", false
+}
+
+// goModDirective returns the argument of a single-line go.mod directive
+func goModDirective(data, directive string) string {
+	for _, line := range strings.Split(data, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), directive+" "); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// makeTargets returns the rule names of a Makefile in the order they appear
+func makeTargets(data string) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(data, "\n") {
+		match := makeTarget.FindStringSubmatch(line)
+		if match == nil || seen[match[1]] || strings.ContainsAny(match[1], "%") {
+			continue
+		}
+		seen[match[1]] = true
+		targets = append(targets, match[1])
+	}
+	return targets
+}
+
+// describeList renders a short list like "scripts: build, test"
+func describeList(name string, items []string) string {
+	if len(items) == 0 {
+		return "no " + name
+	}
+	return name + ": " + strings.Join(items, ", ")
+}
@@ -23,14 +23,34 @@ func New() *Tool {
 	parameters := map[string]core.Parameter{
 		"type": {
 			Type:        "string",
-			Description: "Type of information to retrieve (basic, memory, env)",
+			Description: "Type of information to retrieve (basic, memory, cpu, disk, top, processes, tree, ports, connections, env, project). memory, cpu, disk, top, processes, tree, ports and connections report on the whole host and are only available on Linux. processes lists processes with their command lines, tree shows parent/child relationships, ports lists listening ports with their owning processes and connections lists established connections. project reports the languages, build files, build and test commands, git state and CI configuration of a project",
 			Required:    true,
 		},
+		"filter": {
+			Type:        "string",
+			Description: "Only list processes whose name or command line contains this text, for the processes type",
+			Required:    false,
+		},
+		"pid": {
+			Type:        "integer",
+			Description: "Show only the parents and children of this process, for the tree type",
+			Required:    false,
+		},
+		"port": {
+			Type:        "integer",
+			Description: "Only list sockets using this port, for the ports and connections types",
+			Required:    false,
+		},
+		"path": {
+			Type:        "string",
+			Description: "Project directory for the project type (default: current directory)",
+			Required:    false,
+		},
 	}
 
 	return &Tool{
 		name:        "sysinfo",
-		description: "Provides system information and status",
+		description: "Provides system information and status: host memory and swap, load average and CPU usage, disk usage per mount, the processes using the most CPU and memory, processes with their command lines and parent/child tree, listening ports and connections with the processes that own them, and how to build and test the project in a directory",
 		parameters:  parameters,
 	}
 }
@@ -85,6 +105,83 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 		} else {
 			result.AddStep("Successfully retrieved memory usage information")
 		}
+	case "cpu":
+		result.AddStep("Measuring CPU usage...")
+		output, err = getCPUInfo(ctx)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error getting CPU info: %v", err))
+		} else {
+			result.AddStep("Successfully retrieved load average and CPU usage")
+		}
+	case "disk":
+		result.AddStep("Gathering disk usage per mount...")
+		output, err = getDiskInfo()
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error getting disk info: %v", err))
+		} else {
+			mountCount := strings.Count(output, "\n") - 1
+			result.AddStep(fmt.Sprintf("Successfully retrieved usage of %d mounts", mountCount))
+		}
+	case "top":
+		result.AddStep("Measuring CPU and memory usage of processes...")
+		output, err = getTopProcesses(ctx)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error getting process usage: %v", err))
+		} else {
+			result.AddStep("Successfully retrieved the top processes by CPU and memory")
+		}
+	case "processes":
+		filter, filterErr := core.GetString(args, "filter", "")
+		if filterErr != nil {
+			return result, filterErr
+		}
+		result.AddStep("Listing processes...")
+		output, err = getProcessList(filter)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error listing processes: %v", err))
+		} else {
+			result.AddStep("Successfully listed processes")
+		}
+	case "tree":
+		pid, pidErr := core.GetInt(args, "pid", 0)
+		if pidErr != nil {
+			return result, pidErr
+		}
+		result.AddStep("Building process tree...")
+		output, err = getProcessTree(pid)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error building process tree: %v", err))
+		} else {
+			result.AddStep("Successfully built the process tree")
+		}
+	case "ports", "connections":
+		port, portErr := core.GetInt(args, "port", 0)
+		if portErr != nil {
+			return result, portErr
+		}
+		result.AddStep(fmt.Sprintf("Reading %s from %s/net...", infoType, procRoot))
+		if infoType == "ports" {
+			output, err = getPorts(port)
+		} else {
+			output, err = getConnections(port)
+		}
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error reading %s: %v", infoType, err))
+		} else {
+			result.AddStep(fmt.Sprintf("Successfully retrieved %s", infoType))
+		}
+	case "project":
+		path, pathErr := core.GetString(args, "path", ".")
+		if pathErr != nil {
+			return result, pathErr
+		}
+		result.AddStep(fmt.Sprintf("Inspecting project in %s...", path))
+		output, err = getProjectInfo(ctx, path)
+		if err != nil {
+			result.AddStep(fmt.Sprintf("Error inspecting project: %v", err))
+		} else {
+			result.AddStep("Successfully detected languages, build files and commands")
+		}
 	case "env":
 		result.AddStep("Gathering environment variables...")
 		output, err = t.getEnvironmentInfo()
@@ -120,10 +217,18 @@ func (t *Tool) getBasicInfo() (string, error) {
 		"arch":       runtime.GOARCH,
 		"cpus":       runtime.NumCPU(),
 		"time":       time.Now().Format(time.RFC3339),
-		"uptime":     time.Since(startTime).String(),
 		"go_version": runtime.Version(),
 	}
 
+	if uptime, err := readUptime(); err == nil {
+		info["uptime"] = formatUptime(uptime)
+	} else {
+		info["kiwi_uptime"] = time.Since(startTime).Truncate(time.Second).String()
+	}
+	if load, err := readLoadAvg(); err == nil {
+		info["load_average"] = fmt.Sprintf("%.2f %.2f %.2f", load.one, load.five, load.fifteen)
+	}
+
 	var result strings.Builder
 	for k, v := range info {
 		result.WriteString(fmt.Sprintf("%s: %v\n", k, v))
@@ -132,8 +237,14 @@ func (t *Tool) getBasicInfo() (string, error) {
 	return result.String(), nil
 }
 
-// getMemoryInfo returns memory usage information
+// getMemoryInfo returns host memory usage, or kiwi's own where /proc isn't available
 func (t *Tool) getMemoryInfo() (string, error) {
+	if output, err := getHostMemoryInfo(); err == nil {
+		return output, nil
+	} else if runtime.GOOS == "linux" {
+		return "", err
+	}
+
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
@@ -145,6 +256,7 @@ func (t *Tool) getMemoryInfo() (string, error) {
 	}
 
 	var result strings.Builder
+	result.WriteString("Host memory is only available on Linux, showing kiwi's own memory usage\n")
 	for k, v := range info {
 		result.WriteString(fmt.Sprintf("%s: %s\n", k, v))
 	}
@@ -0,0 +1,384 @@
+package sysinfo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain reads /proc from the fixtures in testdata and doesn't wait between CPU samples
+func TestMain(m *testing.M) {
+	procRoot = "testdata/proc"
+	sampleInterval = 0
+	os.Exit(m.Run())
+}
+
+func TestReadMemInfo(t *testing.T) {
+	mem, err := readMemInfo()
+	if err != nil {
+		t.Fatalf("readMemInfo() failed: %v", err)
+	}
+	want := memInfo{total: 16318412 * 1024, available: 8159206 * 1024, swapTotal: 2097148 * 1024, swapFree: 1572860 * 1024}
+	if mem != want {
+		t.Errorf("readMemInfo() = %+v, want %+v", mem, want)
+	}
+}
+
+func TestReadLoadAvgAndUptime(t *testing.T) {
+	load, err := readLoadAvg()
+	if err != nil {
+		t.Fatalf("readLoadAvg() failed: %v", err)
+	}
+	if load != (loadAvg{one: 1.25, five: 0.80, fifteen: 0.50, running: 3, total: 512}) {
+		t.Errorf("readLoadAvg() = %+v", load)
+	}
+
+	uptime, err := readUptime()
+	if err != nil {
+		t.Fatalf("readUptime() failed: %v", err)
+	}
+	if got := formatUptime(uptime); got != "3 days, 4h02m" {
+		t.Errorf("formatUptime(%s) = %q, want %q", uptime, got, "3 days, 4h02m")
+	}
+}
+
+func TestCPUUsage(t *testing.T) {
+	cpus, err := readCPUTimes()
+	if err != nil {
+		t.Fatalf("readCPUTimes() failed: %v", err)
+	}
+	if len(cpus) != 3 || cpus[0].name != "cpu" || cpus[2].name != "cpu1" {
+		t.Fatalf("readCPUTimes() should return the total and both CPUs, got %+v", cpus)
+	}
+	if cpus[1].idle != 201000 || cpus[1].total != 226300 {
+		t.Errorf("readCPUTimes() cpu0 = %+v, want idle 201000 and total 226300", cpus[1])
+	}
+
+	before := cpuTimes{idle: 1000, total: 2000}
+	after := cpuTimes{idle: 1300, total: 3000}
+	if got := cpuUsage(before, after); got != 70 {
+		t.Errorf("cpuUsage() = %v, want 70", got)
+	}
+	if got := cpuUsage(before, before); got != 0 {
+		t.Errorf("cpuUsage() without elapsed time = %v, want 0", got)
+	}
+}
+
+func TestReadProcesses(t *testing.T) {
+	processes, err := readProcesses()
+	if err != nil {
+		t.Fatalf("readProcesses() failed: %v", err)
+	}
+	if len(processes) != 3 {
+		t.Fatalf("readProcesses() returned %d processes, want 3", len(processes))
+	}
+
+	byPID := make(map[int]procStat)
+	for _, process := range processes {
+		byPID[process.pid] = process
+	}
+	web := byPID[42]
+	if web.comm != "Web Content" || web.state != "R" || web.ppid != 1 || web.cpuTicks != 100000 {
+		t.Errorf("readProcStat(42) = %+v", web)
+	}
+	if web.rss != 131072*uint64(os.Getpagesize()) {
+		t.Errorf("readProcStat(42) rss = %d, want %d pages", web.rss, 131072)
+	}
+	if odd := byPID[137]; odd.comm != "odd) name)" || odd.state != "Z" || odd.ppid != 42 {
+		t.Errorf("readProcStat(137) should handle parentheses in the name, got %+v", odd)
+	}
+}
+
+func TestReadMounts(t *testing.T) {
+	mounts, err := readMounts()
+	if err != nil {
+		t.Fatalf("readMounts() failed: %v", err)
+	}
+	want := []mount{
+		{device: "/dev/root", path: "/", fstype: "ext4"},
+		{device: "/dev/sdb1", path: "/media/My Disk", fstype: "vfat"},
+	}
+	if len(mounts) != len(want) {
+		t.Fatalf("readMounts() = %+v, want %+v", mounts, want)
+	}
+	for i := range want {
+		if mounts[i] != want[i] {
+			t.Errorf("readMounts()[%d] = %+v, want %+v", i, mounts[i], want[i])
+		}
+	}
+}
+
+func TestHostInfoTypes(t *testing.T) {
+	tool := New()
+	tests := []struct {
+		infoType string
+		want     []string
+	}{
+		{"basic", []string{"uptime: 3 days, 4h02m", "load_average: 1.25 0.80 0.50"}},
+		{"memory", []string{"total: 15.6 GB", "available: 7.8 GB", "used: 7.8 GB (50%)", "swap_used: 512.0 MB (25%)"}},
+		{"cpu", []string{"load_average: 1.25 0.80 0.50", "processes: 3 running, 512 total", "all: 0.0%", "cpu1: 0.0%"}},
+		{"top", []string{"Top processes by CPU", "Top processes by memory", "Web Content", "odd) name)"}},
+	}
+
+	for _, tt := range tests {
+		result, err := tool.Execute(context.Background(), map[string]interface{}{"type": tt.infoType})
+		if err != nil {
+			t.Errorf("Execute(%s) failed: %v", tt.infoType, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(result.Output, want) {
+				t.Errorf("Execute(%s) should contain %q, got:\n%s", tt.infoType, want, result.Output)
+			}
+		}
+	}
+
+	// The processes are listed by memory, largest first
+	result, _ := tool.Execute(context.Background(), map[string]interface{}{"type": "top"})
+	byMemory := result.Output[strings.Index(result.Output, "by memory"):]
+	if strings.Index(byMemory, "Web Content") > strings.Index(byMemory, "systemd") {
+		t.Errorf("Execute(top) should list the largest process first, got:\n%s", byMemory)
+	}
+}
+
+func TestDiskInfo(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("disk usage is only available on Linux")
+	}
+
+	output, err := getDiskInfo()
+	if err != nil {
+		t.Fatalf("getDiskInfo() failed: %v", err)
+	}
+	// The root filesystem exists on every host, the fixture's USB disk doesn't
+	if !strings.Contains(output, "/dev/root") || strings.Contains(output, "/media/My Disk") {
+		t.Errorf("getDiskInfo() should list only accessible mounts, got:\n%s", output)
+	}
+}
+
+func TestTopProcessesCanceled(t *testing.T) {
+	sampleInterval = time.Minute
+	defer func() { sampleInterval = 0 }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := getTopProcesses(ctx); err == nil {
+		t.Error("getTopProcesses() should stop when the context is canceled")
+	}
+}
+
+// writeFiles creates files under dir, with their slash-separated names relative to it
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProjectInfo(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                    "module example.com/app\n\ngo 1.23\n",
+		"main.go":                   "package main\n",
+		"internal/app/app.go":       "package app\n",
+		"web/index.ts":              "export {}\n",
+		"node_modules/dep/index.js": "module.exports = {}\n",
+		"package.json":              `{"name": "app-web", "scripts": {"build": "tsc", "test": "vitest", "format": "prettier -w ."}}`,
+		"pnpm-lock.yaml":            "lockfileVersion: '9.0'\n",
+		"Makefile":                  "CC := gcc\n.PHONY: build test\n\nbuild: deps\n\tgo build\n\ntest:\n\tgo test ./...\n%.o: %.c\n",
+		".github/workflows/ci.yml":  "on: push\n",
+	}
+	writeFiles(t, dir, files)
+
+	output, err := getProjectInfo(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("getProjectInfo() failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"Languages: Go (2 files), TypeScript (1 file)\n",
+		"go.mod: Go module example.com/app (go 1.23)",
+		"package.json: Node.js package app-web (pnpm), scripts: build, format, test",
+		"Makefile: targets: build, test",
+		"install: pnpm install (from package.json)",
+		"build: go build ./... (from go.mod)",
+		"build: pnpm run build (from package.json)",
+		"build: make build (from Makefile)",
+		"test: pnpm test (from package.json)",
+		".github/workflows/ci.yml (GitHub Actions)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("getProjectInfo() should contain %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "JavaScript") || strings.Contains(output, "format (from") {
+		t.Errorf("getProjectInfo() should skip dependencies and unrelated scripts, got:\n%s", output)
+	}
+	if _, err := exec.LookPath("git"); err == nil && !strings.Contains(output, "Git: not a git repository") {
+		t.Errorf("getProjectInfo() should report a directory outside of git, got:\n%s", output)
+	}
+}
+
+func TestProjectGitState(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# app\n"), 0644)
+	git("add", "README.md")
+	git("commit", "-q", "-m", "Initial commit")
+
+	if state := gitState(context.Background(), dir); state != "branch main, clean" {
+		t.Errorf("gitState() = %q, want %q", state, "branch main, clean")
+	}
+
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("# app\n\nMore.\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("todo\n"), 0644)
+	if state := gitState(context.Background(), dir); state != "branch main, dirty (1 modified, 1 untracked)" {
+		t.Errorf("gitState() = %q, want %q", state, "branch main, dirty (1 modified, 1 untracked)")
+	}
+
+	// Programs named by the repository config are never run
+	if runtime.GOOS != "windows" {
+		marker := filepath.Join(t.TempDir(), "ran")
+		script := filepath.Join(t.TempDir(), "evil.sh")
+		if err := os.WriteFile(script, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		git("config", "core.fsmonitor", script)
+		git("config", "filter.evil.clean", script)
+		if err := os.WriteFile(filepath.Join(dir, ".git", "info", "attributes"), []byte("* filter=evil\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitState(context.Background(), dir)
+		if _, err := os.Stat(marker); err == nil {
+			t.Error("gitState() should not run programs from the repository config")
+		}
+	}
+}
+
+func TestParseSocketAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		ip      string
+		port    int
+	}{
+		{"0100007F:1538", "127.0.0.1", 5432},
+		{"22D8B85D:01BB", "93.184.216.34", 443},
+		{"00000000000000000000000001000000:0277", "::1", 631},
+		{"0000000000000000FFFF00000100007F:1F90", "127.0.0.1", 8080},
+	}
+	for _, tt := range tests {
+		ip, port, err := parseSocketAddress(tt.address)
+		if err != nil || ip.String() != tt.ip || port != tt.port {
+			t.Errorf("parseSocketAddress(%q) = %v, %d, %v, want %s, %d", tt.address, ip, port, err, tt.ip, tt.port)
+		}
+	}
+	if _, _, err := parseSocketAddress("0100007F"); err == nil {
+		t.Error("parseSocketAddress() should reject an address without a port")
+	}
+}
+
+func TestProcessTypes(t *testing.T) {
+	tool := New()
+	tests := []struct {
+		args    map[string]interface{}
+		want    []string
+		exclude []string
+	}{
+		{
+			args: map[string]interface{}{"type": "processes"},
+			want: []string{
+				"/usr/lib/firefox/firefox -contentproc -childID",
+				"root", "[odd) name)]",
+				"Zombie: 137 (odd) name)) has exited but was not reaped by its parent 42",
+			},
+		},
+		{
+			args:    map[string]interface{}{"type": "processes", "filter": "FIREFOX"},
+			want:    []string{"-contentproc"},
+			exclude: []string{"/sbin/init"},
+		},
+		{
+			args: map[string]interface{}{"type": "tree"},
+			want: []string{"1 /sbin/init splash [root]\n└─ 42 /usr/lib/firefox/firefox", "\n   └─ 137 [odd) name)] [?] <zombie>\n"},
+		},
+		{
+			args:    map[string]interface{}{"type": "tree", "pid": 42},
+			want:    []string{"Parents:\n  1 /sbin/init splash [root]\n", "Process and children:\n42 /usr/lib/firefox/firefox"},
+			exclude: []string{"\n1 /sbin/init"},
+		},
+		{
+			args: map[string]interface{}{"type": "ports"},
+			want: []string{
+				"tcp   127.0.0.1:5432", "4242               42  /usr/lib/firefox/firefox",
+				"tcp   0.0.0.0:22", "tcp6  [::]:8080", "1  /sbin/init splash",
+				"tcp6  [::1]:631", "udp   127.0.0.53:53",
+			},
+			exclude: []string{"ESTABLISHED", "TIME_WAIT"},
+		},
+		{
+			args:    map[string]interface{}{"type": "ports", "port": 5432},
+			want:    []string{"127.0.0.1:5432"},
+			exclude: []string{":22", ":8080"},
+		},
+		{
+			args: map[string]interface{}{"type": "connections"},
+			want: []string{
+				"127.0.0.1:5432               127.0.0.1:54321              ESTABLISHED",
+				"127.0.0.1:40000              93.184.216.34:443            TIME_WAIT",
+			},
+			exclude: []string{"LISTEN"},
+		},
+		{
+			args:    map[string]interface{}{"type": "connections", "port": 443},
+			want:    []string{"93.184.216.34:443"},
+			exclude: []string{"54321"},
+		},
+		{
+			args: map[string]interface{}{"type": "ports", "port": 9999},
+			want: []string{"No listening ports on port 9999"},
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := tool.Execute(context.Background(), tt.args)
+		if err != nil {
+			t.Errorf("Execute(%v) failed: %v", tt.args, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(result.Output, want) {
+				t.Errorf("Execute(%v) should contain %q, got:\n%s", tt.args, want, result.Output)
+			}
+		}
+		for _, exclude := range tt.exclude {
+			if strings.Contains(result.Output, exclude) {
+				t.Errorf("Execute(%v) should not contain %q, got:\n%s", tt.args, exclude, result.Output)
+			}
+		}
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"type": "tree", "pid": 999}); err == nil {
+		t.Error("Execute(tree) should fail for an unknown PID")
+	}
+}
@@ -0,0 +1,222 @@
+package testrunner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// goLocation matches the file:line prefix of t.Error and t.Fatal messages
+	goLocation = regexp.MustCompile(`^\s*([\w.\-/]+\.go):(\d+): `)
+
+	// goFrame matches a file:line of a stack trace, printed by panics
+	goFrame = regexp.MustCompile(`^\s+(/\S+\.go):(\d+)`)
+)
+
+// goEvent is a line of go test -json output, see go doc test2json
+type goEvent struct {
+	Action      string
+	Package     string
+	ImportPath  string // set on build-output events
+	Test        string
+	Output      string
+	FailedBuild string // set when the package failed because it didn't build
+}
+
+// runGo runs go test -json in dir, inside the module at root
+func runGo(ctx context.Context, dir, root, target, pattern string) (*report, error) {
+	if target == "" {
+		target = "./..."
+	}
+	args := []string{"test", "-json"}
+	if pattern != "" {
+		args = append(args, "-run", pattern)
+	}
+	args = append(args, target)
+
+	stdout, stderr, err := runCommand(ctx, dir, nil, "go", args...)
+	if err != nil {
+		return nil, err
+	}
+	rep := parseGoTest(bytes.NewReader(stdout), root, modulePath(root))
+	rep.command = "go " + strings.Join(args, " ")
+	if errors := strings.TrimSpace(string(stderr)); errors != "" {
+		rep.errors = strings.TrimSpace(rep.errors + "\n" + errors)
+	}
+	return rep, nil
+}
+
+// modulePath reads the module path from the go.mod in root
+func modulePath(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+	return ""
+}
+
+// parseGoTest reads the events of go test -json. Failing tests keep their output, and
+// so do packages that fail without a failing test, like when TestMain exits early.
+// Packages that don't build are reported with the compiler errors instead.
+func parseGoTest(r io.Reader, root, module string) *report {
+	rep := &report{framework: "go"}
+	outputs := make(map[string]*strings.Builder)
+	buildOutput := make(map[string]*strings.Builder)
+	var buildOrder []string
+	var other strings.Builder
+	failingPackages := make(map[string]bool)
+
+	appendTo := func(outputs map[string]*strings.Builder, key, text string) {
+		if outputs[key] == nil {
+			outputs[key] = &strings.Builder{}
+		}
+		outputs[key].WriteString(text)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event goEvent
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil {
+			// Older go versions print build errors as plain text
+			if len(bytes.TrimSpace(line)) > 0 {
+				other.Write(line)
+				other.WriteString("\n")
+			}
+			continue
+		}
+
+		key := event.Package + " " + event.Test
+		switch event.Action {
+		case "output":
+			appendTo(outputs, key, event.Output)
+		case "build-output":
+			if buildOutput[event.ImportPath] == nil {
+				buildOrder = append(buildOrder, event.ImportPath)
+			}
+			appendTo(buildOutput, event.ImportPath, event.Output)
+		case "pass":
+			if event.Test != "" {
+				rep.passed++
+			}
+		case "skip":
+			if event.Test != "" {
+				rep.skipped++
+			}
+		case "fail":
+			if event.Test != "" {
+				rep.failed++
+				failingPackages[event.Package] = true
+				output := testOutput(outputs[key])
+				rep.failures = append(rep.failures, failure{
+					name:     shortPackage(event.Package, module) + "." + event.Test,
+					location: goFailureLocation(output, root, packageDir(event.Package, module)),
+					output:   output,
+				})
+			} else if !failingPackages[event.Package] && event.FailedBuild == "" {
+				output := testOutput(outputs[key])
+				rep.failures = append(rep.failures, failure{
+					name:     shortPackage(event.Package, module),
+					location: goFailureLocation(output, root, packageDir(event.Package, module)),
+					output:   output,
+				})
+			}
+		}
+	}
+
+	rep.failures = dropFailingParents(rep.failures)
+	var errors strings.Builder
+	for _, importPath := range buildOrder {
+		errors.WriteString(buildOutput[importPath].String())
+	}
+	errors.WriteString(other.String())
+	rep.errors = strings.TrimSpace(errors.String())
+	return rep
+}
+
+// testOutput returns the output of a test without the lines go test adds around it
+func testOutput(output *strings.Builder) string {
+	if output == nil {
+		return ""
+	}
+	var lines []string
+	for _, line := range strings.Split(output.String(), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") ||
+			trimmed == "PASS" || trimmed == "FAIL" || strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "ok  \t") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dropFailingParents keeps only the failing subtests of a test, which carry the messages
+func dropFailingParents(failures []failure) []failure {
+	var kept []failure
+	for _, f := range failures {
+		parent := false
+		for _, other := range failures {
+			if strings.HasPrefix(other.name, f.name+"/") {
+				parent = true
+				break
+			}
+		}
+		if !parent {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// shortPackage returns an import path relative to the module
+func shortPackage(importPath, module string) string {
+	if module == "" || !strings.HasPrefix(importPath, module) {
+		return importPath
+	}
+	if short := strings.TrimPrefix(strings.TrimPrefix(importPath, module), "/"); short != "" {
+		return short
+	}
+	return path.Base(importPath)
+}
+
+// packageDir returns the directory of a package relative to the module root
+func packageDir(importPath, module string) string {
+	if module == "" || !strings.HasPrefix(importPath, module) {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(importPath, module), "/")
+}
+
+// goFailureLocation finds where a test failed: the first t.Error style message, or
+// else the first frame of a stack trace inside the module
+func goFailureLocation(output, root, dir string) string {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if match := goLocation.FindStringSubmatch(line); match != nil {
+			return path.Join(dir, match[1]) + ":" + match[2]
+		}
+	}
+	for _, line := range lines {
+		if match := goFrame.FindStringSubmatch(line); match != nil {
+			if file := relative(root, match[1]); !filepath.IsAbs(file) {
+				return file + ":" + match[2]
+			}
+		}
+	}
+	return ""
+}
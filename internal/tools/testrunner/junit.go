@@ -0,0 +1,204 @@
+package testrunner
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// pythonLocation matches the file:line pytest prints below a failing assertion
+	pythonLocation = regexp.MustCompile(`(?m)^([^\s:]+\.py):(\d+): `)
+
+	// jsLocation matches a file:line:column of a JavaScript stack trace
+	jsLocation = regexp.MustCompile(`\(?([^\s()]+\.[cm]?[jt]sx?):(\d+):\d+\)?`)
+)
+
+// junitSuite is a testsuites or testsuite element of a JUnit XML report
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+// junitCase is a testcase element of a JUnit XML report
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitMessage `xml:"failure"`
+	Errors    []junitMessage `xml:"error"`
+	Skipped   *junitMessage  `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// runPytest runs pytest in root with a JUnit XML report
+func runPytest(ctx context.Context, root, target, pattern string) (*report, error) {
+	name, args := "pytest", []string{}
+	if _, err := exec.LookPath(name); err != nil {
+		name, args = "python3", []string{"-m", "pytest"}
+	}
+	reportFile, cleanup, err := tempReport()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	// xunit1 reports carry the file and line of each test
+	args = append(args, "-q", "-o", "junit_family=xunit1", "--junitxml="+reportFile)
+	if pattern != "" {
+		args = append(args, "-k", pattern)
+	}
+	if target != "" {
+		args = append(args, target)
+	}
+	return runJUnit(ctx, "pytest", root, reportFile, nil, name, args...)
+}
+
+// runJest runs jest in root with the jest-junit reporter
+func runJest(ctx context.Context, root, target, pattern string) (*report, error) {
+	reportFile, cleanup, err := tempReport()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	args := []string{"--no-install", "jest", "--ci", "--reporters=default", "--reporters=jest-junit"}
+	if pattern != "" {
+		args = append(args, "-t", pattern)
+	}
+	if target != "" {
+		args = append(args, target)
+	}
+	env := []string{
+		"JEST_JUNIT_OUTPUT_DIR=" + filepath.Dir(reportFile),
+		"JEST_JUNIT_OUTPUT_NAME=" + filepath.Base(reportFile),
+		"JEST_JUNIT_ADD_FILE_ATTRIBUTE=true",
+	}
+	rep, err := runJUnit(ctx, "jest", root, reportFile, env, "npx", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w (jest needs the jest-junit reporter: npm install --save-dev jest-junit)", err)
+	}
+	return rep, nil
+}
+
+// tempReport reserves a file for a JUnit XML report
+func tempReport() (string, func(), error) {
+	file, err := os.CreateTemp("", "kiwi-junit-*.xml")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create report file: %w", err)
+	}
+	file.Close()
+	// The runner writes the report itself, an empty file would look like a report
+	os.Remove(file.Name())
+	return file.Name(), func() { os.Remove(file.Name()) }, nil
+}
+
+// runJUnit runs a test command and parses the JUnit XML report it writes
+func runJUnit(ctx context.Context, framework, root, reportFile string, env []string, name string, args ...string) (*report, error) {
+	stdout, stderr, err := runCommand(ctx, root, env, name, args...)
+	if err != nil {
+		return nil, err
+	}
+	output := string(stdout) + string(stderr)
+	command := name + " " + strings.Join(args, " ")
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s did not write a JUnit report, its output ends with:\n%s", command, tail(output, maxFailureLines))
+	}
+	rep, err := parseJUnit(data, framework, root)
+	if err != nil {
+		return nil, err
+	}
+	rep.command = command
+	rep.raw = tail(output, maxFailureLines)
+	return rep, nil
+}
+
+// parseJUnit reads a JUnit XML report, as written by pytest and jest-junit
+func parseJUnit(data []byte, framework, root string) (*report, error) {
+	var suite junitSuite
+	if err := xml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit report: %w", err)
+	}
+
+	rep := &report{framework: framework}
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, c := range suite.Cases {
+			problems := append(append([]junitMessage{}, c.Failures...), c.Errors...)
+			switch {
+			case len(problems) > 0:
+				rep.failed++
+				rep.failures = append(rep.failures, junitFailure(c, problems, framework, root))
+			case c.Skipped != nil:
+				rep.skipped++
+			default:
+				rep.passed++
+			}
+		}
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+	walk(suite)
+	return rep, nil
+}
+
+// junitFailure describes a failing test case, locating the failure in its output
+func junitFailure(c junitCase, problems []junitMessage, framework, root string) failure {
+	name := c.Name
+	if c.Classname != "" && c.Classname != c.Name {
+		separator := "::"
+		if framework == "jest" {
+			separator = " › "
+		}
+		name = c.Classname + separator + c.Name
+	}
+
+	var output []string
+	for _, problem := range problems {
+		text := strings.TrimSpace(problem.Text)
+		if text == "" {
+			text = problem.Message
+		}
+		output = append(output, text)
+	}
+	text := strings.Join(output, "\n")
+
+	location := ""
+	switch framework {
+	case "pytest":
+		// The last file:line is the failing assertion, the first the test itself
+		if matches := pythonLocation.FindAllStringSubmatch(text, -1); len(matches) > 0 {
+			match := matches[len(matches)-1]
+			location = relative(root, match[1]) + ":" + match[2]
+		}
+	case "jest":
+		for _, match := range jsLocation.FindAllStringSubmatch(text, -1) {
+			if !strings.Contains(match[1], "node_modules") {
+				location = relative(root, match[1]) + ":" + match[2]
+				break
+			}
+		}
+	}
+	if location == "" && c.File != "" {
+		location = relative(root, c.File)
+		// pytest counts lines from zero
+		if line, err := strconv.Atoi(c.Line); err == nil {
+			location += ":" + strconv.Itoa(line+1)
+		}
+	}
+	return failure{name: name, location: location, output: text}
+}
@@ -0,0 +1,138 @@
+package testrunner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// failure is a failing test, or a package or file that failed without running its tests
+type failure struct {
+	name     string
+	location string // file:line relative to the project, if known
+	output   string
+}
+
+// report is the outcome of a test run
+type report struct {
+	framework string
+	command   string
+	passed    int
+	failed    int
+	skipped   int
+	failures  []failure
+	errors    string // build or collection errors
+	raw       string // end of the output, shown when nothing could be parsed
+	duration  time.Duration
+}
+
+// String formats the report for the model, failures first
+func (r *report) String() string {
+	status := "PASSED"
+	switch {
+	case r.failed > 0 || len(r.failures) > 0 || r.errors != "":
+		status = "FAILED"
+	case r.passed+r.skipped == 0:
+		status = "NO TESTS RUN"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s: %d passed, %d failed, %d skipped", status, r.passed, r.failed, r.skipped))
+	if r.duration > 0 {
+		b.WriteString(fmt.Sprintf(" in %s", r.duration.Round(100*time.Millisecond)))
+	}
+	b.WriteString(fmt.Sprintf("\nCommand: %s\n", r.command))
+
+	if r.errors != "" {
+		b.WriteString("\nErrors:\n")
+		b.WriteString(indent(trimLines(r.errors, maxFailureLines*2)))
+	}
+
+	if len(r.failures) > 0 {
+		b.WriteString(fmt.Sprintf("\nFailures (%d):\n", len(r.failures)))
+	}
+	for i, f := range r.failures {
+		if i == maxFailures {
+			b.WriteString(fmt.Sprintf("\n[%d more failures not shown, narrow it down with target or run]\n", len(r.failures)-i))
+			break
+		}
+		b.WriteString("\n--- " + f.name)
+		if f.location != "" {
+			b.WriteString(" (" + f.location + ")")
+		}
+		b.WriteString("\n")
+		if output := trimLines(dedent(f.output), maxFailureLines); output != "" {
+			b.WriteString(indent(output))
+		}
+	}
+
+	if status == "NO TESTS RUN" && r.raw != "" {
+		b.WriteString("\nOutput:\n")
+		b.WriteString(indent(r.raw))
+	}
+	return b.String()
+}
+
+// trimLines drops blank lines at both ends and keeps at most n lines
+func trimLines(text string, n int) string {
+	text = strings.Trim(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = append(lines[:n], fmt.Sprintf("[%d more lines]", len(lines)-n))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// tail keeps the last n lines of an output
+func tail(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// dedent removes the indentation shared by all non-blank lines
+func dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " "))
+		if common == -1 || width < common {
+			common = width
+		}
+	}
+	if common <= 0 {
+		return text
+	}
+	for i, line := range lines {
+		if len(line) >= common {
+			lines[i] = line[common:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n    ") + "\n"
+}
+
+// relative returns a file relative to the project root when it is inside it
+func relative(root, file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	if rel, err := filepath.Rel(root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
@@ -0,0 +1,268 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+)
+
+const (
+	// maxFailures caps the failing tests described in detail
+	maxFailures = 20
+
+	// maxFailureLines caps the output shown for a single failure
+	maxFailureLines = 30
+
+	// testTimeout bounds a single test run
+	testTimeout = 10 * time.Minute
+)
+
+// frameworks are the test frameworks the tool can run, in the order they are detected
+var frameworks = []string{"go", "pytest", "jest"}
+
+// Tool runs the tests of a project and reports the results in a structured form
+type Tool struct {
+	name        string
+	description string
+	parameters  map[string]core.Parameter
+}
+
+// New creates a new test tool
+func New() *Tool {
+	parameters := map[string]core.Parameter{
+		"path": {
+			Type:        "string",
+			Description: "Directory of the project or package to test (default: current directory)",
+			Required:    false,
+		},
+		"framework": {
+			Type:        "string",
+			Description: "Test framework: 'go', 'pytest' or 'jest' (default: detected from go.mod, pytest configuration or package.json)",
+			Required:    false,
+		},
+		"target": {
+			Type:        "string",
+			Description: "What to test: a package pattern for go (default ./...), a file, directory or node id relative to path for pytest, a path pattern for jest (default: the tests under path)",
+			Required:    false,
+		},
+		"run": {
+			Type:        "string",
+			Description: "Only run tests matching this pattern (go test -run, pytest -k, jest -t)",
+			Required:    false,
+		},
+	}
+
+	return &Tool{
+		name:        "test",
+		description: "Runs the tests of a Go, pytest or jest project and returns pass, fail and skip counts, the failing tests with their file:line and trimmed failure output",
+		parameters:  parameters,
+	}
+}
+
+// Name returns the name of the tool
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the description of the tool
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Parameters returns the parameters for the tool
+func (t *Tool) Parameters() map[string]core.Parameter {
+	return t.parameters
+}
+
+// RequiresConfirmation returns true because tests run the code of the project
+func (t *Tool) RequiresConfirmation() bool {
+	return true
+}
+
+// Execute runs the tests
+func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.ToolExecutionResult, error) {
+	result := core.ToolExecutionResult{
+		ToolMethod: "",
+		Output:     "",
+	}
+
+	path, err := core.GetString(args, "path", ".")
+	if err != nil {
+		return result, err
+	}
+	dir, err := workspace.Current().Check(path, workspace.Read)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Path check failed: %v", err))
+		return result, err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, fmt.Errorf("failed to access %s: %w", path, err)
+	} else if !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+
+	framework, err := core.GetString(args, "framework", "")
+	if err != nil {
+		return result, err
+	}
+	target, err := core.GetString(args, "target", "")
+	if err != nil {
+		return result, err
+	}
+	pattern, err := core.GetString(args, "run", "")
+	if err != nil {
+		return result, err
+	}
+	if strings.HasPrefix(target, "-") {
+		return result, fmt.Errorf("invalid target: %q", target)
+	}
+
+	root := dir
+	if framework == "" {
+		framework, root = detect(dir)
+		if framework == "" {
+			result.AddStep("No test framework detected")
+			return result, fmt.Errorf("no go.mod, pytest configuration or jest in package.json found in %s or its parents, set framework", dir)
+		}
+		result.AddStep(fmt.Sprintf("Detected %s project at %s", framework, root))
+	} else if found := findRoot(dir, framework); found != "" {
+		root = found
+	}
+	result.ToolMethod = framework
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	var rep *report
+	start := time.Now()
+	switch framework {
+	case "go":
+		rep, err = runGo(ctx, dir, root, target, pattern)
+	case "pytest":
+		rep, err = runPytest(ctx, root, scope(root, dir, target, true), pattern)
+	case "jest":
+		rep, err = runJest(ctx, root, scope(root, dir, target, false), pattern)
+	default:
+		result.AddStep(fmt.Sprintf("Unknown framework: %s", framework))
+		return result, fmt.Errorf("unknown framework: %s, use one of %s", framework, strings.Join(frameworks, ", "))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("tests did not finish within %s", testTimeout)
+	}
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error: %v", err))
+		return result, err
+	}
+	rep.duration = time.Since(start)
+	result.AddStep(fmt.Sprintf("Ran %s: %d passed, %d failed, %d skipped", rep.command, rep.passed, rep.failed, rep.skipped))
+
+	output := rep.String()
+	output = result.TruncateOutput(output, "narrow it down with target or run")
+	result.Output = output
+	return result, nil
+}
+
+// detect finds the framework of the project containing dir, and the directory it runs in.
+// The search stops at the workspace root.
+func detect(dir string) (string, string) {
+	policy := workspace.Current()
+	for current := dir; ; current = filepath.Dir(current) {
+		if !policy.Allowed(current, workspace.Read) {
+			return "", ""
+		}
+		for _, framework := range frameworks {
+			if uses(current, framework) {
+				return framework, current
+			}
+		}
+		if filepath.Dir(current) == current {
+			return "", ""
+		}
+	}
+}
+
+// findRoot returns the nearest directory from dir up to the workspace root configured for the framework
+func findRoot(dir, framework string) string {
+	policy := workspace.Current()
+	for current := dir; ; current = filepath.Dir(current) {
+		if !policy.Allowed(current, workspace.Read) {
+			return ""
+		}
+		if uses(current, framework) {
+			return current
+		}
+		if filepath.Dir(current) == current {
+			return ""
+		}
+	}
+}
+
+// scope returns the target of a runner started in root so that it only tests dir.
+// Path targets are relative to dir, other targets like jest patterns are kept as given.
+func scope(root, dir, target string, isPath bool) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return target
+	}
+	if target == "" {
+		return filepath.ToSlash(rel)
+	}
+	if isPath && !filepath.IsAbs(target) {
+		return filepath.ToSlash(filepath.Join(rel, target))
+	}
+	return target
+}
+
+// uses reports whether dir holds the configuration of a framework
+func uses(dir, framework string) bool {
+	contains := func(name, text string) bool {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		return err == nil && strings.Contains(string(data), text)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	switch framework {
+	case "go":
+		return exists("go.mod")
+	case "pytest":
+		return exists("pytest.ini") || exists("conftest.py") ||
+			contains("pyproject.toml", "[tool.pytest") || contains("setup.cfg", "[tool:pytest]") || contains("tox.ini", "[pytest]")
+	case "jest":
+		return contains("package.json", `"jest"`) || exists("jest.config.js") || exists("jest.config.ts")
+	}
+	return false
+}
+
+// runCommand runs a test command. Failing tests make it exit with an error, which is
+// not an error of the tool, so only failures to start it are returned.
+func runCommand(ctx context.Context, dir string, env []string, name string, args ...string) ([]byte, []byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%s is not installed", name)
+		}
+		return nil, nil, fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return stdout.Bytes(), stderr.Bytes(), nil
+}
@@ -3,12 +3,30 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
 
+	"github.com/saurabh0719/kiwi/internal/config"
+	"github.com/saurabh0719/kiwi/internal/httpcache"
+	"github.com/saurabh0719/kiwi/internal/memory"
+	"github.com/saurabh0719/kiwi/internal/tools/archive"
+	"github.com/saurabh0719/kiwi/internal/tools/code"
 	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/data"
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
 	"github.com/saurabh0719/kiwi/internal/tools/filesystem"
+	"github.com/saurabh0719/kiwi/internal/tools/git"
+	"github.com/saurabh0719/kiwi/internal/tools/httprequest"
+	"github.com/saurabh0719/kiwi/internal/tools/memorytool"
+	"github.com/saurabh0719/kiwi/internal/tools/plantool"
 	"github.com/saurabh0719/kiwi/internal/tools/shell"
+	"github.com/saurabh0719/kiwi/internal/tools/sqlite"
 	"github.com/saurabh0719/kiwi/internal/tools/sysinfo"
+	"github.com/saurabh0719/kiwi/internal/tools/testrunner"
 	"github.com/saurabh0719/kiwi/internal/tools/websearch"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
+	"github.com/saurabh0719/kiwi/internal/util"
 )
 
 // Parameter represents a parameter for a tool
@@ -106,13 +124,102 @@ func (r *Registry) ToJSON() (string, error) {
 }
 
 // RegisterStandardTools initializes and registers the default set of tools
-func RegisterStandardTools(registry *Registry) {
+func RegisterStandardTools(registry *Registry, cfg *config.Config) {
+	if cfg == nil {
+		cfg = &config.Config{
+			LLM: config.LLMConfig{SafeMode: true},
+			Tools: config.ToolsConfig{
+				Workspace: config.WorkspaceConfig{Deny: workspace.DefaultDeny},
+				Network:   config.NetworkConfig{ConfirmNewDomains: true},
+				Cache:     config.CacheConfig{Enabled: true},
+			},
+		}
+	}
+
+	// Every tool that touches paths shares the same workspace policy
+	ws := cfg.Tools.Workspace
+	workspace.SetCurrent(workspace.New(ws.ReadWrite, ws.ReadOnly, ws.Deny))
+
+	// Every tool that makes requests shares the same egress policy
+	network := cfg.Tools.Network
+	policy := egress.New(network.Allow, network.Deny, network.AllowPrivate, int64(network.MaxResponseMB)*1024*1024)
+	if network.ConfirmNewDomains {
+		policy.SetConfirm(confirmDomain)
+	}
+	egress.SetCurrent(policy)
+
 	// Register default tools
-	registry.Register(NewFileSystemTool())
+	fsTool := filesystem.New()
+	fsTool.SetSafeMode(cfg.LLM.SafeMode)
+	registry.Register(fsTool)
 	registry.Register(NewShellTool())
 	registry.Register(NewSystemInfoTool())
-	// Register web search tool by default (no API key required)
-	registry.Register(NewWebSearchTool())
+	registry.Register(NewGitTool())
+	registry.Register(NewCodeTool())
+	registry.Register(NewTestTool())
+	// Databases are read-only unless the user enabled writes
+	sqliteTool := sqlite.New()
+	sqliteTool.SetAllowWrites(cfg.Tools.SQLite.AllowWrites)
+	registry.Register(sqliteTool)
+	registry.Register(NewDataTool())
+	registry.Register(NewArchiveTool())
+	registry.Register(NewMemoryTool())
+	registry.Register(NewPlanTool())
+	// Register web search tool by default, DuckDuckGo needs no API key
+	webTool := websearch.New()
+	webTool.SetMaxResults(cfg.Tools.Search.MaxResults)
+	search := cfg.Tools.Search
+	backend, err := websearch.NewBackend(search.Backend, search.URL, search.APIKey, webTool.HTTPClient())
+	if err != nil {
+		util.WarningColor.Printf("Warning: %v, falling back to DuckDuckGo for web search\n", err)
+		backend, _ = websearch.NewBackend("duckduckgo", "", "", webTool.HTTPClient())
+	}
+	webTool.SetBackend(backend)
+	if cache := newHTTPCache(cfg.Tools.Cache); cache != nil {
+		webTool.SetCache(cache)
+	}
+	// The search backend is chosen by the user, so it is reachable without a prompt
+	if endpoint, err := url.Parse(websearch.Endpoint(backend)); err == nil {
+		policy.Trust(endpoint.Hostname())
+	}
+	registry.Register(webTool)
+
+	// Register the HTTP tool, requests reference configured secrets by name
+	httpTool := httprequest.New()
+	httpTool.SetSecrets(cfg.Tools.HTTP.Secrets)
+	registry.Register(httpTool)
+}
+
+// newHTTPCache creates the on-disk cache for fetched pages, or returns nil when it is disabled
+func newHTTPCache(cfg config.CacheConfig) *httpcache.Cache {
+	if !cfg.Enabled && !cfg.Offline {
+		return nil
+	}
+
+	cache, err := httpcache.New()
+	if err != nil {
+		util.WarningColor.Printf("Warning: %v, the HTTP cache is disabled\n", err)
+		return nil
+	}
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			util.WarningColor.Printf("Warning: invalid cache TTL %q, using the cache headers of each response\n", cfg.TTL)
+		} else {
+			cache.SetTTL(ttl)
+		}
+	}
+	cache.SetOffline(cfg.Offline)
+	return cache
+}
+
+// confirmDomain asks the user before a network tool first connects to a host
+func confirmDomain(host string) bool {
+	util.GetGlobalSpinnerManager().TransitionToResponse()
+	fmt.Println()
+	util.InfoColor.Printf("[Network] A tool wants to connect to %s for the first time in this session\n", host)
+	confirmed, err := util.PromptForConfirmation("Allow connections to this host? (y/N): ")
+	return err == nil && confirmed
 }
 
 // NewFileSystemTool creates a new FileSystemTool
@@ -133,8 +240,66 @@ func NewSystemInfoTool() core.Tool {
 	return sysinfo.New()
 }
 
+// NewGitTool creates a new git tool
+func NewGitTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return git.New()
+}
+
+// NewCodeTool creates a new Go code navigation tool
+func NewCodeTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return code.New()
+}
+
+// NewTestTool creates a new test runner tool
+func NewTestTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return testrunner.New()
+}
+
+// NewSQLiteTool creates a new read-only SQLite tool
+func NewSQLiteTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return sqlite.New()
+}
+
+// NewDataTool creates a new tool for querying JSON, YAML, TOML and CSV files
+func NewDataTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return data.New()
+}
+
+// NewArchiveTool creates a new tool for tar, tar.gz and zip archives
+func NewArchiveTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return archive.New()
+}
+
+// NewMemoryTool creates a new memory tool backed by ~/.kiwi/memory
+func NewMemoryTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	memoryTool := memorytool.New()
+	if store, err := memory.New(); err == nil {
+		memoryTool.SetStore(store)
+	}
+	return memoryTool
+}
+
+// NewPlanTool creates a new tool for the todo list of the current session
+func NewPlanTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return plantool.New()
+}
+
 // NewWebSearchTool creates a new WebSearchTool
 func NewWebSearchTool() core.Tool {
 	// Direct implementation that returns ToolExecutionResult
 	return websearch.New()
 }
+
+// NewHTTPTool creates a new HTTP request tool
+func NewHTTPTool() core.Tool {
+	// Direct implementation that returns ToolExecutionResult
+	return httprequest.New()
+}
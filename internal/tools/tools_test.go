@@ -1,13 +1,51 @@
 package tools
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/saurabh0719/kiwi/internal/checkpoint"
+	"github.com/saurabh0719/kiwi/internal/memory"
+	"github.com/saurabh0719/kiwi/internal/plan"
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
+	"github.com/saurabh0719/kiwi/internal/tools/httprequest"
+	"github.com/saurabh0719/kiwi/internal/tools/memorytool"
+	"github.com/saurabh0719/kiwi/internal/tools/plantool"
+	"github.com/saurabh0719/kiwi/internal/tools/sqlite"
+	"github.com/saurabh0719/kiwi/internal/tools/workspace"
 )
 
+// writeFiles creates files under dir, with their slash-separated names relative to it
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+}
+
 func TestFileSystemTool(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir, err := os.MkdirTemp("", "kiwi-test-*")
@@ -35,8 +73,8 @@ func TestFileSystemTool(t *testing.T) {
 	if err != nil {
 		t.Errorf("Execute(read) failed: %v", err)
 	}
-	if result.Output != testContent {
-		t.Errorf("Execute(read) content mismatch: got %q, want %q", result.Output, testContent)
+	if want := "     1\t" + testContent + "\n"; result.Output != want {
+		t.Errorf("Execute(read) content mismatch: got %q, want %q", result.Output, want)
 	}
 	// Verify the toolMethod is correctly set
 	if result.ToolMethod != "read" {
@@ -63,6 +101,725 @@ func TestFileSystemTool(t *testing.T) {
 	}
 }
 
+func TestFileSystemToolReadRange(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+
+	// Create a file with numbered lines
+	var content strings.Builder
+	for i := 1; i <= 100; i++ {
+		content.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	testFile := filepath.Join(tmpDir, "lines.txt")
+	if err := os.WriteFile(testFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Test reading a range of lines
+	args := map[string]interface{}{
+		"operation": "read",
+		"path":      testFile,
+		"offset":    float64(10),
+		"limit":     float64(3),
+	}
+	result, err := fsTool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute(read) failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Output, "    10\tline 10\n    11\tline 11\n    12\tline 12\n") {
+		t.Errorf("Execute(read) range mismatch, got %q", result.Output)
+	}
+	if !strings.Contains(result.Output, "Use offset=13 to read more") {
+		t.Errorf("Execute(read) should tell how to read more, got %q", result.Output)
+	}
+
+	// Test reading past the end of the file
+	args["offset"] = float64(500)
+	if _, err := fsTool.Execute(context.Background(), args); err == nil {
+		t.Error("Execute(read) with offset beyond end of file should fail")
+	}
+
+	// Test that large files are capped
+	bigFile := filepath.Join(tmpDir, "big.txt")
+	big := strings.Repeat(strings.Repeat("x", 100)+"\n", 5000)
+	if err := os.WriteFile(bigFile, []byte(big), 0644); err != nil {
+		t.Fatalf("Failed to create big file: %v", err)
+	}
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "read",
+		"path":      bigFile,
+	})
+	if err != nil {
+		t.Fatalf("Execute(read) failed: %v", err)
+	}
+	if len(result.Output) > 70*1024 {
+		t.Errorf("Execute(read) output should be capped, got %d bytes", len(result.Output))
+	}
+	if !strings.Contains(result.Output, "of 5000") {
+		t.Errorf("Execute(read) should report total lines, got tail %q", result.Output[len(result.Output)-120:])
+	}
+
+	// Test that binary files are summarized instead of dumped
+	binFile := filepath.Join(tmpDir, "data.bin")
+	if err := os.WriteFile(binFile, []byte{0x89, 'P', 'N', 'G', 0x00, 0x01, 0x02, 0xff}, 0644); err != nil {
+		t.Fatalf("Failed to create binary file: %v", err)
+	}
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "read",
+		"path":      binFile,
+	})
+	if err != nil {
+		t.Fatalf("Execute(read) of binary file failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "binary file") || !strings.Contains(result.Output, "89 50 4e 47") {
+		t.Errorf("Execute(read) should hex-summarize binary files, got %q", result.Output)
+	}
+}
+
+func TestFileSystemToolEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+
+	testFile := filepath.Join(tmpDir, "main.go")
+	original := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"hello\")\n}\n"
+	if err := os.WriteFile(testFile, []byte(original), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// An ambiguous match must be rejected without touching the file
+	args := map[string]interface{}{
+		"operation":  "edit",
+		"path":       testFile,
+		"old_string": `println("hello")`,
+		"new_string": `println("bye")`,
+	}
+	if _, err := fsTool.Execute(context.Background(), args); err == nil || !strings.Contains(err.Error(), "matches 2 times") {
+		t.Errorf("Execute(edit) should reject ambiguous matches, got %v", err)
+	}
+
+	// The preview shows the diff without applying it
+	previewer, ok := fsTool.(core.DiffPreviewer)
+	if !ok {
+		t.Fatal("filesystem tool should implement DiffPreviewer")
+	}
+	args["replace_all"] = true
+	diff, err := previewer.PreviewDiff(args)
+	if err != nil {
+		t.Fatalf("PreviewDiff(edit) failed: %v", err)
+	}
+	if !strings.Contains(diff, "-\tprintln(\"hello\")") || !strings.Contains(diff, "+\tprintln(\"bye\")") {
+		t.Errorf("PreviewDiff(edit) unexpected diff:\n%s", diff)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != original {
+		t.Error("PreviewDiff(edit) must not modify the file")
+	}
+
+	// Edits need confirmation in safe mode, reads don't
+	checker := fsTool.(core.ConfirmationChecker)
+	if !checker.NeedsConfirmation(args) {
+		t.Error("edit should need confirmation in safe mode")
+	}
+	if checker.NeedsConfirmation(map[string]interface{}{"operation": "read", "path": testFile}) {
+		t.Error("read should not need confirmation")
+	}
+
+	// A batch of edits is applied in order
+	args = map[string]interface{}{
+		"operation": "edit",
+		"path":      testFile,
+		"edits": []interface{}{
+			map[string]interface{}{"old_string": `println("hello")`, "new_string": `println("bye")`, "replace_all": true},
+			map[string]interface{}{"old_string": "func main()", "new_string": "func run()"},
+		},
+	}
+	result, err := fsTool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute(edit) failed: %v", err)
+	}
+	if result.ToolMethod != "edit" {
+		t.Errorf("Execute(edit) toolMethod mismatch: got %q, want %q", result.ToolMethod, "edit")
+	}
+	want := "package main\n\nfunc run() {\n\tprintln(\"bye\")\n\tprintln(\"bye\")\n}\n"
+	if data, _ := os.ReadFile(testFile); string(data) != want {
+		t.Errorf("Execute(edit) content mismatch: got %q, want %q", data, want)
+	}
+}
+
+func TestFileSystemToolApplyPatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+
+	testFile := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(testFile, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// The hunk header is off by one line, the context should still locate it
+	patch := `--- a/notes.txt
++++ b/notes.txt
+@@ -3,3 +3,3 @@
+ two
+-three
++THREE
+ four
+`
+	result, err := fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "apply_patch",
+		"path":      testFile,
+		"patch":     patch,
+	})
+	if err != nil {
+		t.Fatalf("Execute(apply_patch) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "+1 -1") {
+		t.Errorf("Execute(apply_patch) should summarize the change, got %q", result.Output)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "one\ntwo\nTHREE\nfour\nfive\n" {
+		t.Errorf("Execute(apply_patch) content mismatch: got %q", data)
+	}
+
+	// A patch whose context doesn't match must fail
+	_, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "apply_patch",
+		"path":      testFile,
+		"patch":     "@@ -1,2 +1,2 @@\n missing\n-line\n+LINE\n",
+	})
+	if err == nil {
+		t.Error("Execute(apply_patch) with mismatched context should fail")
+	}
+
+	// The preview reports why the patch can't be applied, and has nothing to say about other operations
+	previewer := fsTool.(core.DiffPreviewer)
+	if _, err := previewer.PreviewDiff(map[string]interface{}{
+		"operation": "apply_patch",
+		"path":      testFile,
+		"patch":     "@@ -1,2 +1,2 @@\n missing\n-line\n+LINE\n",
+	}); err == nil {
+		t.Error("PreviewDiff(apply_patch) with mismatched context should fail")
+	}
+	if diff, err := previewer.PreviewDiff(map[string]interface{}{"operation": "list"}); diff != "" || err != nil {
+		t.Errorf("PreviewDiff(list) = %q, %v, want no preview", diff, err)
+	}
+}
+
+func TestFileSystemToolSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+
+	files := map[string]string{
+		".gitignore":          "build/\n*.log\n",
+		"main.go":             "package main\n\n// TODO: handle errors\nfunc main() {}\n",
+		"pkg/util/util.go":    "package util\n\nfunc Helper() {}\n// todo: tests\n",
+		"pkg/util/util.py":    "# TODO python\n",
+		"build/generated.go":  "// TODO generated\n",
+		"debug.log":           "TODO in a log\n",
+		"docs/guide/intro.md": "# Intro\n",
+	}
+	writeFiles(t, tmpDir, files)
+
+	// Glob matches names at any depth and skips ignored directories
+	result, err := fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "glob",
+		"path":      tmpDir,
+		"pattern":   "*.go",
+	})
+	if err != nil {
+		t.Fatalf("Execute(glob) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "main.go") || !strings.Contains(result.Output, filepath.Join("pkg", "util", "util.go")) {
+		t.Errorf("Execute(glob) missing expected files, got %q", result.Output)
+	}
+	if strings.Contains(result.Output, "generated.go") {
+		t.Errorf("Execute(glob) should respect .gitignore, got %q", result.Output)
+	}
+
+	// Glob with a path pattern
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "glob",
+		"path":      tmpDir,
+		"pattern":   "docs/**/*.md",
+	})
+	if err != nil {
+		t.Fatalf("Execute(glob) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "intro.md") {
+		t.Errorf("Execute(glob) should match ** patterns, got %q", result.Output)
+	}
+
+	// Grep is case sensitive by default and skips ignored files
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "grep",
+		"path":      tmpDir,
+		"pattern":   "TODO",
+	})
+	if err != nil {
+		t.Fatalf("Execute(grep) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "main.go:3:// TODO: handle errors") {
+		t.Errorf("Execute(grep) should report file:line matches, got %q", result.Output)
+	}
+	if strings.Contains(result.Output, "todo: tests") || strings.Contains(result.Output, "generated") || strings.Contains(result.Output, "debug.log") {
+		t.Errorf("Execute(grep) returned unexpected matches, got %q", result.Output)
+	}
+
+	// Case-insensitive grep filtered by file type, with context
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation":        "grep",
+		"path":             tmpDir,
+		"pattern":          "todo",
+		"case_insensitive": true,
+		"file_type":        "go",
+		"context_lines":    float64(1),
+	})
+	if err != nil {
+		t.Fatalf("Execute(grep) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "util.go:4:// todo: tests") || !strings.Contains(result.Output, "util.go-3-func Helper() {}") {
+		t.Errorf("Execute(grep) should include context lines, got %q", result.Output)
+	}
+	if strings.Contains(result.Output, "util.py") {
+		t.Errorf("Execute(grep) should filter by file type, got %q", result.Output)
+	}
+
+	// Results are capped
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation":        "grep",
+		"path":             tmpDir,
+		"pattern":          "todo",
+		"case_insensitive": true,
+		"max_results":      float64(1),
+	})
+	if err != nil {
+		t.Fatalf("Execute(grep) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "Stopped after 1 matches") {
+		t.Errorf("Execute(grep) should stop at max_results, got %q", result.Output)
+	}
+
+	// Long lines are truncated without splitting a multi-byte character
+	writeFiles(t, tmpDir, map[string]string{"min.js": "LONG" + strings.Repeat("a", 495) + "é" + strings.Repeat("b", 100) + "\n"})
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "grep",
+		"path":      tmpDir,
+		"pattern":   "LONG",
+	})
+	if err != nil {
+		t.Fatalf("Execute(grep) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "a ... [line truncated]") || !utf8.ValidString(result.Output) {
+		t.Errorf("Execute(grep) should truncate long lines at a character boundary, got %q", result.Output)
+	}
+
+	// Searching outside the workspace is refused
+	if _, err := fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "grep",
+		"path":      "/etc",
+		"pattern":   "root",
+	}); err == nil {
+		t.Error("Execute(grep) outside the workspace should fail")
+	}
+}
+
+func TestFileSystemToolTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+
+	files := map[string]string{
+		".kiwiignore":               "secrets/\n",
+		"README.md":                 "# Project\n",
+		"src/app/main.go":           "package main\n",
+		"src/app/deep/inner/x.go":   "package inner\n",
+		"node_modules/pkg/index.js": "module.exports = {}\n",
+		"secrets/key.txt":           "hunter2\n",
+	}
+	writeFiles(t, tmpDir, files)
+
+	result, err := fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "tree",
+		"path":      tmpDir,
+		"depth":     float64(2),
+	})
+	if err != nil {
+		t.Fatalf("Execute(tree) failed: %v", err)
+	}
+	if result.ToolMethod != "tree" {
+		t.Errorf("Execute(tree) toolMethod mismatch: got %q, want %q", result.ToolMethod, "tree")
+	}
+
+	// Directories below the depth limit are counted but not walked, so counts above them are lower bounds
+	if !strings.Contains(result.Output, "src/ (at least 1 file, 2 dirs") {
+		t.Errorf("Execute(tree) should report recursive counts for src, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "app/ (at least 1 file, 1 dir") || strings.Contains(result.Output, "main.go") {
+		t.Errorf("Execute(tree) should stop expanding at the depth limit, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "README.md (10 B,") {
+		t.Errorf("Execute(tree) should report file sizes, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "node_modules/ [dependencies, not expanded]") || strings.Contains(result.Output, "index.js") {
+		t.Errorf("Execute(tree) should not expand node_modules, got:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "secrets") {
+		t.Errorf("Execute(tree) should honor .kiwiignore, got:\n%s", result.Output)
+	}
+
+	// Symlinked directories are listed, not followed, so a link to a parent can't loop
+	if err := os.Symlink(tmpDir, filepath.Join(tmpDir, "src", "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	result, err = fsTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "tree",
+		"path":      tmpDir,
+		"depth":     float64(20),
+	})
+	if err != nil {
+		t.Fatalf("Execute(tree) with a symlink loop failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "loop (") || strings.Count(result.Output, "README.md") != 1 {
+		t.Errorf("Execute(tree) should list the link without following it, got:\n%s", result.Output)
+	}
+}
+
+func TestFileSystemToolManipulation(t *testing.T) {
+	tmpDir := t.TempDir()
+	fsTool := NewFileSystemTool()
+	ctx := context.Background()
+
+	// Write creates missing parent directories
+	nested := filepath.Join(tmpDir, "a", "b", "note.txt")
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation": "write",
+		"path":      nested,
+		"content":   "hello",
+	}); err != nil {
+		t.Fatalf("Execute(write) into a missing directory failed: %v", err)
+	}
+	if data, err := os.ReadFile(nested); err != nil || string(data) != "hello" {
+		t.Errorf("Execute(write) content mismatch: got %q, %v", data, err)
+	}
+
+	// Mkdir creates directories recursively
+	dir := filepath.Join(tmpDir, "x", "y", "z")
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{"operation": "mkdir", "path": dir}); err != nil {
+		t.Fatalf("Execute(mkdir) failed: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("Execute(mkdir) did not create %s", dir)
+	}
+
+	// Copy a directory tree
+	copied := filepath.Join(tmpDir, "copy")
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a"),
+		"destination": copied,
+	}); err != nil {
+		t.Fatalf("Execute(copy) failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(copied, "b", "note.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("Execute(copy) content mismatch: got %q, %v", data, err)
+	}
+
+	// Copy refuses to overwrite unless asked to
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a"),
+		"destination": copied,
+	}); err == nil {
+		t.Error("Execute(copy) onto an existing destination should fail without overwrite")
+	}
+
+	// Copying a directory into itself is rejected
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a"),
+		"destination": filepath.Join(tmpDir, "a", "inner"),
+	}); err == nil {
+		t.Error("Execute(copy) of a directory into itself should fail")
+	}
+
+	// Move a file into a new directory
+	moved := filepath.Join(tmpDir, "moved", "note.txt")
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "move",
+		"path":        nested,
+		"destination": moved,
+	}); err != nil {
+		t.Fatalf("Execute(move) failed: %v", err)
+	}
+	if _, err := os.Stat(nested); !os.IsNotExist(err) {
+		t.Error("Execute(move) should remove the source")
+	}
+	if data, err := os.ReadFile(moved); err != nil || string(data) != "hello" {
+		t.Errorf("Execute(move) content mismatch: got %q, %v", data, err)
+	}
+
+	// Moving with overwrite replaces a directory instead of merging into it
+	if err := os.WriteFile(filepath.Join(copied, "stale.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "move",
+		"path":        filepath.Join(tmpDir, "x"),
+		"destination": copied,
+		"overwrite":   true,
+	}); err != nil {
+		t.Fatalf("Execute(move) with overwrite failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(copied, "stale.txt")); !os.IsNotExist(err) {
+		t.Error("Execute(move) with overwrite should replace the destination directory")
+	}
+	if _, err := os.Stat(filepath.Join(copied, "y", "z")); err != nil {
+		t.Errorf("Execute(move) with overwrite should move the source: %v", err)
+	}
+
+	// Copying a directory leaves out entries denied by the workspace policy
+	if err := os.WriteFile(filepath.Join(tmpDir, "a", ".env"), []byte("TOKEN=1"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	result, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a"),
+		"destination": filepath.Join(tmpDir, "copy2"),
+	})
+	if err != nil {
+		t.Fatalf("Execute(copy) failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "copy2", ".env")); !os.IsNotExist(err) || !strings.Contains(result.Output, "Skipped entries denied by the workspace policy: .env") {
+		t.Errorf("Execute(copy) should skip denied entries, got:\n%s", result.Output)
+	}
+
+	// Copying with overwrite replaces a directory instead of merging into it
+	if err := os.WriteFile(filepath.Join(tmpDir, "copy2", "stale.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a"),
+		"destination": filepath.Join(tmpDir, "copy2"),
+		"overwrite":   true,
+	}); err != nil {
+		t.Fatalf("Execute(copy) with overwrite failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "copy2", "stale.txt")); !os.IsNotExist(err) {
+		t.Error("Execute(copy) with overwrite should replace the destination directory")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "copy2", "b")); err != nil {
+		t.Errorf("Execute(copy) with overwrite should copy the source: %v", err)
+	}
+
+	// Overwriting a directory that contains the source would delete the source
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(tmpDir, "a", "b"),
+		"destination": filepath.Join(tmpDir, "a"),
+		"overwrite":   true,
+	}); err == nil {
+		t.Error("Execute(copy) over a directory containing the source should fail")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "a", "b")); err != nil {
+		t.Errorf("the source should be kept: %v", err)
+	}
+
+	// Stat reports type and size
+	result, err = fsTool.Execute(ctx, map[string]interface{}{"operation": "stat", "path": moved})
+	if err != nil {
+		t.Fatalf("Execute(stat) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "type: file") || !strings.Contains(result.Output, "size: 5 bytes") {
+		t.Errorf("Execute(stat) unexpected output: %q", result.Output)
+	}
+
+	// Every operation is subject to the path safety check
+	outside := []map[string]interface{}{
+		{"operation": "mkdir", "path": "/kiwi-outside-workspace"},
+		{"operation": "stat", "path": "/etc/passwd"},
+		{"operation": "copy", "path": moved, "destination": "/kiwi-outside-workspace"},
+		{"operation": "move", "path": "/etc/hostname", "destination": filepath.Join(tmpDir, "hostname")},
+	}
+	for _, args := range outside {
+		if _, err := fsTool.Execute(ctx, args); err == nil {
+			t.Errorf("Execute(%s) outside the workspace should fail: %v", args["operation"], args)
+		}
+	}
+}
+
+func TestWorkspacePolicy(t *testing.T) {
+	workDir := t.TempDir()
+	docsDir := t.TempDir()
+	outsideDir := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(workDir, "main.go"):        "package main\n",
+		filepath.Join(workDir, ".env"):           "SECRET=1\n",
+		filepath.Join(workDir, ".git", "config"): "[core]\n",
+		filepath.Join(docsDir, "guide.md"):       "# Guide\n",
+		filepath.Join(outsideDir, "secret.txt"):  "secret\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(workDir, "escape")); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	policy := workspace.New([]string{workDir}, []string{docsDir}, workspace.DefaultDeny)
+	previous := workspace.Current()
+	workspace.SetCurrent(policy)
+	defer workspace.SetCurrent(previous)
+
+	checks := []struct {
+		path   string
+		access workspace.Access
+		ok     bool
+	}{
+		{filepath.Join(workDir, "main.go"), workspace.Write, true},
+		{filepath.Join(workDir, "new", "file.go"), workspace.Write, true},
+		{filepath.Join(docsDir, "guide.md"), workspace.Read, true},
+		{filepath.Join(docsDir, "guide.md"), workspace.Write, false},
+		{filepath.Join(workDir, ".env"), workspace.Read, false},
+		{filepath.Join(workDir, ".env.local"), workspace.Read, false},
+		{filepath.Join(workDir, "config", ".env.production"), workspace.Write, false},
+		{filepath.Join(workDir, "env.go"), workspace.Write, true},
+		{filepath.Join(workDir, ".git", "config"), workspace.Read, false},
+		{filepath.Join(outsideDir, "secret.txt"), workspace.Read, false},
+		{filepath.Join(workDir, "escape", "secret.txt"), workspace.Read, false},
+		{filepath.Join(workDir, "escape", "new.txt"), workspace.Write, false},
+		{filepath.Join(workDir, "..", filepath.Base(outsideDir), "secret.txt"), workspace.Read, false},
+	}
+	for _, check := range checks {
+		if _, err := policy.Check(check.path, check.access); (err == nil) != check.ok {
+			t.Errorf("Check(%s, %v) error = %v, want allowed = %v", check.path, check.access, err, check.ok)
+		}
+	}
+
+	fsTool := NewFileSystemTool()
+	ctx := context.Background()
+
+	// Reads through a symlink that leaves the workspace are rejected
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation": "read",
+		"path":      filepath.Join(workDir, "escape", "secret.txt"),
+	}); err == nil {
+		t.Error("Execute(read) through a symlink out of the workspace should fail")
+	}
+
+	// Read-only roots can be read but not written
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{"operation": "read", "path": filepath.Join(docsDir, "guide.md")}); err != nil {
+		t.Errorf("Execute(read) in a read-only root failed: %v", err)
+	}
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation": "write",
+		"path":      filepath.Join(docsDir, "guide.md"),
+		"content":   "changed",
+	}); err == nil {
+		t.Error("Execute(write) in a read-only root should fail")
+	}
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "copy",
+		"path":        filepath.Join(docsDir, "guide.md"),
+		"destination": filepath.Join(workDir, "guide.md"),
+	}); err != nil {
+		t.Errorf("Execute(copy) out of a read-only root failed: %v", err)
+	}
+	if _, err := fsTool.Execute(ctx, map[string]interface{}{
+		"operation":   "move",
+		"path":        filepath.Join(docsDir, "guide.md"),
+		"destination": filepath.Join(workDir, "moved.md"),
+	}); err == nil {
+		t.Error("Execute(move) out of a read-only root should fail")
+	}
+
+	// Searches skip denied files and symlinks that leave the workspace
+	result, err := fsTool.Execute(ctx, map[string]interface{}{"operation": "grep", "path": workDir, "pattern": "SECRET|secret|core"})
+	if err != nil {
+		t.Fatalf("Execute(grep) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "No matches") {
+		t.Errorf("Execute(grep) should not search denied or escaped files, got %q", result.Output)
+	}
+}
+
+func TestFileSystemToolCheckpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := checkpoint.NewStoreAt(filepath.Join(tmpDir, "checkpoints"))
+	workDir := filepath.Join(tmpDir, "work")
+	defer checkpoint.ClearActive()
+
+	fsTool := NewFileSystemTool()
+	ctx := context.Background()
+	run := func(turn int, args map[string]interface{}) {
+		t.Helper()
+		checkpoint.SetActive(store, "session_test", turn)
+		if _, err := fsTool.Execute(ctx, args); err != nil {
+			t.Fatalf("Execute(%s) in turn %d failed: %v", args["operation"], turn, err)
+		}
+	}
+	readFile := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(workDir, name))
+		if err != nil {
+			return "<missing>"
+		}
+		return string(data)
+	}
+
+	// Turn 1 creates two files, turn 2 edits one and deletes the other, turn 3 adds a directory
+	run(1, map[string]interface{}{"operation": "write", "path": filepath.Join(workDir, "a.txt"), "content": "one\n"})
+	run(1, map[string]interface{}{"operation": "write", "path": filepath.Join(workDir, "b.txt"), "content": "bee\n"})
+	run(2, map[string]interface{}{"operation": "edit", "path": filepath.Join(workDir, "a.txt"), "old_string": "one", "new_string": "two"})
+	run(2, map[string]interface{}{"operation": "write", "path": filepath.Join(workDir, "a.txt"), "content": "three\n"})
+	run(2, map[string]interface{}{"operation": "delete", "path": filepath.Join(workDir, "b.txt")})
+	run(3, map[string]interface{}{"operation": "mkdir", "path": filepath.Join(workDir, "sub", "dir")})
+	run(3, map[string]interface{}{"operation": "move", "path": filepath.Join(workDir, "a.txt"), "destination": filepath.Join(workDir, "sub", "a.txt")})
+
+	turns, err := store.Turns("session_test")
+	if err != nil || len(turns) != 3 {
+		t.Fatalf("Turns() = %d turns, %v; want 3", len(turns), err)
+	}
+	if len(turns[1].Files) != 2 {
+		t.Errorf("turn 2 should record each file once, got %+v", turns[1].Files)
+	}
+
+	// Undo reverts the last turn only
+	if _, err := store.Undo("session_test"); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+	if got := readFile("a.txt"); got != "three\n" {
+		t.Errorf("after undo a.txt = %q, want %q", got, "three\n")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "sub")); !os.IsNotExist(err) {
+		t.Error("after undo the directory created in turn 3 should be gone")
+	}
+
+	// Rewinding to turn 1 reverts everything after it, in order
+	undone, err := store.Rewind("session_test", 1)
+	if err != nil || len(undone) != 1 {
+		t.Fatalf("Rewind(1) undid %d turns, %v; want 1", len(undone), err)
+	}
+	if got := readFile("a.txt"); got != "one\n" {
+		t.Errorf("after rewind a.txt = %q, want %q", got, "one\n")
+	}
+	if got := readFile("b.txt"); got != "bee\n" {
+		t.Errorf("after rewind b.txt = %q, want %q", got, "bee\n")
+	}
+
+	// Rewinding to turn 0 restores the workspace to before the session
+	if _, err := store.Rewind("session_test", 0); err != nil {
+		t.Fatalf("Rewind(0) failed: %v", err)
+	}
+	if readFile("a.txt") != "<missing>" || readFile("b.txt") != "<missing>" {
+		t.Error("after rewinding to turn 0 the files created in turn 1 should be gone")
+	}
+	if _, err := store.Undo("session_test"); err == nil {
+		t.Error("Undo() with no checkpoints left should fail")
+	}
+}
+
 func TestShellTool(t *testing.T) {
 	shellTool := NewShellTool()
 
@@ -113,8 +870,12 @@ func TestSystemInfoTool(t *testing.T) {
 		t.Errorf("Execute(memory) failed: %v", err)
 	}
 
-	// Check if memory information is included
-	if !strings.Contains(result.Output, "alloc:") || !strings.Contains(result.Output, "sys:") {
+	// Check if host memory information is included, other systems report kiwi's own usage
+	if runtime.GOOS == "linux" {
+		if !strings.Contains(result.Output, "total:") || !strings.Contains(result.Output, "available:") {
+			t.Error("Execute(memory) missing required fields in output:", result.Output)
+		}
+	} else if !strings.Contains(result.Output, "alloc:") || !strings.Contains(result.Output, "sys:") {
 		t.Error("Execute(memory) missing required fields in output:", result.Output)
 	}
 	// Verify the toolMethod is correctly set
@@ -123,29 +884,255 @@ func TestSystemInfoTool(t *testing.T) {
 	}
 }
 
-func TestRegistry(t *testing.T) {
-	registry := NewRegistry()
+func TestHTTPTool(t *testing.T) {
+	var gotMethod, gotAuth, gotBody, gotQuery, gotType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotAuth, gotBody = r.Method, r.Header.Get("Authorization"), string(body)
+		gotQuery, gotType = r.URL.RawQuery, r.Header.Get("Content-Type")
 
-	// Test registering tools
-	fsTool := NewFileSystemTool()
-	shellTool := NewShellTool()
-	sysInfo := NewSystemInfoTool()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		// Echo the token to check that it gets redacted
+		fmt.Fprintf(w, `{"id":7,"auth":%q}`, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
 
-	registry.Register(fsTool)
-	registry.Register(shellTool)
-	registry.Register(sysInfo)
+	previous := egress.Current()
+	egress.SetCurrent(egress.New(nil, nil, true, 0))
+	defer egress.SetCurrent(previous)
 
-	// Test getting tools
-	if tool, ok := registry.Get("filesystem"); !ok || tool == nil {
-		t.Error("Failed to get filesystem tool")
+	tool := httprequest.New()
+	tool.SetSecrets(map[string]string{"API_Token": "s3cr3t-value"})
+	ctx := context.Background()
+
+	// GET with query parameters and a secret header
+	result, err := tool.Execute(ctx, map[string]interface{}{
+		"url":     server.URL + "/items?page=2",
+		"query":   map[string]interface{}{"limit": float64(10)},
+		"headers": map[string]interface{}{"Authorization": "Bearer {{secret:api_token}}"},
+	})
+	if err != nil {
+		t.Fatalf("Execute(GET) failed: %v", err)
 	}
-	if tool, ok := registry.Get("shell"); !ok || tool == nil {
-		t.Error("Failed to get shell tool")
+	if gotMethod != "GET" || gotAuth != "Bearer s3cr3t-value" || gotQuery != "limit=10&page=2" {
+		t.Errorf("unexpected request: %s auth=%q query=%q", gotMethod, gotAuth, gotQuery)
 	}
-	if tool, ok := registry.Get("sysinfo"); !ok || tool == nil {
-		t.Error("Failed to get sysinfo tool")
+	for _, want := range []string{"200 OK", "Content-Type: application/json", "X-RateLimit-Remaining: 41", "{\n  \"id\": 7,"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("Execute(GET) output should contain %q, got:\n%s", want, result.Output)
+		}
 	}
-
+	if strings.Contains(result.Output, "s3cr3t-value") || strings.Contains(strings.Join(result.ToolExecutionSteps, "\n"), "s3cr3t-value") {
+		t.Errorf("secret values should never be returned, got:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "Set-Cookie") {
+		t.Error("only selected headers should be returned")
+	}
+
+	// POST with a JSON body that references a secret
+	_, err = tool.Execute(ctx, map[string]interface{}{
+		"method": "post",
+		"url":    server.URL + "/items",
+		"json":   map[string]interface{}{"name": "kiwi", "token": "{{secret:api_token}}"},
+	})
+	if err != nil {
+		t.Fatalf("Execute(POST) failed: %v", err)
+	}
+	if gotMethod != "POST" || gotType != "application/json" || gotBody != `{"name":"kiwi","token":"s3cr3t-value"}` {
+		t.Errorf("unexpected request: %s %s %s", gotMethod, gotType, gotBody)
+	}
+
+	// Form bodies are URL-encoded
+	if _, err := tool.Execute(ctx, map[string]interface{}{
+		"method": "PUT",
+		"url":    server.URL + "/items/7",
+		"form":   map[string]interface{}{"name": "kiwi bird"},
+	}); err != nil {
+		t.Fatalf("Execute(PUT) failed: %v", err)
+	}
+	if gotType != "application/x-www-form-urlencoded" || gotBody != "name=kiwi+bird" {
+		t.Errorf("unexpected form request: %s %s", gotType, gotBody)
+	}
+
+	// Error statuses are returned, not treated as failures
+	result, err = tool.Execute(ctx, map[string]interface{}{"url": server.URL + "/missing"})
+	if err != nil || !strings.Contains(result.Output, "404 Not Found") || !strings.Contains(result.Output, `"error": "not found"`) {
+		t.Errorf("Execute(404) should return the response, got %v:\n%s", err, result.Output)
+	}
+
+	// Headers carrying secrets are dropped when a redirect leaves the host
+	redirectedKey := "not redirected"
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirectedKey = r.Header.Get("X-API-Key")
+	}))
+	defer other.Close()
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(other.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer redirecting.Close()
+	if _, err := tool.Execute(ctx, map[string]interface{}{
+		"url":     redirecting.URL,
+		"headers": map[string]interface{}{"X-API-Key": "{{secret:api_token}}"},
+	}); err != nil {
+		t.Fatalf("Execute(redirect) failed: %v", err)
+	}
+	if redirectedKey != "" {
+		t.Errorf("secret header should not follow a redirect to another host, got %q", redirectedKey)
+	}
+
+	// Invalid calls
+	invalid := []map[string]interface{}{
+		{"url": server.URL, "headers": map[string]interface{}{"Authorization": "{{secret:unknown}}"}},
+		{"url": server.URL, "method": "TRACE"},
+		{"url": "file:///etc/passwd"},
+		{"url": server.URL, "method": "POST", "json": map[string]interface{}{}, "body": "x"},
+	}
+	for _, args := range invalid {
+		if _, err := tool.Execute(ctx, args); err == nil {
+			t.Errorf("Execute(%v) should fail", args)
+		}
+	}
+
+	// Only requests that can change something or use secrets need confirmation
+	if tool.NeedsConfirmation(map[string]interface{}{"url": server.URL}) {
+		t.Error("GET requests should not need confirmation")
+	}
+	if !tool.NeedsConfirmation(map[string]interface{}{"url": server.URL, "query": map[string]interface{}{"key": "{{secret:api_token}}"}}) {
+		t.Error("requests using secrets should need confirmation")
+	}
+	for _, method := range []string{"POST", "put", "DELETE", "PATCH"} {
+		if !tool.NeedsConfirmation(map[string]interface{}{"url": server.URL, "method": method}) {
+			t.Errorf("%s requests should need confirmation", method)
+		}
+	}
+}
+
+func TestEgressPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			// Same server under a name the policy doesn't trust
+			http.Redirect(w, r, strings.Replace(r.Host, "127.0.0.1", "http://localhost", 1)+"/admin", http.StatusFound)
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 2048)))
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	previous := egress.Current()
+	defer egress.SetCurrent(previous)
+	tool := httprequest.New()
+	ctx := context.Background()
+	get := func(target string) (string, error) {
+		result, err := tool.Execute(ctx, map[string]interface{}{"url": target})
+		return result.Output, err
+	}
+
+	// Private, loopback and link-local addresses are blocked by default
+	egress.SetCurrent(egress.Default())
+	for _, target := range []string{server.URL, "http://169.254.169.254/latest/meta-data/", "http://[::1]:8080/"} {
+		if _, err := get(target); err == nil || !strings.Contains(err.Error(), "request blocked") {
+			t.Errorf("request to %s should be blocked, got %v", target, err)
+		}
+	}
+
+	// Hosts resolving to private addresses are checked when connecting
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if _, err := get(localhost); err == nil || !strings.Contains(err.Error(), "resolves to") {
+		t.Errorf("request to %s should be blocked after resolving, got %v", localhost, err)
+	}
+
+	// Trusted hosts are reachable, but redirects are checked again
+	policy := egress.Default()
+	policy.Trust("127.0.0.1")
+	egress.SetCurrent(policy)
+	if output, err := get(server.URL); err != nil || !strings.HasSuffix(output, "ok") {
+		t.Errorf("request to a trusted host should succeed, got %v", err)
+	}
+	if _, err := get(server.URL + "/redirect"); err == nil || !strings.Contains(err.Error(), "request blocked") {
+		t.Errorf("redirect to an untrusted private host should be blocked, got %v", err)
+	}
+
+	// Allow and deny lists, domains match their subdomains
+	egress.SetCurrent(egress.New([]string{"127.0.0.0/8", "docs.example.org"}, []string{"example.com"}, false, 1024))
+	checks := []struct {
+		target string
+		ok     bool
+	}{
+		{"https://docs.example.org/guide", true},
+		{"https://api.docs.example.org/", true},
+		{"https://example.org/", false},
+		{"https://www.example.com/", false},
+		{"ftp://docs.example.org/", false},
+	}
+	for _, check := range checks {
+		u, _ := url.Parse(check.target)
+		if err := egress.Current().CheckURL(u); (err == nil) != check.ok {
+			t.Errorf("CheckURL(%s) = %v, want allowed %v", check.target, err, check.ok)
+		}
+	}
+
+	// Address ranges on the allow list are reachable, and responses are capped
+	if _, err := get(server.URL); err != nil {
+		t.Errorf("request to an allowed range should succeed, got %v", err)
+	}
+	if _, err := get(server.URL + "/large"); err == nil || !strings.Contains(err.Error(), "limit") {
+		t.Errorf("response over the size limit should fail, got %v", err)
+	}
+
+	// New hosts are confirmed once per session
+	prompts := map[string]int{}
+	policy = egress.New(nil, nil, true, 0)
+	policy.SetConfirm(func(host string) bool {
+		prompts[host]++
+		return host == "127.0.0.1"
+	})
+	egress.SetCurrent(policy)
+	for i := 0; i < 2; i++ {
+		if _, err := get(server.URL); err != nil {
+			t.Errorf("request to an approved host should succeed, got %v", err)
+		}
+		if _, err := get(localhost); err == nil || !strings.Contains(err.Error(), "declined") {
+			t.Errorf("request to a declined host should fail, got %v", err)
+		}
+	}
+	if prompts["127.0.0.1"] != 1 || prompts["localhost"] != 1 {
+		t.Errorf("each host should be confirmed once, got %v", prompts)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	// Test registering tools
+	fsTool := NewFileSystemTool()
+	shellTool := NewShellTool()
+	sysInfo := NewSystemInfoTool()
+
+	registry.Register(fsTool)
+	registry.Register(shellTool)
+	registry.Register(sysInfo)
+
+	// Test getting tools
+	if tool, ok := registry.Get("filesystem"); !ok || tool == nil {
+		t.Error("Failed to get filesystem tool")
+	}
+	if tool, ok := registry.Get("shell"); !ok || tool == nil {
+		t.Error("Failed to get shell tool")
+	}
+	if tool, ok := registry.Get("sysinfo"); !ok || tool == nil {
+		t.Error("Failed to get sysinfo tool")
+	}
+
 	// Test getting non-existent tool
 	if tool, ok := registry.Get("nonexistent"); ok || tool != nil {
 		t.Error("Got non-existent tool")
@@ -157,3 +1144,996 @@ func TestRegistry(t *testing.T) {
 		t.Error("GetToolsDescription returned empty string")
 	}
 }
+
+func TestTruncateOutput(t *testing.T) {
+	var result core.ToolExecutionResult
+	if got := result.TruncateOutput("short", "ask for less"); got != "short" || len(result.ToolExecutionSteps) != 0 {
+		t.Errorf("short output should be kept as is, got %q with steps %v", got, result.ToolExecutionSteps)
+	}
+
+	// A multi-byte character straddles the limit and must not be cut in half
+	output := strings.Repeat("a", core.MaxOutput-1) + "é" + strings.Repeat("b", 10)
+	got := result.TruncateOutput(output, "ask for less")
+	if !utf8.ValidString(got) {
+		t.Error("truncated output should be valid UTF-8")
+	}
+	if !strings.HasPrefix(got, strings.Repeat("a", core.MaxOutput-1)+"\n\n") {
+		t.Error("output should be cut before the split character")
+	}
+	if !strings.HasSuffix(got, "[Output truncated at 20000 characters, ask for less]") {
+		t.Errorf("truncated output should end with the note, got %q", got[len(got)-80:])
+	}
+	if len(result.ToolExecutionSteps) != 1 {
+		t.Errorf("truncating should add a step, got %v", result.ToolExecutionSteps)
+	}
+}
+
+func TestGitTool(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "Ada")
+	t.Setenv("GIT_AUTHOR_EMAIL", "ada@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Ada")
+	t.Setenv("GIT_COMMITTER_EMAIL", "ada@example.com")
+	if output, err := exec.Command("git", "init", "-q", "-b", "main", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+
+	gitTool := NewGitTool()
+	run := func(args map[string]interface{}) string {
+		t.Helper()
+		args["repo"] = dir
+		result, err := gitTool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%v) failed: %v", args, err)
+		}
+		return result.Output
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("notes.txt", "todo\n")
+	if output := run(map[string]interface{}{"operation": "status"}); !strings.Contains(output, "Untracked (2):\n  main.go\n  notes.txt") {
+		t.Errorf("status should list untracked files, got:\n%s", output)
+	}
+
+	output := run(map[string]interface{}{"operation": "add", "paths": []interface{}{"main.go"}})
+	if !strings.Contains(output, "Staged (1):\n  added: main.go") || !strings.Contains(output, "Untracked (1):\n  notes.txt") {
+		t.Errorf("add should stage only the given paths, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "commit", "message": "Add main"}); !strings.Contains(output, "Add main") {
+		t.Errorf("commit should report the new commit, got:\n%s", output)
+	}
+
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	output = run(map[string]interface{}{"operation": "diff", "paths": []interface{}{"main.go"}})
+	if !strings.Contains(output, "main.go | ") || !strings.Contains(output, "+\tprintln(\"hi\")") {
+		t.Errorf("diff should show the stat and patch, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "diff", "staged": true}); output != "No staged changes" {
+		t.Errorf("diff of the index should be empty, got:\n%s", output)
+	}
+	run(map[string]interface{}{"operation": "commit", "message": "Print a greeting", "all": true})
+
+	output = run(map[string]interface{}{"operation": "log", "max_count": 5})
+	if !strings.Contains(output, "Ada: Print a greeting (HEAD -> main)") || !strings.Contains(output, "Ada: Add main\n") {
+		t.Errorf("log should list the commits, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "show", "ref": "HEAD~1"}); !strings.Contains(output, "Add main") || !strings.Contains(output, "+package main") {
+		t.Errorf("show should show the commit and its patch, got:\n%s", output)
+	}
+	output = run(map[string]interface{}{"operation": "blame", "path": "main.go", "start_line": 3, "end_line": 4})
+	if !strings.Contains(output, "Ada") || !strings.Contains(output, "3| func main() {") || !strings.Contains(output, "4| \tprintln(\"hi\")") || strings.Contains(output, "package main") {
+		t.Errorf("blame should annotate the requested lines, got:\n%s", output)
+	}
+
+	run(map[string]interface{}{"operation": "checkout", "ref": "feature", "create": true})
+	output = run(map[string]interface{}{"operation": "branches"})
+	if !strings.Contains(output, "* feature") || !strings.Contains(output, "  main") {
+		t.Errorf("branches should mark the current branch, got:\n%s", output)
+	}
+
+	write("main.go", "package main\n")
+	if output := run(map[string]interface{}{"operation": "stash", "message": "wip"}); !strings.Contains(output, "wip") {
+		t.Errorf("stash should save the changes, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "status"}); !strings.Contains(output, "Branch: feature\nWorking tree clean") {
+		t.Errorf("status should be clean after stashing, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "stash", "action": "list"}); !strings.Contains(output, "stash@{0}") {
+		t.Errorf("stash list should list the stash, got:\n%s", output)
+	}
+	if output := run(map[string]interface{}{"operation": "stash", "action": "pop"}); !strings.Contains(output, "Not staged (1):\n  modified: main.go") {
+		t.Errorf("stash pop should restore the changes, got:\n%s", output)
+	}
+
+	// Programs named by the repository config are never run by read-only operations
+	if runtime.GOOS != "windows" {
+		marker := filepath.Join(t.TempDir(), "ran")
+		script := filepath.Join(t.TempDir(), "evil.sh")
+		if err := os.WriteFile(script, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		settings := [][]string{
+			{"diff.external", script}, {"diff.evil.textconv", script}, {"core.fsmonitor", script},
+			{"filter.evil.clean", script}, {"filter.evil.required", "true"}, {"log.showSignature", "true"}, {"gpg.program", script},
+		}
+		for _, setting := range settings {
+			if output, err := exec.Command("git", "-C", dir, "config", setting[0], setting[1]).CombinedOutput(); err != nil {
+				t.Fatalf("git config failed: %v\n%s", err, output)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".git", "info", "attributes"), []byte("* diff=evil filter=evil\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// A commit with a signature, which git verifies with gpg.program when log.showSignature is set
+		tree, _ := exec.Command("git", "-C", dir, "rev-parse", "HEAD^{tree}").Output()
+		commit := fmt.Sprintf("tree %s\nauthor Ada <ada@example.com> 0 +0000\ncommitter Ada <ada@example.com> 0 +0000\n"+
+			"gpgsig -----BEGIN PGP SIGNATURE-----\n \n -----END PGP SIGNATURE-----\n\nSigned\n", strings.TrimSpace(string(tree)))
+		hash := exec.Command("git", "-C", dir, "hash-object", "-t", "commit", "-w", "--stdin")
+		hash.Stdin = strings.NewReader(commit)
+		signed, err := hash.Output()
+		if err != nil {
+			t.Fatalf("git hash-object failed: %v", err)
+		}
+
+		for _, operation := range []string{"status", "diff", "show", "log"} {
+			run(map[string]interface{}{"operation": operation})
+		}
+		run(map[string]interface{}{"operation": "show", "ref": strings.TrimSpace(string(signed))})
+		run(map[string]interface{}{"operation": "blame", "path": "main.go"})
+		if _, err := os.Stat(marker); err == nil {
+			t.Error("read-only operations should not run programs from the repository config")
+		}
+		for _, setting := range settings {
+			exec.Command("git", "-C", dir, "config", "--unset", setting[0]).Run()
+		}
+	}
+
+	// Refs can't smuggle options into the command
+	if _, err := gitTool.Execute(context.Background(), map[string]interface{}{"operation": "log", "repo": dir, "ref": "--output=/tmp/pwned"}); err == nil {
+		t.Error("log should reject a ref starting with a dash")
+	}
+
+	checker := gitTool.(core.ConfirmationChecker)
+	for operation, want := range map[string]bool{"status": false, "diff": false, "log": false, "blame": false, "add": true, "commit": true, "checkout": true, "stash": true} {
+		if got := checker.NeedsConfirmation(map[string]interface{}{"operation": operation}); got != want {
+			t.Errorf("NeedsConfirmation(%s) = %v, want %v", operation, got, want)
+		}
+	}
+	if checker.NeedsConfirmation(map[string]interface{}{"operation": "stash", "action": "list"}) {
+		t.Error("NeedsConfirmation(stash list) should be false")
+	}
+}
+
+func TestCodeTool(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/shop\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+import "fmt"
+
+// Limit is the largest order accepted
+const Limit = 100
+
+// Base has the fields shared by every item
+type Base struct {
+	ID int
+}
+
+// Key identifies the item in the store
+func (b Base) Key() string { return fmt.Sprint(b.ID) }
+
+// Item is something for sale
+type Item struct {
+	Base
+	// Price in cents
+	Price int
+}
+
+// Total returns the price of n items
+func (i *Item) Total(n int) int {
+	return i.Price * n
+}
+
+// String describes the item
+func (i Item) String() string { return i.Key() }
+
+// Keyed is anything with a key
+type Keyed interface {
+	Key() string
+}
+`,
+		"store/store_test.go": `package store
+
+import "testing"
+
+func TestTotal(t *testing.T) {
+	item := &Item{Price: 2}
+	if item.Total(3) != 6 {
+		t.Fail()
+	}
+}
+`,
+		"cmd/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/shop/store"
+)
+
+func checkout(item *store.Item) int {
+	return item.Total(store.Limit)
+}
+
+func main() {
+	fmt.Println(checkout(&store.Item{Price: 5}))
+}
+`,
+	}
+	writeFiles(t, dir, files)
+
+	codeTool := NewCodeTool()
+	run := func(args map[string]interface{}) string {
+		t.Helper()
+		result, err := codeTool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%v) failed: %v", args, err)
+		}
+		return result.Output
+	}
+	storeDir := filepath.Join(dir, "store")
+
+	output := run(map[string]interface{}{"operation": "symbols", "path": storeDir, "exported": true})
+	for _, want := range []string{
+		"package store (example.com/shop/store)",
+		"const Limit untyped int = 100  // store/store.go:6",
+		"type Item struct (2 fields)",
+		"func (*Item).Total(n int) int  // store/store.go:24",
+		"type Keyed interface (1 methods)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("symbols should contain %q, got:\n%s", want, output)
+		}
+	}
+
+	// Symbols of other packages are found from anywhere in the module
+	output = run(map[string]interface{}{"operation": "definition", "path": filepath.Join(dir, "cmd"), "symbol": "Item.Total"})
+	if !strings.Contains(output, "store/store.go:24") || !strings.Contains(output, "// Total returns the price of n items\nfunc (i *Item) Total(n int) int {\n\treturn i.Price * n\n}") {
+		t.Errorf("definition should show the doc comment and source, got:\n%s", output)
+	}
+	output = run(map[string]interface{}{"operation": "definition", "path": storeDir, "symbol": "Item.Price"})
+	if !strings.Contains(output, "\t// Price in cents\n\tPrice int") {
+		t.Errorf("definition of a field should show the field, got:\n%s", output)
+	}
+
+	output = run(map[string]interface{}{"operation": "references", "path": storeDir, "symbol": "(*Item).Total"})
+	for _, want := range []string{
+		"2 in 2 files",
+		"10 in checkout: return item.Total(store.Limit)",
+		"7 in TestTotal: if item.Total(3) != 6 {",
+		"checkout (cmd/main.go)",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("references should contain %q, got:\n%s", want, output)
+		}
+	}
+
+	output = run(map[string]interface{}{"operation": "methods", "path": storeDir, "symbol": "Item"})
+	for _, want := range []string{
+		"func (Base).Key() string [promoted from Base]",
+		"func (*Item).Total(n int) int [pointer receiver]",
+		"Keyed\n",
+		"fmt.Stringer\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("methods should contain %q, got:\n%s", want, output)
+		}
+	}
+	output = run(map[string]interface{}{"operation": "methods", "path": storeDir, "symbol": "Keyed"})
+	if !strings.Contains(output, "Implemented by (2):\n  Base  // store/store.go:9\n  Item") {
+		t.Errorf("methods of an interface should list its implementations, got:\n%s", output)
+	}
+
+	if _, err := codeTool.Execute(context.Background(), map[string]interface{}{"operation": "definition", "path": storeDir, "symbol": "Missing"}); err == nil {
+		t.Error("definition of an unknown symbol should fail")
+	}
+
+	// With only the package in the workspace, the module above it is not loaded
+	previous := workspace.Current()
+	workspace.SetCurrent(workspace.New([]string{storeDir}, nil, nil))
+	defer workspace.SetCurrent(previous)
+	result, err := codeTool.Execute(context.Background(), map[string]interface{}{"operation": "symbols", "path": storeDir})
+	if err != nil {
+		t.Fatalf("symbols inside a narrow workspace failed: %v", err)
+	}
+	if steps := strings.Join(result.ToolExecutionSteps, "\n"); strings.Contains(steps, "example.com/shop") {
+		t.Errorf("the module above the workspace should not be used, got steps:\n%s", steps)
+	}
+}
+
+func TestTestTool(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/shop\n\ngo 1.21\n",
+		"calc/calc.go": "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if got := Add(2, 2); got != 5 {
+		t.Errorf("Add(2, 2) = %d, want 5", got)
+	}
+}
+
+func TestAddZero(t *testing.T) {
+	if Add(0, 0) != 0 {
+		t.Fail()
+	}
+}
+`,
+	}
+	writeFiles(t, dir, files)
+
+	testTool := NewTestTool()
+	if !testTool.RequiresConfirmation() {
+		t.Error("test tool should require confirmation, tests run the code of the project")
+	}
+
+	result, err := testTool.Execute(context.Background(), map[string]interface{}{"path": dir})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	for _, want := range []string{
+		"FAILED: 1 passed, 1 failed, 0 skipped",
+		"Command: go test -json ./...",
+		"--- calc.TestAdd (calc/calc_test.go:7)\n    calc_test.go:7: Add(2, 2) = 4, want 5",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output should contain %q, got:\n%s", want, result.Output)
+		}
+	}
+
+	result, err = testTool.Execute(context.Background(), map[string]interface{}{"path": filepath.Join(dir, "calc"), "run": "TestAddZero"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !strings.HasPrefix(result.Output, "PASSED: 1 passed, 0 failed, 0 skipped") {
+		t.Errorf("running a single test should pass, got:\n%s", result.Output)
+	}
+
+	if _, err := testTool.Execute(context.Background(), map[string]interface{}{"path": dir, "target": "-exec=sh"}); err == nil {
+		t.Error("a target starting with a dash should be rejected")
+	}
+}
+
+func TestTestToolFrameworks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake test runners are shell scripts")
+	}
+
+	// Fake go, pytest and npx print or write the report named by KIWI_FAKE_REPORT, with
+	// the paths of the project the fixtures were recorded in replaced by their own directory
+	bin := t.TempDir()
+	replace := `sed "s|/home/dev/shop|$(pwd -P)|g" "$KIWI_FAKE_REPORT"`
+	scripts := map[string]string{
+		"go":     replace + "\nexit 1\n",
+		"pytest": "for arg; do case \"$arg\" in --junitxml=*) " + replace + " > \"${arg#--junitxml=}\";; esac; done\nexit 1\n",
+		"npx":    replace + " > \"$JEST_JUNIT_OUTPUT_DIR/$JEST_JUNIT_OUTPUT_NAME\"\nexit 1\n",
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	fixture := func(name string) {
+		path, err := filepath.Abs(filepath.Join("testrunner", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("KIWI_FAKE_REPORT", path)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                     "module example.com/shop\n",
+		"web/package.json":           `{"devDependencies": {"jest": "^29.0.0"}}`,
+		"web/src/cart.js":            "",
+		"scripts/pyproject.toml":     "[tool.pytest.ini_options]\n",
+		"scripts/tests/test_cart.py": "",
+		"docs/README.md":             "",
+	})
+	previous := workspace.Current()
+	defer workspace.SetCurrent(previous)
+	workspace.SetCurrent(workspace.New([]string{dir}, nil, nil))
+
+	testTool := NewTestTool()
+	run := func(args map[string]interface{}) core.ToolExecutionResult {
+		t.Helper()
+		result, err := testTool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%v) failed: %v", args, err)
+		}
+		return result
+	}
+	contains := func(result core.ToolExecutionResult, wants ...string) {
+		t.Helper()
+		for _, want := range wants {
+			if !strings.Contains(result.Output+strings.Join(result.ToolExecutionSteps, "\n"), want) {
+				t.Errorf("result should contain %q, got:\n%s\nsteps: %v", want, result.Output, result.ToolExecutionSteps)
+			}
+		}
+	}
+
+	// Go: failing subtests replace their parents, panics keep the panic without the framing lines
+	fixture("go.json")
+	result := run(map[string]interface{}{"path": dir})
+	contains(result,
+		"Detected go project at "+dir,
+		"FAILED: 2 passed, 3 failed, 1 skipped",
+		"Failures (2):",
+		"--- boom.TestPanic (boom/boom_test.go:7)",
+		"panic: assignment to entry in nil map",
+		"--- calc.TestTable/bad (calc/calc_test.go:18)\n    calc_test.go:18: Add(2, 2) = 4, want 5\n",
+		"broken/broken.go:3:28: undefined: undefined",
+	)
+	if strings.Contains(result.Output, "--- FAIL") || strings.Contains(result.Output, "--- calc.TestTable (") {
+		t.Errorf("go report should leave out framing lines and failing parents, got:\n%s", result.Output)
+	}
+
+	// pytest runs in its project root, limited to path, with errors reported as failures
+	fixture("pytest.xml")
+	result = run(map[string]interface{}{"path": filepath.Join(dir, "scripts", "tests"), "target": "test_cart.py::test_total"})
+	contains(result,
+		"Detected pytest project at "+filepath.Join(dir, "scripts"),
+		" tests/test_cart.py::test_total\n",
+		"FAILED: 2 passed, 2 failed, 1 skipped",
+		"--- tests.test_cart::test_total (tests/test_cart.py:10)",
+		"E       assert 5 == 6",
+		"--- tests.test_orders::test_load (tests/conftest.py:8)",
+	)
+
+	// jest failures are located in the test file rather than the stack of the framework
+	fixture("jest.xml")
+	result = run(map[string]interface{}{"path": filepath.Join(dir, "web", "src")})
+	contains(result,
+		"Detected jest project at "+filepath.Join(dir, "web"),
+		"--ci --reporters=default --reporters=jest-junit src\n",
+		"FAILED: 1 passed, 1 failed, 1 skipped",
+		"--- cart computes the total (src/cart.test.js:12)",
+	)
+
+	// A broken report is an error
+	broken := filepath.Join(t.TempDir(), "broken.xml")
+	if err := os.WriteFile(broken, []byte("not xml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KIWI_FAKE_REPORT", broken)
+	if _, err := testTool.Execute(context.Background(), map[string]interface{}{"path": filepath.Join(dir, "scripts")}); err == nil {
+		t.Error("a broken report should fail")
+	}
+
+	// Projects above the workspace root are not used
+	workspace.SetCurrent(workspace.New([]string{filepath.Join(dir, "docs")}, nil, nil))
+	if _, err := testTool.Execute(context.Background(), map[string]interface{}{"path": filepath.Join(dir, "docs")}); err == nil || !strings.Contains(err.Error(), "no go.mod") {
+		t.Errorf("detection should stop at the workspace root, got %v", err)
+	}
+	workspace.SetCurrent(workspace.New([]string{filepath.Join(dir, "scripts", "tests")}, nil, nil))
+	fixture("pytest.xml")
+	result = run(map[string]interface{}{"path": filepath.Join(dir, "scripts", "tests"), "framework": "pytest"})
+	if !strings.Contains(result.Output, ".xml\n") {
+		t.Errorf("pytest should run in path without a target when its project is outside of the workspace, got:\n%s", result.Output)
+	}
+}
+
+func TestSQLiteTool(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "shop.db")
+	if err := os.WriteFile(db, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writer := sqlite.New()
+	writer.SetAllowWrites(true)
+	setup := `CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+		INSERT INTO customers (name) VALUES ('Ada'), ('Grace'), ('Linus')`
+	if _, err := writer.Execute(context.Background(), map[string]interface{}{"operation": "query", "database": db, "sql": setup}); err == nil {
+		t.Error("multiple statements should be rejected")
+	}
+	for _, statement := range []string{
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+		"INSERT INTO customers (name) VALUES ('Ada'), ('Grace'), ('Linus')",
+		"CREATE VIEW first_customers AS SELECT * FROM customers WHERE id < 3",
+	} {
+		args := map[string]interface{}{"operation": "query", "database": db, "sql": statement}
+		if !writer.NeedsConfirmation(args) {
+			t.Errorf("write statement %q should need confirmation", statement)
+		}
+		if _, err := writer.Execute(context.Background(), args); err != nil {
+			t.Fatalf("Execute(%q) failed: %v", statement, err)
+		}
+	}
+
+	sqliteTool := NewSQLiteTool()
+	if sqliteTool.RequiresConfirmation() {
+		t.Error("sqlite tool should not require confirmation for reads")
+	}
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string
+	}{
+		{"tables", map[string]interface{}{"operation": "tables", "database": db}, []string{"shop.db: 1 tables, 1 views", "customers", "first_customers"}},
+		{"schema", map[string]interface{}{"operation": "schema", "database": db, "table": "customers"}, []string{"CREATE TABLE customers", "NOT NULL"}},
+		{"query", map[string]interface{}{"operation": "query", "database": db, "sql": "SELECT name FROM customers ORDER BY id"}, []string{"name", "Ada", "Linus", "3 rows"}},
+		{"limit", map[string]interface{}{"operation": "query", "database": db, "sql": "SELECT name FROM customers ORDER BY id", "limit": 2}, []string{"Grace", "first 2 rows"}},
+		{"json", map[string]interface{}{"operation": "query", "database": db, "sql": "SELECT id, name FROM first_customers", "format": "json"}, []string{`"id": 1`, `"name": "Ada"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sqliteTool.Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result.Output, want) {
+					t.Errorf("output should contain %q, got:\n%s", want, result.Output)
+				}
+			}
+		})
+	}
+
+	remove := map[string]interface{}{"operation": "query", "database": db, "sql": "DELETE FROM customers"}
+	if sqliteTool.(core.ConfirmationChecker).NeedsConfirmation(remove) {
+		t.Error("write statements should be rejected, not confirmed, when writes are disabled")
+	}
+	if _, err := sqliteTool.Execute(context.Background(), remove); err == nil {
+		t.Error("write statements should be rejected when writes are disabled")
+	}
+	hidden := map[string]interface{}{"operation": "query", "database": db, "sql": "WITH gone AS (SELECT 1) DELETE FROM customers"}
+	if _, err := sqliteTool.Execute(context.Background(), hidden); err == nil {
+		t.Error("a write behind a WITH clause should fail on a read-only connection")
+	}
+}
+
+func TestDataTool(t *testing.T) {
+	dataTool := NewDataTool()
+	if dataTool.RequiresConfirmation() {
+		t.Error("data tool should not require confirmation, it only reads files")
+	}
+
+	// query runs an expression and returns the matched values as compact JSON
+	query := func(path, expression string) (string, error) {
+		t.Helper()
+		result, err := dataTool.Execute(context.Background(), map[string]interface{}{"operation": "query", "path": path, "expression": expression})
+		if err != nil || result.Output == "No matches" {
+			return "", err
+		}
+		var parts []string
+		decoder := json.NewDecoder(strings.NewReader(result.Output))
+		for decoder.More() {
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				t.Fatalf("%s: output is not JSON: %v\n%s", expression, err, result.Output)
+			}
+			data, _ := json.Marshal(value)
+			parts = append(parts, string(data))
+		}
+		return strings.Join(parts, " "), nil
+	}
+
+	orders := filepath.Join("data", "testdata", "orders.json")
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{".store", `"north"`},
+		{".orders[0].customer.name", `"Ada"`},
+		{".orders[-1].id", `1003`},
+		{".orders[5]", `null`},
+		{".missing.deeper", `null`},
+		{`.orders[2]."gift-wrap"`, `true`},
+		{`.orders[2].gift-wrap`, `true`},
+		{`.orders[1]["coupon"]`, `"SPRING"`},
+		{".orders[].id", `1001 1002 1003`},
+		{".orders[1:].id?", ``},
+		{"[.orders[1:][].id]", `[1002,1003]`},
+		{".store[1:3]", `"or"`},
+		{".orders | length", `3`},
+		{`.orders[] | select(.status == "shipped") | .id`, `1001 1003`},
+		{`.orders[] | select(.total > 40 and .status != "pending") | .id`, `1001`},
+		{`.orders[] | select(.total < 10 or .coupon) | .id`, `1002 1003`},
+		{".orders[] | select(has(\"coupon\")) | .customer.name", `"Grace"`},
+		{".orders[] | select(.customer.name | test(\"^[AG]\")) | .id", `1001 1002`},
+		{".orders[0] | {id, who: .customer.name}", `{"id":1001,"who":"Ada"}`},
+		{`.orders[0] | {(.status): .total}`, `{"shipped":42.5}`},
+		{".orders[0] | .id, .status", `1001 "shipped"`},
+		{"[.orders[].status] | unique", `["pending","shipped"]`},
+		{"[.orders[].total] | add", `169.75`},
+		{"[.orders[].items[].qty] | add", `4`},
+		{"[.orders[].total] | max", `120`},
+		{".orders | map(.id) | sort | first", `1001`},
+		{".orders | sort_by(.total) | map(.customer.name)", `["Linus","Ada","Grace"]`},
+		{".orders[0] | keys", `["customer","id","items","status","total"]`},
+		{".orders[0].customer | to_entries | map(.key)", `["email","name"]`},
+		{".orders[] | .coupon // \"none\"", `"none" "SPRING" "none"`},
+		{"[.. | .sku? | select(. != null)]", `["KB-01","MS-02","MN-27"]`},
+		{".orders[0].total | type", `"number"`},
+		{".orders[2].items | length == 0", `true`},
+		{"[.orders[] | .total >= 42.5 | not]", `[false,false,true]`},
+		{"[]", `[]`},
+	}
+	for _, tt := range tests {
+		got, err := query(orders, tt.expression)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expression, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.expression, got, tt.want)
+		}
+	}
+
+	// YAML, TOML and CSV are detected from the extension
+	formats := []struct {
+		file       string
+		expression string
+		want       string
+	}{
+		{"services.yaml", ".[0].services.api.ports[0]", `8080`},
+		{"services.yaml", ".[1].name", `"overrides"`},
+		{"config.toml", ".server.port", `8080`},
+		{"config.toml", ".released", `"2024-05-02"`},
+		{"config.toml", "[.backends[] | select(.weight > 1) | .name]", `["primary"]`},
+		{"users.csv", ".[0]", `{"active":true,"id":7,"name":"Ada","score":9.5,"zip":"01234"}`},
+		{"users.csv", "[.[] | select(.score > 8) | .name]", `["Ada"]`},
+	}
+	for _, tt := range formats {
+		got, err := query(filepath.Join("data", "testdata", tt.file), tt.expression)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.file, tt.expression, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s %s = %s, want %s", tt.file, tt.expression, got, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		expression string
+		want       string
+	}{
+		{".orders.id", `cannot index array with "id"`},
+		{".store[]", "cannot iterate over string"},
+		{".orders[", "expected"},
+		{".orders | lenght", "unknown function"},
+		{`.store == "north`, "unterminated string"},
+		{"select", "select takes an argument"},
+		{".orders[0] | {(.id): 1}", "object keys must be strings"},
+		{".orders[] | .id &", `unexpected "&"`},
+	}
+	for _, tt := range invalid {
+		if _, err := query(orders, tt.expression); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.expression, err, tt.want)
+		}
+	}
+
+	// Matches are returned as indented JSON
+	result, err := dataTool.Execute(context.Background(), map[string]interface{}{"operation": "query", "path": orders, "expression": ".orders[0] | {id, who: .customer.name}"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if want := "{\n  \"id\": 1001,\n  \"who\": \"Ada\"\n}"; result.Output != want {
+		t.Errorf("query output = %q, want %q", result.Output, want)
+	}
+	result, err = dataTool.Execute(context.Background(), map[string]interface{}{"operation": "query", "path": orders, "expression": ".orders[] | select(.id == 0)"})
+	if err != nil || result.Output != "No matches" {
+		t.Errorf("a query without matches should say so, got %q, %v", result.Output, err)
+	}
+
+	// The schema describes the structure without the values
+	result, err = dataTool.Execute(context.Background(), map[string]interface{}{"operation": "schema", "path": orders})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	want := `. : object
+  .orders: array[3] of object
+    .orders[].customer: object
+      .orders[].customer.email: string|null
+      .orders[].customer.name: string
+    .orders[].id: integer
+    .orders[].items: array[0-2] of object
+      .orders[].items[].qty: integer
+      .orders[].items[].sku: string
+    .orders[].status: string
+    .orders[].total: integer|number
+    .orders[].coupon: string (in 1 of 3)
+    .orders[].gift-wrap: boolean (in 1 of 3)
+  .store: string
+  .updated: string`
+	if result.Output != want {
+		t.Errorf("schema =\n%s\nwant\n%s", result.Output, want)
+	}
+	result, err = dataTool.Execute(context.Background(), map[string]interface{}{"operation": "schema", "path": orders, "expression": ".orders[2].items"})
+	if err != nil || result.Output != ". : array[0]" {
+		t.Errorf("schema of an empty array = %q, %v", result.Output, err)
+	}
+
+	if _, err := dataTool.Execute(context.Background(), map[string]interface{}{"operation": "query", "path": filepath.Join(t.TempDir(), "notes.json")}); err == nil {
+		t.Error("a missing file should fail")
+	}
+}
+
+func TestArchiveTool(t *testing.T) {
+	dir := t.TempDir()
+
+	// A release tarball with a link that must not be followed when extracting
+	tarball := filepath.Join(dir, "release.tar.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tarEntries := []struct {
+		header  tar.Header
+		content string
+	}{
+		{tar.Header{Name: "release/", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "release/README.md", Typeflag: tar.TypeReg, Mode: 0644}, "# Release\n"},
+		{tar.Header{Name: "release/bin/run.sh", Typeflag: tar.TypeReg, Mode: 0755}, "#!/bin/sh\necho run\n"},
+		{tar.Header{Name: "release/passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}, ""},
+	}
+	for _, e := range tarEntries {
+		header := e.header
+		header.Size = int64(len(e.content))
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(tarball, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	archiveTool := NewArchiveTool()
+	checker := archiveTool.(core.ConfirmationChecker)
+	if checker.NeedsConfirmation(map[string]interface{}{"operation": "list"}) || !checker.NeedsConfirmation(map[string]interface{}{"operation": "extract"}) {
+		t.Error("only extract should need confirmation")
+	}
+
+	result, err := archiveTool.Execute(context.Background(), map[string]interface{}{"operation": "list", "path": tarball})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	for _, want := range []string{"release.tar.gz: tar.gz, 2 files, 1 directories, 1 links", "release/bin/run.sh", "release/passwd -> /etc/passwd"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("list should contain %q, got:\n%s", want, result.Output)
+		}
+	}
+
+	result, err = archiveTool.Execute(context.Background(), map[string]interface{}{"operation": "read", "path": tarball, "entry": "./release/README.md"})
+	if err != nil || result.Output != "# Release\n" {
+		t.Errorf("read = %q, %v", result.Output, err)
+	}
+
+	result, err = archiveTool.Execute(context.Background(), map[string]interface{}{"operation": "extract", "path": tarball})
+	if err != nil {
+		t.Fatalf("extract failed: %v", err)
+	}
+	script := filepath.Join(dir, "release", "release", "bin", "run.sh")
+	if content, err := os.ReadFile(script); err != nil || string(content) != "#!/bin/sh\necho run\n" {
+		t.Errorf("extracted script = %q, %v", content, err)
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "release", "release", "passwd")); !os.IsNotExist(err) {
+		t.Error("links should not be extracted")
+	}
+	if !strings.Contains(result.Output, "Extracted 2 files and 1 directories") || !strings.Contains(result.Output, "Skipped 1 entries") {
+		t.Errorf("unexpected extract output:\n%s", result.Output)
+	}
+
+	// Extracting again must not replace the files without overwrite
+	if err := os.WriteFile(script, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := archiveTool.Execute(context.Background(), map[string]interface{}{"operation": "extract", "path": tarball}); err == nil || !strings.Contains(err.Error(), "already exist") {
+		t.Errorf("extracting over existing files should fail, got %v", err)
+	}
+	if content, _ := os.ReadFile(script); string(content) != "changed" {
+		t.Error("a refused extraction should not write anything")
+	}
+	_, err = archiveTool.Execute(context.Background(), map[string]interface{}{
+		"operation": "extract", "path": tarball, "entry": "release/bin", "destination": filepath.Join(dir, "release"), "overwrite": true,
+	})
+	if err != nil {
+		t.Fatalf("extract with overwrite failed: %v", err)
+	}
+	if content, _ := os.ReadFile(script); string(content) != "#!/bin/sh\necho run\n" {
+		t.Error("overwrite should replace existing files")
+	}
+
+	// A zip with an entry escaping the destination must not extract anything
+	evil := filepath.Join(dir, "evil.zip")
+	buf.Reset()
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"ok.txt", "../../escaped.txt"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("data"))
+	}
+	zw.Close()
+	if err := os.WriteFile(evil, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = archiveTool.Execute(context.Background(), map[string]interface{}{"operation": "extract", "path": evil, "destination": filepath.Join(dir, "out")})
+	if err == nil || !strings.Contains(err.Error(), "outside of the archive") {
+		t.Errorf("zip slip should be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "ok.txt")); !os.IsNotExist(err) {
+		t.Error("a refused extraction should not write anything")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("zip slip entry was written outside of the destination")
+	}
+}
+
+func TestMemoryTool(t *testing.T) {
+	store := memory.NewAt(t.TempDir())
+	memoryTool := memorytool.New()
+	memoryTool.SetStore(store)
+	project := memory.CurrentProject()
+
+	execute := func(args map[string]interface{}) string {
+		t.Helper()
+		result, err := memoryTool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%v) failed: %v", args, err)
+		}
+		return result.Output
+	}
+
+	execute(map[string]interface{}{"operation": "save", "content": "Run make lint, not golangci-lint directly"})
+	execute(map[string]interface{}{"operation": "save", "content": "Prefer   table-driven tests", "scope": "global"})
+	// Saving a known fact again doesn't duplicate it
+	execute(map[string]interface{}{"operation": "save", "content": "run make lint, not golangci-lint directly"})
+
+	projectMemories, err := store.List(project)
+	if err != nil || len(projectMemories) != 1 {
+		t.Fatalf("project should have 1 memory, got %v, %v", projectMemories, err)
+	}
+	global, err := store.List("")
+	if err != nil || len(global) != 1 || global[0].Content != "Prefer table-driven tests" {
+		t.Fatalf("global memories = %v, %v", global, err)
+	}
+
+	output := execute(map[string]interface{}{"operation": "list"})
+	if !strings.Contains(output, "Run make lint, not golangci-lint directly (project") || !strings.Contains(output, "Prefer table-driven tests (global") {
+		t.Errorf("list should show project and global memories, got:\n%s", output)
+	}
+
+	output = execute(map[string]interface{}{"operation": "search", "query": "LINT"})
+	if !strings.Contains(output, "make lint") || strings.Contains(output, "table-driven") {
+		t.Errorf("search should only find the lint memory, got:\n%s", output)
+	}
+
+	// A new session sees the project memories first, then the global ones
+	prompt := store.Prompt(project)
+	lint, tests := strings.Index(prompt, "make lint"), strings.Index(prompt, "table-driven")
+	if lint < 0 || tests < lint || !strings.Contains(prompt, "Project "+project+":") {
+		t.Errorf("unexpected system prompt section:\n%s", prompt)
+	}
+	if other := store.Prompt(filepath.Join(t.TempDir(), "other")); strings.Contains(other, "make lint") {
+		t.Errorf("other projects should not see the memories of this one:\n%s", other)
+	}
+
+	execute(map[string]interface{}{"operation": "delete", "id": "[" + projectMemories[0].ID + "]"})
+	if remaining, _ := store.List(project); len(remaining) != 0 {
+		t.Errorf("memory should be deleted, got %v", remaining)
+	}
+	if _, err := memoryTool.Execute(context.Background(), map[string]interface{}{"operation": "delete", "id": "nope"}); err == nil {
+		t.Error("deleting an unknown memory should fail")
+	}
+	// Memories of other projects are out of reach of the tool
+	other, err := store.Add(filepath.Join(t.TempDir(), "other"), "Other projects use tabs")
+	if err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if _, err := memoryTool.Execute(context.Background(), map[string]interface{}{"operation": "delete", "id": other.ID}); err == nil {
+		t.Error("deleting a memory of another project should fail")
+	}
+	if _, err := store.DeleteAny(other.ID); err != nil {
+		t.Errorf("DeleteAny() should delete memories of any project: %v", err)
+	}
+
+	// Everything that changes what later sessions are told is confirmed
+	for operation, want := range map[string]bool{"save": true, "delete": true, "list": false, "search": false} {
+		if got := memoryTool.NeedsConfirmation(map[string]interface{}{"operation": operation}); got != want {
+			t.Errorf("NeedsConfirmation(%s) = %v, want %v", operation, got, want)
+		}
+	}
+	if _, err := memoryTool.Execute(context.Background(), map[string]interface{}{"operation": "save", "content": strings.Repeat("x", memory.MaxContentLength+1)}); err == nil {
+		t.Error("long memories should be rejected")
+	}
+}
+
+func TestPlanTool(t *testing.T) {
+	var saved *plan.Plan
+	plan.SetActive(nil, func(p *plan.Plan) error {
+		saved = p.Clone()
+		return nil
+	})
+	defer plan.ClearActive()
+	planTool := plantool.New()
+
+	execute := func(args map[string]interface{}) core.ToolExecutionResult {
+		t.Helper()
+		result, err := planTool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("Execute(%v) failed: %v", args, err)
+		}
+		return result
+	}
+
+	if _, err := planTool.Execute(context.Background(), map[string]interface{}{"operation": "update", "item": 1, "status": "done"}); err == nil {
+		t.Error("updating before creating a plan should fail")
+	}
+
+	result := execute(map[string]interface{}{
+		"operation": "create",
+		"goal":      "Set up CI",
+		"items":     []interface{}{"Add workflow", "Add tests", " "},
+	})
+	if saved == nil || len(saved.Items) != 2 || saved.Goal != "Set up CI" {
+		t.Fatalf("created plan should be saved, got %+v", saved)
+	}
+	if !strings.Contains(result.Display, "Plan: Set up CI (0/2 done)") || !strings.Contains(result.Display, "[ ] Add workflow") {
+		t.Errorf("unexpected checklist:\n%s", result.Display)
+	}
+
+	execute(map[string]interface{}{"operation": "update", "item": 1, "status": "done"})
+	result = execute(map[string]interface{}{"operation": "update", "item": 2, "status": "in-progress"})
+	if !strings.Contains(result.Display, "[x] Add workflow") || !strings.Contains(result.Display, "[>] Add tests") || !strings.Contains(result.Display, "(1/2 done)") {
+		t.Errorf("checklist should show progress, got:\n%s", result.Display)
+	}
+	if saved.Items[1].Status != plan.InProgress {
+		t.Errorf("update should be saved, got %+v", saved.Items)
+	}
+
+	execute(map[string]interface{}{"operation": "add", "items": []interface{}{"Fix lint"}})
+	result = execute(map[string]interface{}{"operation": "show"})
+	if !strings.Contains(result.Output, "3. [ ] Fix lint (pending)") || !strings.Contains(result.Output, "1 of 3 items done") {
+		t.Errorf("unexpected plan:\n%s", result.Output)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"operation": "update", "item": 4, "status": "done"},
+		{"operation": "update", "item": 1, "status": "maybe"},
+		{"operation": "create", "items": []interface{}{}},
+	} {
+		if _, err := planTool.Execute(context.Background(), args); err == nil {
+			t.Errorf("Execute(%v) should fail", args)
+		}
+	}
+	if len(saved.Items) != 3 {
+		t.Errorf("failed operations should not change the plan, got %+v", saved.Items)
+	}
+}
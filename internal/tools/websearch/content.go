@@ -0,0 +1,291 @@
+package websearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// maxVisitResponse caps the size of a visited document
+	maxVisitResponse = 10 * 1024 * 1024
+
+	// maxJSONItems is the number of array elements kept when formatting JSON
+	maxJSONItems = 50
+
+	// maxJSONString is the length at which JSON string values are cut
+	maxJSONString = 1000
+
+	// maxFeedItems is the number of entries kept from an RSS or Atom feed
+	maxFeedItems = 50
+)
+
+// acceptHeader lists the content types the visit method can read
+const acceptHeader = "text/html,application/xhtml+xml,application/json;q=0.9,application/xml;q=0.9,text/markdown;q=0.9,application/pdf;q=0.8,text/*;q=0.8,*/*;q=0.5"
+
+// extractContent converts a response body to text based on its content type.
+// HTML is converted to Markdown and prefixed with its title.
+func extractContent(body []byte, contentType string, base *url.URL) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		// Servers often send raw files without a useful type, so sniff the content instead
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		title, content := extractMarkdown(string(body), base)
+		if title != "" {
+			content = "# " + title + "\n\n" + content
+		}
+		return content, nil
+
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return formatJSON(body), nil
+
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return formatXML(body), nil
+
+	case mediaType == "application/pdf":
+		return extractPDFText(body)
+
+	case strings.HasPrefix(mediaType, "text/"):
+		// Markdown, plain text and source files are already readable
+		return strings.TrimSpace(string(body)), nil
+
+	default:
+		return "", fmt.Errorf("unsupported content type: %s", contentType)
+	}
+}
+
+// formatJSON pretty-prints a JSON document, keeping the order of object keys and
+// trimming long arrays and strings. Invalid JSON is returned as is.
+func formatJSON(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var output strings.Builder
+	if err := writeJSONValue(&output, decoder, ""); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+	return output.String()
+}
+
+// writeJSONValue writes the next value of the decoder, indented by indent
+func writeJSONValue(output *strings.Builder, decoder *json.Decoder, indent string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return writeJSONScalar(output, token)
+	}
+
+	closing := "}"
+	if delim == '[' {
+		closing = "]"
+	}
+	output.WriteString(delim.String())
+
+	count := 0
+	for decoder.More() {
+		if delim == '[' && count >= maxJSONItems {
+			// Consume the rest of the array without writing it
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return err
+			}
+			count++
+			continue
+		}
+		if count > 0 {
+			output.WriteString(",")
+		}
+		count++
+
+		output.WriteString("\n" + indent + "  ")
+		if delim == '{' {
+			key, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			writeJSONScalar(output, key)
+			output.WriteString(": ")
+		}
+		if err := writeJSONValue(output, decoder, indent+"  "); err != nil {
+			return err
+		}
+	}
+	if delim == '[' && count > maxJSONItems {
+		output.WriteString(fmt.Sprintf("\n%s  ... %d more items", indent, count-maxJSONItems))
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	if count > 0 {
+		output.WriteString("\n" + indent)
+	}
+	output.WriteString(closing)
+	return nil
+}
+
+// writeJSONScalar writes a string, number, boolean or null
+func writeJSONScalar(output *strings.Builder, token json.Token) error {
+	if s, ok := token.(string); ok && len(s) > maxJSONString {
+		token = fmt.Sprintf("%s... (%d more characters)", s[:maxJSONString], len(s)-maxJSONString)
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	output.Write(data)
+	return nil
+}
+
+// feed is an RSS 2.0, RSS 1.0 or Atom feed
+type feed struct {
+	XMLName  xml.Name
+	Title    string     `xml:"title"`
+	Channel  *feed      `xml:"channel"`
+	Items    []feedItem `xml:"item"`
+	Entries  []feedItem `xml:"entry"`
+	Subtitle string     `xml:"subtitle"`
+	Desc     string     `xml:"description"`
+}
+
+// feedItem is an RSS item or Atom entry
+type feedItem struct {
+	Title     string     `xml:"title"`
+	Links     []feedLink `xml:"link"`
+	Published string     `xml:"pubDate"`
+	Date      string     `xml:"date"`
+	Updated   string     `xml:"updated"`
+	Summary   string     `xml:"summary"`
+	Desc      string     `xml:"description"`
+	Content   string     `xml:"content"`
+}
+
+// feedLink is an RSS link, which holds the URL as text, or an Atom link with an href
+type feedLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Text string `xml:",chardata"`
+}
+
+// formatXML lists the entries of RSS and Atom feeds. Other XML documents are returned as is.
+func formatXML(body []byte) string {
+	var doc feed
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return strings.TrimSpace(string(body))
+	}
+
+	switch doc.XMLName.Local {
+	case "rss":
+		if doc.Channel == nil {
+			break
+		}
+		return formatFeed(doc.Channel.Title, doc.Channel.Desc, doc.Channel.Items)
+	case "RDF":
+		// RSS 1.0 puts the items next to the channel
+		title, desc := "", ""
+		if doc.Channel != nil {
+			title, desc = doc.Channel.Title, doc.Channel.Desc
+		}
+		return formatFeed(title, desc, doc.Items)
+	case "feed":
+		return formatFeed(doc.Title, doc.Subtitle, doc.Entries)
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// formatFeed renders feed entries as a Markdown list
+func formatFeed(title, description string, items []feedItem) string {
+	var output strings.Builder
+	if title = cleanHTML(title); title != "" {
+		output.WriteString("# " + title + "\n\n")
+	}
+	if description = cleanHTML(description); description != "" {
+		output.WriteString(description + "\n\n")
+	}
+	output.WriteString(plural(len(items), "entry", "entries") + ":\n")
+
+	for i, item := range items {
+		if i == maxFeedItems {
+			output.WriteString(fmt.Sprintf("\n... %d more entries\n", len(items)-maxFeedItems))
+			break
+		}
+
+		heading := cleanHTML(item.Title)
+		if heading == "" {
+			heading = "(untitled)"
+		}
+		if link := item.link(); link != "" {
+			heading = fmt.Sprintf("[%s](%s)", heading, link)
+		}
+		output.WriteString(fmt.Sprintf("\n%d. %s\n", i+1, heading))
+
+		if date := firstNonEmpty(item.Published, item.Date, item.Updated); date != "" {
+			output.WriteString("   " + strings.TrimSpace(date) + "\n")
+		}
+		if summary := cleanHTML(firstNonEmpty(item.Desc, item.Summary, item.Content)); summary != "" {
+			if cut := strings.LastIndex(summary[:min(len(summary), 300)], " "); len(summary) > 300 && cut > 0 {
+				summary = summary[:cut] + "…"
+			}
+			output.WriteString("   " + summary + "\n")
+		}
+	}
+	return output.String()
+}
+
+// link returns the URL of an item, preferring Atom alternate links
+func (item feedItem) link() string {
+	for _, l := range item.Links {
+		if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+			return l.Href
+		}
+	}
+	for _, l := range item.Links {
+		if text := strings.TrimSpace(l.Text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// plural formats a count with the singular or plural form of a noun
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, pluralForm)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// readBody reads a response body up to maxVisitResponse
+func readBody(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxVisitResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxVisitResponse {
+		return nil, fmt.Errorf("response is larger than %d MB", maxVisitResponse/(1024*1024))
+	}
+	return data, nil
+}
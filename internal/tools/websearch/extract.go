@@ -0,0 +1,609 @@
+package websearch
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// unlikelyPattern matches class and id names of page chrome that is never the main content
+	unlikelyPattern = regexp.MustCompile(`(?i)\b(comments?|sidebar|footer|header|masthead|nav|navbar|menu|breadcrumbs?|advert|ads?|sponsor|social|share|sharing|cookie|consent|banner|popup|modal|related|newsletter|subscribe|promo|skip-link)\b`)
+
+	// positivePattern matches class and id names that usually hold the main content
+	positivePattern = regexp.MustCompile(`(?i)\b(article|content|main|post|entry|body|text|story|prose|markdown|documentation|docs)\b`)
+
+	// negativePattern matches class and id names that lower the score of a content candidate
+	negativePattern = regexp.MustCompile(`(?i)\b(comment|meta|footer|footnote|sidebar|widget|aside|promo|related|tags?|author)\b`)
+
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// skippedElements are removed before looking for the main content
+var skippedElements = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Form:     true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Footer:   true,
+	atom.Head:     true,
+}
+
+// extractMarkdown finds the main content of an HTML page and converts it to Markdown.
+// Relative links are resolved against base. It returns the page title and the content.
+func extractMarkdown(body string, base *url.URL) (string, string) {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return "", strings.TrimSpace(body)
+	}
+
+	title := pageTitle(doc)
+	if href := findBaseHref(doc); href != "" && base != nil {
+		if resolved, err := base.Parse(href); err == nil {
+			base = resolved
+		}
+	}
+
+	removeClutter(doc)
+	main := findMainContent(doc)
+
+	c := &mdConverter{base: base}
+	content := cleanMarkdown(c.children(main))
+
+	// Drop a leading heading that only repeats the title
+	if first, rest, ok := strings.Cut(content, "\n"); ok && strings.TrimLeft(first, "# ") == title {
+		content = strings.TrimSpace(rest)
+	}
+	return title, content
+}
+
+// pageTitle returns the document title, or the first top-level heading
+func pageTitle(doc *html.Node) string {
+	if n := findFirst(doc, atom.Title); n != nil {
+		if title := oneLine(textContent(n)); title != "" {
+			return title
+		}
+	}
+	if n := findFirst(doc, atom.H1); n != nil {
+		return oneLine(textContent(n))
+	}
+	return ""
+}
+
+// findBaseHref returns the href of the <base> element
+func findBaseHref(doc *html.Node) string {
+	if n := findFirst(doc, atom.Base); n != nil {
+		return attr(n, "href")
+	}
+	return ""
+}
+
+// removeClutter deletes scripts, navigation, hidden elements and other page chrome
+func removeClutter(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && isClutter(child)) {
+			n.RemoveChild(child)
+		} else {
+			removeClutter(child)
+		}
+		child = next
+	}
+}
+
+// isClutter reports whether an element is never part of the main content
+func isClutter(n *html.Node) bool {
+	if skippedElements[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Header && findFirst(n, atom.H1) == nil {
+		// Page headers are chrome, but article headers hold the title
+		return true
+	}
+	if _, hidden := attrOk(n, "hidden"); hidden || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	if strings.Contains(strings.ReplaceAll(attr(n, "style"), " ", ""), "display:none") {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary", "search", "dialog":
+		return true
+	}
+
+	// Never drop the document structure or code, whatever its class says
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Main, atom.Article, atom.Pre, atom.Code, atom.Table:
+		return false
+	}
+	names := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyPattern.MatchString(names) && !positivePattern.MatchString(names)
+}
+
+// findMainContent picks the element holding the article, in the spirit of readability:
+// explicit <article> and <main> elements win, otherwise paragraphs vote for their ancestors
+func findMainContent(doc *html.Node) *html.Node {
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+
+	// The longest <article>, as long as it holds a reasonable part of the page
+	var best *html.Node
+	bestLen := 0
+	for _, n := range findAll(body, atom.Article) {
+		if l := len(oneLine(textContent(n))); l > bestLen {
+			best, bestLen = n, l
+		}
+	}
+	total := len(oneLine(textContent(body)))
+	if best != nil && bestLen*3 >= total {
+		return best
+	}
+
+	if n := findFirst(body, atom.Main); n != nil {
+		return n
+	}
+	if n := findByAttr(body, "role", "main"); n != nil {
+		return n
+	}
+
+	// Score the ancestors of every paragraph-like element
+	scores := map[*html.Node]float64{}
+	var candidates []*html.Node // in document order, so that ties are broken the same way every time
+	for _, p := range findAll(body, atom.P, atom.Pre, atom.Td, atom.Blockquote) {
+		text := oneLine(textContent(p))
+		if len(text) < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(len(text)/100, 3))
+
+		parent := p.Parent
+		for level := 0; parent != nil && parent.Type == html.ElementNode && level < 3; level++ {
+			if _, ok := scores[parent]; !ok {
+				scores[parent] = classWeight(parent)
+				candidates = append(candidates, parent)
+			}
+			scores[parent] += score / float64(level+1)
+			parent = parent.Parent
+		}
+	}
+
+	var top *html.Node
+	topScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if top == nil || score > topScore {
+			top, topScore = n, score
+		}
+	}
+	if top == nil {
+		return body
+	}
+	return top
+}
+
+// classWeight scores an element by its class and id names
+func classWeight(n *html.Node) float64 {
+	names := attr(n, "class") + " " + attr(n, "id")
+	weight := 0.0
+	if positivePattern.MatchString(names) {
+		weight += 25
+	}
+	if negativePattern.MatchString(names) {
+		weight -= 25
+	}
+	switch n.DataAtom {
+	case atom.Div, atom.Section:
+		weight += 5
+	case atom.Ul, atom.Ol, atom.Form, atom.Li:
+		weight -= 3
+	}
+	return weight
+}
+
+// linkDensity is the fraction of an element's text that is inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(oneLine(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	for _, a := range findAll(n, atom.A) {
+		links += len(oneLine(textContent(a)))
+	}
+	return float64(links) / float64(total)
+}
+
+// mdConverter renders an HTML tree as Markdown
+type mdConverter struct {
+	base *url.URL
+}
+
+// children renders all children of n
+func (c *mdConverter) children(n *html.Node) string {
+	var out strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		out.WriteString(c.node(child))
+	}
+	return out.String()
+}
+
+// node renders a single node
+func (c *mdConverter) node(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return spacePattern.ReplaceAllString(n.Data, " ")
+	}
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := oneLine(c.children(n))
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + text + "\n\n"
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Figure,
+		atom.Figcaption, atom.Details, atom.Summary, atom.Address, atom.Center:
+		return block(strings.TrimSpace(c.children(n)))
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return "\n\n---\n\n"
+	case atom.A:
+		return c.link(n)
+	case atom.Strong, atom.B:
+		return wrapInline(c.children(n), "**")
+	case atom.Em, atom.I:
+		return wrapInline(c.children(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return wrapInline(c.children(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return inlineCode(textContent(n))
+	case atom.Pre:
+		return codeBlock(n)
+	case atom.Ul:
+		return c.list(n, false)
+	case atom.Ol:
+		return c.list(n, true)
+	case atom.Blockquote:
+		content := cleanMarkdown(c.children(n))
+		if content == "" {
+			return ""
+		}
+		return block("> " + strings.ReplaceAll(content, "\n", "\n> "))
+	case atom.Table:
+		return c.table(n)
+	case atom.Img:
+		alt := oneLine(attr(n, "alt"))
+		src := c.resolve(attr(n, "src"))
+		if alt == "" || src == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)", alt, src)
+	case atom.Dt:
+		return "\n\n**" + oneLine(c.children(n)) + "**\n"
+	case atom.Dd:
+		return ": " + oneLine(c.children(n)) + "\n"
+	default:
+		return c.children(n)
+	}
+}
+
+// link renders an anchor with an absolute URL
+func (c *mdConverter) link(n *html.Node) string {
+	text := oneLine(c.children(n))
+	href := attr(n, "href")
+	if text == "" {
+		return ""
+	}
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return text
+	}
+	return fmt.Sprintf("[%s](%s)", text, c.resolve(href))
+}
+
+// resolve makes a link absolute
+func (c *mdConverter) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if c.base == nil || href == "" {
+		return href
+	}
+	resolved, err := c.base.Parse(href)
+	if err != nil {
+		return href
+	}
+	return resolved.String()
+}
+
+// list renders a bulleted or numbered list, indenting nested content under each item
+func (c *mdConverter) list(n *html.Node, ordered bool) string {
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+
+		content := cleanMarkdown(c.children(li))
+		if content == "" {
+			continue
+		}
+		content = strings.ReplaceAll(content, "\n\n", "\n")
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.ReplaceAll(content, "\n", "\n"+indent))
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+	return block(strings.Join(items, "\n"))
+}
+
+// table renders a data table as a Markdown table. Layout tables, which hold other
+// tables or block content, are rendered as plain blocks instead.
+func (c *mdConverter) table(n *html.Node) string {
+	if len(findAll(n, atom.Table)) > 1 {
+		return block(strings.TrimSpace(c.children(n)))
+	}
+
+	var rows [][]string
+	columns := 0
+	for _, tr := range findAll(n, atom.Tr) {
+		var cells []string
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+				text := oneLine(c.children(cell))
+				cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+			columns = max(columns, len(cells))
+		}
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+	if columns == 1 {
+		// A single column is just a list of blocks
+		var lines []string
+		for _, row := range rows {
+			lines = append(lines, row[0])
+		}
+		return block(strings.Join(lines, "\n\n"))
+	}
+
+	var out strings.Builder
+	if caption := findFirst(n, atom.Caption); caption != nil {
+		out.WriteString("**" + oneLine(c.children(caption)) + "**\n\n")
+	}
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return block(strings.TrimSuffix(out.String(), "\n"))
+}
+
+// codeBlock renders a <pre> element as a fenced code block, keeping its whitespace
+func codeBlock(n *html.Node) string {
+	code := strings.Trim(textContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+
+	lang := codeLanguage(n)
+	if inner := findFirst(n, atom.Code); lang == "" && inner != nil {
+		lang = codeLanguage(inner)
+	}
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return "\n\n" + fence + lang + "\n" + code + "\n" + fence + "\n\n"
+}
+
+// codeLanguage reads the language from classes like "language-go" or "lang-go"
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-", "highlight-source-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// inlineCode wraps text in backticks, using more of them if the text contains some
+func inlineCode(text string) string {
+	text = oneLine(text)
+	if text == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// wrapInline wraps inline text in a marker, keeping surrounding spaces outside of it
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// block surrounds content with blank lines
+func block(content string) string {
+	if content == "" {
+		return ""
+	}
+	return "\n\n" + content + "\n\n"
+}
+
+// cleanMarkdown trims trailing spaces and collapses runs of blank lines outside of code blocks
+func cleanMarkdown(text string) string {
+	var out []string
+	inCode := false
+	blank := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode {
+			line = strings.TrimRight(line, " \t")
+			// Inline text after a block boundary may start with a space
+			if trimmed := strings.TrimLeft(line, " "); !strings.HasPrefix(trimmed, "- ") && !isNumbered(trimmed) && len(line)-len(trimmed) == 1 {
+				line = trimmed
+			}
+		}
+		if line == "" && !inCode {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// isNumbered reports whether a line starts with an ordered list marker
+func isNumbered(line string) bool {
+	dot := strings.Index(line, ". ")
+	if dot <= 0 {
+		return false
+	}
+	_, err := strconv.Atoi(line[:dot])
+	return err == nil
+}
+
+// oneLine collapses all whitespace in text to single spaces
+func oneLine(text string) string {
+	return strings.TrimSpace(spacePattern.ReplaceAllString(text, " "))
+}
+
+// textContent returns all text below n, with entities decoded by the parser
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var out strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			out.WriteString("\n")
+			continue
+		}
+		out.WriteString(textContent(child))
+	}
+	return out.String()
+}
+
+// findFirst returns the first element below n with the given tag
+func findFirst(n *html.Node, tag atom.Atom) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == tag {
+			return child
+		}
+		if found := findFirst(child, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findAll returns all elements below n with one of the given tags, in document order
+func findAll(n *html.Node, tags ...atom.Atom) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode {
+				for _, tag := range tags {
+					if child.DataAtom == tag {
+						found = append(found, child)
+						break
+					}
+				}
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// findByAttr returns the first element below n with the given attribute value
+func findByAttr(n *html.Node, key, value string) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && attr(child, key) == value {
+			return child
+		}
+		if found := findByAttr(child, key, value); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// attr returns the value of an attribute, or an empty string
+func attr(n *html.Node, key string) string {
+	value, _ := attrOk(n, key)
+	return value
+}
+
+// attrOk returns the value of an attribute and whether it is present
+func attrOk(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
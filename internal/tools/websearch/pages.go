@@ -0,0 +1,153 @@
+package websearch
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+)
+
+const (
+	// pageSize is the maximum size of one page of a visited document
+	pageSize = 8000
+
+	// maxCachedDocuments caps how many visited documents are kept for the session
+	maxCachedDocuments = 32
+
+	// maxFindMatches caps the number of matches returned by the find method
+	maxFindMatches = 20
+)
+
+// document is a visited page, extracted and split into pages
+type document struct {
+	url   string
+	pages []string
+}
+
+// documentCache keeps the documents visited during a session so that the model can
+// read further pages or search them without fetching them again
+type documentCache struct {
+	mutex sync.Mutex
+	docs  map[string]*document
+	order []string // visit order, oldest first
+}
+
+func newDocumentCache() *documentCache {
+	return &documentCache{docs: make(map[string]*document)}
+}
+
+// get returns the cached document for a URL
+func (c *documentCache) get(url string) (*document, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	doc, ok := c.docs[url]
+	return doc, ok
+}
+
+// last returns the most recently visited document
+func (c *documentCache) last() (*document, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.order) == 0 {
+		return nil, false
+	}
+	return c.docs[c.order[len(c.order)-1]], true
+}
+
+// put caches a document, evicting the oldest one when the cache is full
+func (c *documentCache) put(doc *document) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, url := range c.order {
+		if url == doc.url {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, doc.url)
+	c.docs[doc.url] = doc
+
+	if len(c.order) > maxCachedDocuments {
+		delete(c.docs, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// splitPages splits content into pages of at most size bytes, preferring line boundaries
+func splitPages(content string, size int) []string {
+	var pages []string
+	for len(content) > size {
+		cut := strings.LastIndex(content[:size], "\n")
+		if cut < size/2 {
+			// No convenient line break, cut at the last rune boundary instead
+			cut = len(core.Truncate(content, size))
+		}
+		pages = append(pages, strings.TrimRight(content[:cut], "\n"))
+		content = strings.TrimLeft(content[cut:], "\n")
+	}
+	return append(pages, content)
+}
+
+// formatPage renders one page of a document with directions to the rest of it
+func formatPage(doc *document, page int) string {
+	var output strings.Builder
+	if len(doc.pages) == 1 {
+		output.WriteString(fmt.Sprintf("Content from %s:\n\n", doc.url))
+	} else {
+		output.WriteString(fmt.Sprintf("Content from %s (page %d of %d):\n\n", doc.url, page, len(doc.pages)))
+	}
+	output.WriteString(doc.pages[page-1])
+
+	if page < len(doc.pages) {
+		output.WriteString(fmt.Sprintf("\n\n[Page %d of %d. Use method 'visit' with page %d to continue reading, or method 'find' to search this document for a phrase.]",
+			page, len(doc.pages), page+1))
+	}
+	return output.String()
+}
+
+// findInDocument returns the lines of a document containing the phrase, ignoring case,
+// with a line of context on each side and the page they are on
+func findInDocument(doc *document, phrase string) string {
+	needle := strings.ToLower(phrase)
+
+	var output strings.Builder
+	matches := 0
+	for p, content := range doc.pages {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if !strings.Contains(strings.ToLower(line), needle) {
+				continue
+			}
+			matches++
+			if matches > maxFindMatches {
+				continue
+			}
+
+			output.WriteString(fmt.Sprintf("\n--- Match %d (page %d) ---\n", matches, p+1))
+			for j := max(i-1, 0); j <= min(i+1, len(lines)-1); j++ {
+				if strings.TrimSpace(lines[j]) == "" {
+					continue
+				}
+				marker := "  "
+				if j == i {
+					marker = "> "
+				}
+				output.WriteString(marker + lines[j] + "\n")
+			}
+		}
+	}
+
+	if matches == 0 {
+		return fmt.Sprintf("No matches for %q in %s (%s)", phrase, doc.url, plural(len(doc.pages), "page", "pages"))
+	}
+
+	header := fmt.Sprintf("Found %s for %q in %s (%s):\n",
+		plural(matches, "match", "matches"), phrase, doc.url, plural(len(doc.pages), "page", "pages"))
+	if matches > maxFindMatches {
+		header = fmt.Sprintf("Found %d matches for %q in %s (%s), showing the first %d:\n",
+			matches, phrase, doc.url, plural(len(doc.pages), "page", "pages"), maxFindMatches)
+	}
+	return header + output.String() + "\nUse method 'visit' with the page number to read a match in full.\n"
+}
@@ -0,0 +1,490 @@
+package websearch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// maxPDFStream and maxPDFDecoded cap the decompressed size of a single stream and of
+	// all streams, the download size says nothing about how much a stream expands
+	maxPDFStream  = 16 << 20
+	maxPDFDecoded = 64 << 20
+)
+
+var (
+	pdfStreamPattern  = regexp.MustCompile(`stream\r?\n`)
+	pdfBfCharPattern  = regexp.MustCompile(`(?s)beginbfchar(.*?)endbfchar`)
+	pdfBfRangePattern = regexp.MustCompile(`(?s)beginbfrange(.*?)endbfrange`)
+	pdfHexPattern     = regexp.MustCompile(`<([0-9A-Fa-f\s]*)>`)
+	pdfRangePattern   = regexp.MustCompile(`<([0-9A-Fa-f]+)>\s*<([0-9A-Fa-f]+)>\s*(<[0-9A-Fa-f\s]*>|\[[^\]]*\])`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// winAnsiExtras maps the WinAnsiEncoding bytes that differ from Latin-1
+var winAnsiExtras = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”',
+	0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™',
+}
+
+// cmap maps character codes of a font to Unicode text
+type cmap struct {
+	width int // code length in bytes
+	codes map[uint32]string
+}
+
+// extractPDFText extracts the text of a PDF from the text operators of its content
+// streams. Fonts that map their glyphs with ToUnicode CMaps are decoded through
+// those maps, so scanned documents and fonts with custom encodings yield no text.
+func extractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\r\n\t "), []byte("%PDF-")) {
+		return "", fmt.Errorf("not a PDF document")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", fmt.Errorf("encrypted PDF documents are not supported")
+	}
+
+	streams := pdfStreams(data)
+
+	// Collect the ToUnicode maps first, they apply to every content stream
+	unicode := &cmap{codes: make(map[uint32]string)}
+	for _, stream := range streams {
+		if bytes.Contains(stream, []byte("begincmap")) {
+			parseCMap(stream, unicode)
+		}
+	}
+
+	var output strings.Builder
+	for _, stream := range streams {
+		if bytes.Contains(stream, []byte("begincmap")) || !bytes.Contains(stream, []byte("BT")) {
+			continue
+		}
+		if text := pdfContentText(stream, unicode); strings.TrimSpace(text) != "" {
+			output.WriteString(text)
+			output.WriteString("\n\n")
+		}
+	}
+
+	text := cleanPDFText(output.String())
+	if text == "" {
+		return "", fmt.Errorf("no extractable text found in the PDF, it may be scanned or use embedded font encodings")
+	}
+	return text, nil
+}
+
+// pdfStreams returns the decoded streams of a PDF, skipping images and streams
+// compressed with filters other than FlateDecode. Decoding stops once the streams
+// add up to maxPDFDecoded bytes.
+func pdfStreams(data []byte) [][]byte {
+	var streams [][]byte
+	total := 0
+	for _, loc := range pdfStreamPattern.FindAllIndex(data, -1) {
+		if loc[0] >= 3 && string(data[loc[0]-3:loc[0]]) == "end" {
+			continue
+		}
+
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := data[dictStart:loc[0]]
+
+		end := bytes.Index(data[loc[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		raw := bytes.TrimRight(data[loc[1]:loc[1]+end], "\r\n")
+
+		if bytes.Contains(dict, []byte("/Image")) {
+			continue
+		}
+
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			if total >= maxPDFDecoded {
+				// A small document expanding this much is a decompression bomb, keep what was decoded
+				return streams
+			}
+			decoded, err := inflate(raw, min(maxPDFStream, maxPDFDecoded-total))
+			if err != nil {
+				continue
+			}
+			total += len(decoded)
+			streams = append(streams, decoded)
+		case bytes.Contains(dict, []byte("/Filter")):
+			continue
+		default:
+			streams = append(streams, raw)
+		}
+	}
+	return streams
+}
+
+// inflate decompresses a FlateDecode stream, failing when it expands to more than limit bytes
+func inflate(raw []byte, limit int) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	// Streams are often truncated by a few bytes, keep what was decoded
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if len(decoded) > limit {
+		return nil, fmt.Errorf("stream expands to more than %d bytes", limit)
+	}
+	return decoded, nil
+}
+
+// parseCMap adds the bfchar and bfrange mappings of a CMap stream to m
+func parseCMap(stream []byte, m *cmap) {
+	for _, section := range pdfBfCharPattern.FindAllSubmatch(stream, -1) {
+		hexes := pdfHexPattern.FindAllSubmatch(section[1], -1)
+		for i := 0; i+1 < len(hexes); i += 2 {
+			src, dst := decodeHex(hexes[i][1]), decodeHex(hexes[i+1][1])
+			m.add(src, utf16BE(dst))
+		}
+	}
+
+	for _, section := range pdfBfRangePattern.FindAllSubmatch(stream, -1) {
+		for _, r := range pdfRangePattern.FindAllSubmatch(section[1], -1) {
+			lo, hi := decodeHex(r[1]), decodeHex(r[2])
+			first, last := codeValue(lo), codeValue(hi)
+			if last < first || last-first > 0xFFFF {
+				continue
+			}
+
+			if r[3][0] == '[' {
+				// One destination per code
+				for i, dst := range pdfHexPattern.FindAllSubmatch(r[3], -1) {
+					m.addCode(len(lo), first+uint32(i), utf16BE(decodeHex(dst[1])))
+				}
+				continue
+			}
+
+			// Consecutive codes map to consecutive characters
+			dst := decodeHex(pdfHexPattern.FindSubmatch(r[3])[1])
+			if len(dst) < 2 {
+				continue
+			}
+			base := []rune(utf16BE(dst))
+			for code := first; code <= last; code++ {
+				text := append([]rune{}, base...)
+				text[len(text)-1] += rune(code - first)
+				m.addCode(len(lo), code, string(text))
+			}
+		}
+	}
+}
+
+func (m *cmap) add(code []byte, text string) {
+	m.addCode(len(code), codeValue(code), text)
+}
+
+func (m *cmap) addCode(width int, code uint32, text string) {
+	if m.width == 0 {
+		m.width = width
+	}
+	m.codes[code] = text
+}
+
+// decode maps the codes of a string through the CMap
+func (m *cmap) decode(data []byte) (string, bool) {
+	if len(m.codes) == 0 || m.width == 0 || len(data)%m.width != 0 {
+		return "", false
+	}
+
+	var text strings.Builder
+	for i := 0; i < len(data); i += m.width {
+		mapped, ok := m.codes[codeValue(data[i:i+m.width])]
+		if !ok {
+			return "", false
+		}
+		text.WriteString(mapped)
+	}
+	return text.String(), true
+}
+
+// pdfContentText runs the text operators of a content stream and returns the shown text
+func pdfContentText(stream []byte, unicode *cmap) string {
+	var output strings.Builder
+	var operands []pdfToken
+	lastY := 0.0
+
+	newline := func() {
+		text := output.String()
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			output.WriteString("\n")
+		}
+	}
+	show := func(token pdfToken) {
+		if token.kind == pdfString {
+			output.WriteString(decodePDFString(token, unicode))
+		}
+	}
+
+	lexer := &pdfLexer{data: stream}
+	for {
+		token, ok := lexer.next()
+		if !ok {
+			break
+		}
+		if token.kind != pdfOperator {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch token.text {
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			for _, operand := range operands {
+				if operand.kind == pdfNumber {
+					// Large negative adjustments separate words
+					if value, _ := strconv.ParseFloat(operand.text, 64); value < -200 {
+						output.WriteString(" ")
+					}
+				}
+				show(operand)
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := strconv.ParseFloat(operands[len(operands)-2].text, 64)
+				ty, _ := strconv.ParseFloat(operands[len(operands)-1].text, 64)
+				if ty != 0 {
+					newline()
+				} else if tx != 0 && !strings.HasSuffix(output.String(), " ") {
+					output.WriteString(" ")
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := strconv.ParseFloat(operands[len(operands)-1].text, 64)
+				if y != lastY {
+					newline()
+				}
+				lastY = y
+			}
+		case "T*", "ET":
+			newline()
+		}
+		operands = operands[:0]
+	}
+	return output.String()
+}
+
+// decodePDFString turns the bytes of a PDF string into text
+func decodePDFString(token pdfToken, unicode *cmap) string {
+	data := []byte(token.text)
+	if bytes.HasPrefix(data, []byte{0xFE, 0xFF}) {
+		return utf16BE(data[2:])
+	}
+	if text, ok := unicode.decode(data); ok {
+		return text
+	}
+
+	var text strings.Builder
+	for _, b := range data {
+		if r, ok := winAnsiExtras[b]; ok {
+			text.WriteRune(r)
+		} else if b >= 0x20 || b == '\t' {
+			text.WriteRune(rune(b))
+		}
+	}
+	return text.String()
+}
+
+// cleanPDFText trims the lines of the extracted text and collapses blank lines
+func cleanPDFText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// Kinds of tokens in a content stream
+const (
+	pdfOperator = iota
+	pdfNumber
+	pdfString
+	pdfOther
+)
+
+type pdfToken struct {
+	kind int
+	text string
+}
+
+// pdfLexer splits a content stream into operands and operators
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isPDFSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return pdfToken{kind: pdfString, text: l.literalString()}, true
+		case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<', c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+			l.pos += 2
+			return pdfToken{kind: pdfOther, text: string(c) + string(c)}, true
+		case c == '<':
+			end := bytes.IndexByte(l.data[l.pos:], '>')
+			if end < 0 {
+				l.pos = len(l.data)
+				return pdfToken{}, false
+			}
+			hexText := l.data[l.pos+1 : l.pos+end]
+			l.pos += end + 1
+			return pdfToken{kind: pdfString, text: string(decodeHex(hexText))}, true
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			l.pos++
+			return pdfToken{kind: pdfOther, text: string(c)}, true
+		case c == '/':
+			start := l.pos
+			l.pos++
+			l.regular()
+			return pdfToken{kind: pdfOther, text: string(l.data[start:l.pos])}, true
+		default:
+			start := l.pos
+			l.regular()
+			if l.pos == start {
+				l.pos++ // Skip stray delimiters like ')' or '>'
+				continue
+			}
+			text := string(l.data[start:l.pos])
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				return pdfToken{kind: pdfNumber, text: text}, true
+			}
+			return pdfToken{kind: pdfOperator, text: text}, true
+		}
+	}
+	return pdfToken{}, false
+}
+
+// regular advances past a run of regular characters
+func (l *pdfLexer) regular() {
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !strings.ContainsRune("()<>[]{}/%", rune(l.data[l.pos])) {
+		l.pos++
+	}
+}
+
+// literalString reads a string in parentheses, which may nest and contain escapes
+func (l *pdfLexer) literalString() string {
+	var out []byte
+	depth := 0
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(out)
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return string(out)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+				// Backspace and form feed carry no text
+			case '\r', '\n':
+				// A line continuation
+				if e == '\r' && l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(value))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// decodeHex decodes a hex string, ignoring whitespace and padding an odd final digit
+func decodeHex(text []byte) []byte {
+	digits := strings.Join(strings.Fields(string(text)), "")
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+	decoded, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// codeValue returns the big-endian value of a character code
+func codeValue(code []byte) uint32 {
+	var value uint32
+	for _, b := range code {
+		value = value<<8 | uint32(b)
+	}
+	return value
+}
+
+// utf16BE decodes UTF-16 big-endian text
+func utf16BE(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
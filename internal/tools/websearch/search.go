@@ -0,0 +1,375 @@
+package websearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultSearchResults is the number of results returned when no limit is given
+	defaultSearchResults = 8
+
+	// maxSearchResults caps the number of results a search can return
+	maxSearchResults = 20
+
+	// maxSearchResponse caps the size of a search backend response
+	maxSearchResponse = 4 * 1024 * 1024
+)
+
+// Default endpoints of the search backends
+const (
+	DefaultDuckDuckGoURL = "https://html.duckduckgo.com/html/"
+	DefaultBraveURL      = "https://api.search.brave.com/res/v1/web/search"
+	DefaultBingURL       = "https://api.bing.microsoft.com/v7.0/search"
+)
+
+// SearchResult is a single ranked search hit
+type SearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// Backend is a web search engine
+type Backend interface {
+	// Name returns the name of the backend
+	Name() string
+
+	// Search returns up to limit results for the query, best match first
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// NewBackend creates the backend with the given name. Endpoint overrides the
+// default URL of the backend and is required for SearXNG, which is self-hosted.
+func NewBackend(name, endpoint, apiKey string, client *http.Client) (Backend, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	switch strings.ToLower(name) {
+	case "", "duckduckgo", "ddg":
+		return &DuckDuckGo{Endpoint: orDefault(endpoint, DefaultDuckDuckGoURL), Client: client}, nil
+	case "searxng":
+		if endpoint == "" {
+			return nil, fmt.Errorf("the searxng backend needs the URL of a SearXNG instance (tools.search.url)")
+		}
+		return &SearXNG{Endpoint: strings.TrimSuffix(endpoint, "/") + "/search", Client: client}, nil
+	case "brave":
+		if apiKey == "" {
+			return nil, fmt.Errorf("the brave backend needs an API key (tools.search.api_key)")
+		}
+		return &Brave{Endpoint: orDefault(endpoint, DefaultBraveURL), APIKey: apiKey, Client: client}, nil
+	case "bing":
+		if apiKey == "" {
+			return nil, fmt.Errorf("the bing backend needs an API key (tools.search.api_key)")
+		}
+		return &Bing{Endpoint: orDefault(endpoint, DefaultBingURL), APIKey: apiKey, Client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown search backend %q, supported backends are: duckduckgo, searxng, brave, bing", name)
+	}
+}
+
+// Endpoint returns the URL a backend sends its requests to
+func Endpoint(b Backend) string {
+	switch b := b.(type) {
+	case *DuckDuckGo:
+		return b.Endpoint
+	case *SearXNG:
+		return b.Endpoint
+	case *Brave:
+		return b.Endpoint
+	case *Bing:
+		return b.Endpoint
+	default:
+		return ""
+	}
+}
+
+// SearXNG searches a SearXNG instance through its JSON API
+type SearXNG struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// Name returns the name of the backend
+func (b *SearXNG) Name() string {
+	return "searxng"
+}
+
+// Search queries the instance with format=json
+func (b *SearXNG) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "format": {"json"}}
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := getJSON(ctx, b.Client, b.Endpoint+"?"+params.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range response.Results {
+		results = appendResult(results, r.Title, r.URL, r.Content)
+	}
+	return limitResults(results, limit), nil
+}
+
+// Brave searches with the Brave Search API
+type Brave struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Name returns the name of the backend
+func (b *Brave) Name() string {
+	return "brave"
+}
+
+// Search queries the web search endpoint of the API
+func (b *Brave) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(limit)}}
+	headers := map[string]string{"X-Subscription-Token": b.APIKey}
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := getJSON(ctx, b.Client, b.Endpoint+"?"+params.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range response.Web.Results {
+		results = appendResult(results, r.Title, r.URL, r.Description)
+	}
+	return limitResults(results, limit), nil
+}
+
+// Bing searches with the Bing Web Search API
+type Bing struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Name returns the name of the backend
+func (b *Bing) Name() string {
+	return "bing"
+}
+
+// Search queries the web search endpoint of the API
+func (b *Bing) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(limit)}}
+	headers := map[string]string{"Ocp-Apim-Subscription-Key": b.APIKey}
+
+	var response struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	if err := getJSON(ctx, b.Client, b.Endpoint+"?"+params.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range response.WebPages.Value {
+		results = appendResult(results, r.Name, r.URL, r.Snippet)
+	}
+	return limitResults(results, limit), nil
+}
+
+// DuckDuckGo scrapes the HTML version of DuckDuckGo, which needs no API key
+type DuckDuckGo struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+var (
+	ddgResultPattern  = regexp.MustCompile(`(?s)<a[^>]+class="[^"]*result__a[^"]*"[^>]*href="([^"]+)"[^>]*>(.*?)</a>`)
+	ddgSnippetPattern = regexp.MustCompile(`(?s)class="[^"]*result__snippet[^"]*"[^>]*>(.*?)</(?:a|div|td)>`)
+	tagPattern        = regexp.MustCompile(`<[^>]*>`)
+)
+
+// Name returns the name of the backend
+func (b *DuckDuckGo) Name() string {
+	return "duckduckgo"
+}
+
+// Search fetches the result page and extracts the organic results
+func (b *DuckDuckGo) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}}
+	body, err := fetch(ctx, b.Client, b.Endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	page := string(body)
+	links := ddgResultPattern.FindAllStringSubmatchIndex(page, -1)
+
+	var results []SearchResult
+	for i, link := range links {
+		href := html.UnescapeString(page[link[2]:link[3]])
+		title := cleanHTML(page[link[4]:link[5]])
+
+		// The snippet follows the link, before the next result
+		end := len(page)
+		if i+1 < len(links) {
+			end = links[i+1][0]
+		}
+		snippet := ""
+		if match := ddgSnippetPattern.FindStringSubmatch(page[link[1]:end]); match != nil {
+			snippet = cleanHTML(match[1])
+		}
+
+		// Ads link through a tracking URL on y.js and are skipped
+		target := resolveDuckDuckGoLink(href)
+		if target == "" || strings.Contains(target, "duckduckgo.com/y.js") {
+			continue
+		}
+		results = appendResult(results, title, target, snippet)
+	}
+
+	if len(results) == 0 && strings.Contains(page, "anomaly") {
+		return nil, fmt.Errorf("duckduckgo rejected the request as automated traffic, try again later or configure another backend")
+	}
+	return limitResults(results, limit), nil
+}
+
+// resolveDuckDuckGoLink extracts the target of a DuckDuckGo redirect link
+func resolveDuckDuckGoLink(href string) string {
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if target := parsed.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return href
+}
+
+// cleanHTML turns an HTML fragment into plain text
+func cleanHTML(fragment string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(fragment, ""))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// fetch performs a GET request and returns the body of a successful response
+func fetch(ctx context.Context, client *http.Client, target string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSearchResponse))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search request failed with status %s", resp.Status)
+	}
+	return body, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into v
+func getJSON(ctx context.Context, client *http.Client, target string, headers map[string]string, v interface{}) error {
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers["Accept"] = "application/json"
+
+	body, err := fetch(ctx, client, target, headers)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse search response: %w", err)
+	}
+	return nil
+}
+
+// appendResult adds a result unless it has no URL or duplicates an earlier one
+func appendResult(results []SearchResult, title, link, snippet string) []SearchResult {
+	if link == "" {
+		return results
+	}
+	for _, r := range results {
+		if r.URL == link {
+			return results
+		}
+	}
+	if title == "" {
+		title = link
+	}
+	return append(results, SearchResult{
+		Title:   strings.TrimSpace(title),
+		URL:     link,
+		Snippet: cleanHTML(snippet),
+	})
+}
+
+// limitResults keeps the first limit results
+func limitResults(results []SearchResult, limit int) []SearchResult {
+	if limit > 0 && len(results) > limit {
+		return results[:limit]
+	}
+	return results
+}
+
+// formatResults renders ranked results for the model
+func formatResults(query, backend string, results []SearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("No results found for %q (via %s)", query, backend)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Search results for %q (via %s):\n", query, backend))
+	for i, r := range results {
+		output.WriteString(fmt.Sprintf("\n%d. %s\n   %s\n", i+1, r.Title, r.URL))
+		if r.Snippet != "" {
+			output.WriteString(fmt.Sprintf("   %s\n", r.Snippet))
+		}
+	}
+	output.WriteString("\nUse method 'visit' with one of these URLs to read the page.\n")
+	return output.String()
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
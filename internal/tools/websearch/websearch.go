@@ -3,22 +3,28 @@ package websearch
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/saurabh0719/kiwi/internal/httpcache"
 	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
 )
 
+// userAgent is sent with every request to avoid being blocked
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
 // Tool provides web search capabilities
 type Tool struct {
 	name        string
 	description string
 	parameters  map[string]core.Parameter
 	httpClient  *http.Client
+	backend     Backend
+	maxResults  int
+	documents   *documentCache
 }
 
 // New creates a new WebSearchTool
@@ -26,26 +32,68 @@ func New() *Tool {
 	parameters := map[string]core.Parameter{
 		"method": {
 			Type:        "string",
-			Description: "Method to use: ONLY 'visit' is supported to visit a URL and read its content.",
+			Description: "Method to use: 'search' to search the web for a query, 'visit' to visit a URL and read its content, or 'find' to search a visited page for a phrase.",
 			Required:    true,
 		},
 		"query": {
 			Type:        "string",
-			Description: "URL to visit",
+			Description: "Search terms for the 'search' method, the URL to visit for the 'visit' method, or the phrase to look for with the 'find' method",
 			Required:    true,
 		},
+		"max_results": {
+			Type:        "integer",
+			Description: "Maximum number of search results to return (for search only). Defaults to 8.",
+			Required:    false,
+		},
+		"page": {
+			Type:        "integer",
+			Description: "Page of the document to read (for visit only). Long pages are split into pages of about 8000 characters. Defaults to 1.",
+			Required:    false,
+		},
+		"url": {
+			Type:        "string",
+			Description: "URL of the page to search (for find only). Defaults to the most recently visited page.",
+			Required:    false,
+		},
 	}
 
+	// Requests go through the shared egress policy, the model picks the URLs
+	httpClient := egress.NewClient(15 * time.Second)
+
 	return &Tool{
 		name:        "websearch",
-		description: "Visit URLs and read web content. IMPORTANT: ONLY the 'visit' method is supported - use this method with a valid URL to get content from a webpage.",
+		description: "Search the web and read web content. Use the 'search' method to find pages (returns ranked titles, URLs and snippets), then the 'visit' method with a URL from the results to read a page. Long pages are returned in pages: read further with the 'page' parameter, or use the 'find' method to locate a phrase. Visited pages are kept for the session. Don't guess URLs when you can search for them.",
 		parameters:  parameters,
-		httpClient: &http.Client{
-			Timeout: 15 * time.Second,
-		},
+		httpClient:  httpClient,
+		backend:     &DuckDuckGo{Endpoint: DefaultDuckDuckGoURL, Client: httpClient},
+		maxResults:  defaultSearchResults,
+		documents:   newDocumentCache(),
 	}
 }
 
+// SetBackend sets the search engine used by the search method
+func (t *Tool) SetBackend(backend Backend) {
+	t.backend = backend
+}
+
+// SetMaxResults sets the number of search results returned when the model doesn't ask for a number
+func (t *Tool) SetMaxResults(n int) {
+	if n > 0 {
+		t.maxResults = n
+	}
+}
+
+// SetCache sends requests through an on-disk HTTP cache, which also serves
+// visits and searches when it is in offline mode
+func (t *Tool) SetCache(cache *httpcache.Cache) {
+	t.httpClient.Transport = cache.Transport(t.httpClient.Transport)
+}
+
+// HTTPClient returns the client the tool makes requests with, for creating backends
+func (t *Tool) HTTPClient() *http.Client {
+	return t.httpClient
+}
+
 // Name returns the name of the tool
 func (t *Tool) Name() string {
 	return t.name
@@ -71,8 +119,9 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 	// Extract method parameter
 	method, ok := args["method"].(string)
 	if !ok || method == "" {
-		return result, fmt.Errorf("method must be a non-empty string ('visit' is the only supported method)")
+		return result, fmt.Errorf("method must be a non-empty string ('search', 'visit' or 'find')")
 	}
+	method = strings.ToLower(method)
 	result.ToolMethod = method
 
 	result.AddStep(fmt.Sprintf("Method requested: %s", method))
@@ -80,134 +129,196 @@ func (t *Tool) Execute(ctx context.Context, args map[string]interface{}) (core.T
 	// Extract query parameter
 	query, ok := args["query"].(string)
 	if !ok || query == "" {
-		return result, fmt.Errorf("query must be a non-empty string (should be a URL for the 'visit' method)")
+		return result, fmt.Errorf("query must be a non-empty string (search terms for 'search', a URL for 'visit', a phrase for 'find')")
+	}
+
+	switch method {
+	case "search":
+		return t.executeSearch(ctx, query, args, result)
+	case "visit":
+		return t.executeVisit(ctx, query, args, result)
+	case "find":
+		return t.executeFind(ctx, query, args, result)
+	default:
+		result.AddStep(fmt.Sprintf("Method '%s' is not supported", method))
+		return result, fmt.Errorf("unsupported method: '%s'. Supported methods are 'search', 'visit' and 'find'", method)
+	}
+}
+
+// executeSearch runs a web search with the configured backend
+func (t *Tool) executeSearch(ctx context.Context, query string, args map[string]interface{}, result core.ToolExecutionResult) (core.ToolExecutionResult, error) {
+	limit, err := core.GetInt(args, "max_results", t.maxResults)
+	if err != nil {
+		return result, err
+	}
+	if limit <= 0 {
+		limit = t.maxResults
+	}
+	if limit > maxSearchResults {
+		limit = maxSearchResults
 	}
 
-	result.AddStep(fmt.Sprintf("URL requested: %s", query))
+	result.AddStep(fmt.Sprintf("Searching %s for: %s", t.backend.Name(), query))
 
-	// Only support the 'visit' method - fail fast with a clear message
-	if strings.ToLower(method) != "visit" {
-		result.AddStep(fmt.Sprintf("Method '%s' is not supported - only 'visit' can be used", method))
-		return result, fmt.Errorf("unsupported method: '%s'. The only supported method is 'visit' which requires a valid URL", method)
+	results, err := t.backend.Search(ctx, query, limit)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Error searching: %v", err))
+		return result, fmt.Errorf("search failed: %w", err)
 	}
 
-	// Validate URL
-	parsedURL, err := url.Parse(query)
+	result.AddStep(fmt.Sprintf("Found %d results", len(results)))
+	result.Output = formatResults(query, t.backend.Name(), results)
+	return result, nil
+}
+
+// executeVisit returns a page of a document, fetching it unless it was visited before
+func (t *Tool) executeVisit(ctx context.Context, query string, args map[string]interface{}, result core.ToolExecutionResult) (core.ToolExecutionResult, error) {
+	page, err := core.GetInt(args, "page", 1)
 	if err != nil {
-		result.AddStep(fmt.Sprintf("Invalid URL: %v", err))
-		return result, fmt.Errorf("invalid URL: %w", err)
+		return result, err
+	}
+	if page < 1 {
+		return result, fmt.Errorf("page must be 1 or greater")
 	}
 
-	// Ensure the URL has a scheme
-	if parsedURL.Scheme == "" {
-		result.AddStep(fmt.Sprintf("Adding https:// scheme to URL: %s", query))
-		query = "https://" + query
-		parsedURL, err = url.Parse(query)
+	result.AddStep(fmt.Sprintf("URL requested: %s (page %d)", query, page))
+
+	doc, err := t.loadDocument(ctx, query, &result)
+	if err != nil {
+		return result, err
+	}
+	if page > len(doc.pages) {
+		result.AddStep(fmt.Sprintf("Page %d is out of range", page))
+		return result, fmt.Errorf("page %d is out of range, %s has %d pages", page, doc.url, len(doc.pages))
+	}
+
+	result.Output = formatPage(doc, page)
+	return result, nil
+}
+
+// executeFind searches a visited document for a phrase. It searches the document
+// given by the url parameter, or the most recently visited one.
+func (t *Tool) executeFind(ctx context.Context, phrase string, args map[string]interface{}, result core.ToolExecutionResult) (core.ToolExecutionResult, error) {
+	target, err := core.GetString(args, "url", "")
+	if err != nil {
+		return result, err
+	}
+
+	var doc *document
+	if target != "" {
+		doc, err = t.loadDocument(ctx, target, &result)
 		if err != nil {
-			result.AddStep(fmt.Sprintf("Invalid URL after adding scheme: %v", err))
-			return result, fmt.Errorf("invalid URL: %w", err)
+			return result, err
+		}
+	} else {
+		var ok bool
+		if doc, ok = t.documents.last(); !ok {
+			result.AddStep("No page has been visited yet")
+			return result, fmt.Errorf("no page has been visited yet, visit a URL first or pass it in the url parameter")
 		}
 	}
 
-	result.AddStep(fmt.Sprintf("Validated URL: %s", query))
+	result.AddStep(fmt.Sprintf("Searching %s for: %s", doc.url, phrase))
+	result.Output = findInDocument(doc, phrase)
+	return result, nil
+}
+
+// loadDocument returns the cached document for a URL, fetching and caching it on first use
+func (t *Tool) loadDocument(ctx context.Context, target string, result *core.ToolExecutionResult) (*document, error) {
+	target, err := normalizeURL(target)
+	if err != nil {
+		result.AddStep(fmt.Sprintf("Invalid URL: %v", err))
+		return nil, err
+	}
+
+	if doc, ok := t.documents.get(target); ok {
+		result.AddStep(fmt.Sprintf("Using the copy of %s visited earlier in this session (%d pages)", target, len(doc.pages)))
+		return doc, nil
+	}
+
+	result.AddStep(fmt.Sprintf("Validated URL: %s", target))
 	result.AddStep("Sending HTTP request...")
 
-	// Visit the URL
-	content, err := t.VisitURL(ctx, query)
+	content, cacheStatus, err := t.fetchContent(ctx, target)
 	if err != nil {
 		result.AddStep(fmt.Sprintf("Error visiting URL: %v", err))
-		return result, err
+		return nil, err
+	}
+	switch cacheStatus {
+	case "hit", "revalidated", "offline":
+		result.AddStep(fmt.Sprintf("Served from the HTTP cache (%s)", cacheStatus))
 	}
 
-	// Get approximate size stats
-	contentLength := len(content)
+	doc := &document{url: target, pages: splitPages(content, pageSize)}
+	t.documents.put(doc)
+
 	lineCount := strings.Count(content, "\n") + 1
-	result.AddStep(fmt.Sprintf("Successfully retrieved content from %s (%d lines, %d bytes)",
-		query, lineCount, contentLength))
+	result.AddStep(fmt.Sprintf("Successfully retrieved content from %s (%d lines, %d bytes, %d pages)",
+		target, lineCount, len(content), len(doc.pages)))
+	return doc, nil
+}
 
-	result.Output = content
-	return result, nil
+// normalizeURL validates a URL, defaulting to https when it has no scheme
+func normalizeURL(target string) (string, error) {
+	parsedURL, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if parsedURL.Scheme == "" {
+		target = "https://" + target
+		if _, err := url.Parse(target); err != nil {
+			return "", fmt.Errorf("invalid URL: %w", err)
+		}
+	}
+	return target, nil
 }
 
-// VisitURL visits a URL and returns its text content
+// VisitURL visits a URL and returns the first page of its content
 func (t *Tool) VisitURL(ctx context.Context, urlStr string) (string, error) {
+	content, _, err := t.fetchContent(ctx, urlStr)
+	if err != nil {
+		return "", err
+	}
+	return formatPage(&document{url: urlStr, pages: splitPages(content, pageSize)}, 1), nil
+}
+
+// fetchContent downloads a URL and extracts its content as text. HTML pages are
+// converted to Markdown, JSON is pretty-printed, feeds are listed and PDFs are read.
+// It also returns how the HTTP cache answered, if it is used.
+func (t *Tool) fetchContent(ctx context.Context, urlStr string) (string, string, error) {
 	// Create a request
 	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set a user agent to avoid being blocked
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", acceptHeader)
 	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
 
 	// Execute the request
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
+		return "", "", fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Check if content type is text-based
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(strings.ToLower(contentType), "text/html") &&
-		!strings.Contains(strings.ToLower(contentType), "text/plain") {
-		return "", fmt.Errorf("unsupported content type: %s", contentType)
+	// Error pages are not the content asked for, and would stay in the document cache
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", "", fmt.Errorf("%s returned %s", urlStr, resp.Status)
 	}
 
 	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Extract readable content from HTML
-	content := t.extractReadableContent(string(body))
-
-	return fmt.Sprintf("Content from %s:\n\n%s", urlStr, content), nil
-}
-
-// extractReadableContent extracts the main content from an HTML page
-func (t *Tool) extractReadableContent(html string) string {
-	// Remove script and style elements
-	scriptPattern := regexp.MustCompile(`(?s)<script.*?</script>`)
-	stylePattern := regexp.MustCompile(`(?s)<style.*?</style>`)
-
-	html = scriptPattern.ReplaceAllString(html, "")
-	html = stylePattern.ReplaceAllString(html, "")
-
-	// Replace common block-level elements with newlines
-	html = regexp.MustCompile(`<(?:div|p|h[1-6]|table|tr|ul|ol)[^>]*>`).ReplaceAllString(html, "\n")
-	html = regexp.MustCompile(`</(?:div|p|h[1-6]|table|tr|ul|ol)>`).ReplaceAllString(html, "\n")
-
-	// Replace list items with bullet points
-	html = regexp.MustCompile(`<li[^>]*>`).ReplaceAllString(html, "\n• ")
-
-	// Remove all other HTML tags
-	html = regexp.MustCompile(`<[^>]*>`).ReplaceAllString(html, "")
-
-	// Replace HTML entities
-	html = strings.ReplaceAll(html, "&amp;", "&")
-	html = strings.ReplaceAll(html, "&lt;", "<")
-	html = strings.ReplaceAll(html, "&gt;", ">")
-	html = strings.ReplaceAll(html, "&quot;", "\"")
-	html = strings.ReplaceAll(html, "&#39;", "'")
-	html = strings.ReplaceAll(html, "&nbsp;", " ")
-
-	// Replace multiple newlines with a single one
-	html = regexp.MustCompile(`\n{3,}`).ReplaceAllString(html, "\n\n")
-
-	// Trim whitespace
-	html = strings.TrimSpace(html)
-
-	// Limit content length to avoid very long responses
-	const maxLength = 8000
-	if len(html) > maxLength {
-		html = html[:maxLength] + "...\n[Content truncated due to length]"
+		return "", "", err
 	}
 
-	return html
+	// Convert the body to text based on its content type
+	content, err := extractContent(body, resp.Header.Get("Content-Type"), resp.Request.URL)
+	return content, resp.Header.Get("X-Kiwi-Cache"), err
 }
 
 // RequiresConfirmation returns whether this tool requires confirmation before execution
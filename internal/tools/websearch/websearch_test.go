@@ -0,0 +1,563 @@
+package websearch
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saurabh0719/kiwi/internal/httpcache"
+	"github.com/saurabh0719/kiwi/internal/tools/core"
+	"github.com/saurabh0719/kiwi/internal/tools/egress"
+)
+
+// TestMain lets the tests reach the local fixture servers, which the default egress policy blocks
+func TestMain(m *testing.M) {
+	egress.SetCurrent(egress.New(nil, nil, true, 0))
+	os.Exit(m.Run())
+}
+
+// serveFixture starts a server that answers every request with a file from testdata
+// and records the last request it received
+func serveFixture(t *testing.T, name, contentType string, last **http.Request) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if last != nil {
+			*last = r
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearchBackends(t *testing.T) {
+	var last *http.Request
+	want := []string{"https://pkg.go.dev/context", "https://go.dev/blog/context"}
+
+	tests := []struct {
+		backend     string
+		fixture     string
+		contentType string
+		apiKey      string
+		check       func(r *http.Request) string
+	}{
+		{"searxng", "searxng.json", "application/json", "", func(r *http.Request) string {
+			if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+				return "expected a JSON request to /search, got " + r.URL.String()
+			}
+			return ""
+		}},
+		{"duckduckgo", "duckduckgo.html", "text/html", "", nil},
+		{"brave", "brave.json", "application/json", "brave-key", func(r *http.Request) string {
+			if r.Header.Get("X-Subscription-Token") != "brave-key" {
+				return "expected the API key in X-Subscription-Token"
+			}
+			return ""
+		}},
+		{"bing", "bing.json", "application/json", "bing-key", func(r *http.Request) string {
+			if r.Header.Get("Ocp-Apim-Subscription-Key") != "bing-key" {
+				return "expected the API key in Ocp-Apim-Subscription-Key"
+			}
+			return ""
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			server := serveFixture(t, tt.fixture, tt.contentType, &last)
+
+			backend, err := NewBackend(tt.backend, server.URL, tt.apiKey, server.Client())
+			if err != nil {
+				t.Fatalf("NewBackend(%s) failed: %v", tt.backend, err)
+			}
+
+			results, err := backend.Search(context.Background(), "golang context package", 5)
+			if err != nil {
+				t.Fatalf("Search() failed: %v", err)
+			}
+			if last.URL.Query().Get("q") != "golang context package" {
+				t.Errorf("query not sent, got %s", last.URL.String())
+			}
+			if tt.check != nil {
+				if msg := tt.check(last); msg != "" {
+					t.Error(msg)
+				}
+			}
+
+			if len(results) < len(want) {
+				t.Fatalf("Search() returned %d results, want at least %d: %+v", len(results), len(want), results)
+			}
+			for i, u := range want {
+				if results[i].URL != u {
+					t.Errorf("result %d URL = %q, want %q", i+1, results[i].URL, u)
+				}
+				if results[i].Title == "" || results[i].Snippet == "" {
+					t.Errorf("result %d should have a title and snippet: %+v", i+1, results[i])
+				}
+				if strings.ContainsAny(results[i].Title+results[i].Snippet, "<>") {
+					t.Errorf("result %d should not contain markup: %+v", i+1, results[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDuckDuckGoSkipsAds(t *testing.T) {
+	server := serveFixture(t, "duckduckgo.html", "text/html", nil)
+	backend, _ := NewBackend("duckduckgo", server.URL, "", server.Client())
+
+	results, err := backend.Search(context.Background(), "golang context package", 10)
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Search() returned %d results, want 3 organic results: %+v", len(results), results)
+	}
+	if !strings.Contains(results[0].Snippet, "across API boundaries & between processes") {
+		t.Errorf("entities should be decoded in snippets, got %q", results[0].Snippet)
+	}
+}
+
+func TestNewBackendValidation(t *testing.T) {
+	for _, name := range []string{"searxng", "brave", "bing", "altavista"} {
+		if _, err := NewBackend(name, "", "", nil); err == nil {
+			t.Errorf("NewBackend(%s) without URL or API key should fail", name)
+		}
+	}
+}
+
+func TestSearchMethod(t *testing.T) {
+	server := serveFixture(t, "searxng.json", "application/json", nil)
+	backend, _ := NewBackend("searxng", server.URL, "", server.Client())
+
+	tool := New()
+	tool.SetBackend(backend)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"method":      "search",
+		"query":       "golang context package",
+		"max_results": float64(1),
+	})
+	if err != nil {
+		t.Fatalf("Execute(search) failed: %v", err)
+	}
+	if result.ToolMethod != "search" {
+		t.Errorf("Execute(search) toolMethod = %q, want search", result.ToolMethod)
+	}
+	if !strings.Contains(result.Output, "1. context package - context - Go Packages\n   https://pkg.go.dev/context") {
+		t.Errorf("Execute(search) unexpected output:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "2. ") {
+		t.Errorf("Execute(search) should honor max_results:\n%s", result.Output)
+	}
+}
+
+func TestExtractMarkdown(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "article.html"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	base, _ := url.Parse("https://notes.example.com/posts/contexts/")
+
+	title, content := extractMarkdown(string(data), base)
+	if title != "Understanding Contexts — Go Notes" {
+		t.Errorf("title = %q", title)
+	}
+
+	wants := []string{
+		"# Understanding Contexts\n",
+		"A `context.Context` carries deadlines, cancellation signals & request-scoped values across API boundaries…",
+		"[package docs](https://notes.example.com/posts/docs/context.html)",
+		"[the Go blog](https://go.dev/blog/context)",
+		"## Creating a context",
+		"Use **WithTimeout** to bound *how long* an operation may take — it's the most common case.",
+		"```go\nctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)\ndefer cancel()\n\nif err := doWork(ctx); err != nil {\n\treturn err\n}\n```",
+		"1. Pass the context as the first argument\n2. Never store contexts in structs\n   - Except for request-scoped helpers\n3. Call `cancel` to release resources",
+		"| Function | Returns |\n| --- | --- |\n| `WithCancel` | ctx, cancel |\n| `WithValue` | ctx \\| value |",
+		"> Contexts should not be stored inside a struct type.",
+	}
+	for _, want := range wants {
+		if !strings.Contains(content, want) {
+			t.Errorf("content should contain %q, got:\n%s", want, content)
+		}
+	}
+
+	for _, unwanted := range []string{"analytics", "font-family", "Popular posts", "Privacy", "Share on Twitter", "About"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("content should not contain page chrome %q, got:\n%s", unwanted, content)
+		}
+	}
+}
+
+func TestExtractMarkdownWithoutArticle(t *testing.T) {
+	page := `<html><body>
+		<div id="menu"><a href="/a">Home</a> <a href="/b">Blog</a> <a href="/c">Contact</a></div>
+		<div class="links"><p><a href="/1">A list of links that is long enough to be scored</a></p></div>
+		<div class="content">
+			<p>The first paragraph of the documentation, with enough text, commas, and detail to be picked.</p>
+			<p>The second paragraph explains the configuration options in some more detail, as docs do.</p>
+		</div>
+	</body></html>`
+
+	_, content := extractMarkdown(page, nil)
+	if !strings.HasPrefix(content, "The first paragraph") || !strings.Contains(content, "The second paragraph") {
+		t.Errorf("expected the content div, got:\n%s", content)
+	}
+	if strings.Contains(content, "Home") || strings.Contains(content, "A list of links") {
+		t.Errorf("navigation should be dropped, got:\n%s", content)
+	}
+}
+
+func TestVisitHTML(t *testing.T) {
+	server := serveFixture(t, "article.html", "text/html; charset=utf-8", nil)
+
+	result, err := New().Execute(context.Background(), map[string]interface{}{
+		"method": "visit",
+		"query":  server.URL + "/posts/contexts/",
+	})
+	if err != nil {
+		t.Fatalf("Execute(visit) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "# Understanding Contexts — Go Notes") {
+		t.Errorf("Execute(visit) should start with the title, got:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "[package docs]("+server.URL+"/posts/docs/context.html)") {
+		t.Errorf("Execute(visit) should resolve relative links, got:\n%s", result.Output)
+	}
+}
+
+func TestVisitPagination(t *testing.T) {
+	var doc strings.Builder
+	for i := 1; i <= 600; i++ {
+		fmt.Fprintf(&doc, "Line %d of the reference documentation for the configuration file.\n", i)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(doc.String()))
+	}))
+	defer server.Close()
+
+	tool := New()
+	visit := func(page int) (core.ToolExecutionResult, error) {
+		return tool.Execute(context.Background(), map[string]interface{}{
+			"method": "visit",
+			"query":  server.URL,
+			"page":   float64(page),
+		})
+	}
+
+	first, err := visit(1)
+	if err != nil {
+		t.Fatalf("Execute(visit) failed: %v", err)
+	}
+	if !strings.Contains(first.Output, "(page 1 of 6)") || !strings.Contains(first.Output, "with page 2 to continue") {
+		t.Errorf("first page should point to the next one, got tail:\n%s", first.Output[len(first.Output)-200:])
+	}
+	if !strings.HasSuffix(strings.Split(first.Output, "\n\n[Page")[0], "configuration file.") {
+		t.Error("pages should end at a line boundary")
+	}
+
+	second, err := visit(2)
+	if err != nil {
+		t.Fatalf("Execute(visit, page 2) failed: %v", err)
+	}
+	if !strings.Contains(second.Output, "(page 2 of 6)") || !strings.Contains(second.Output, "Line 120 of") {
+		t.Errorf("unexpected second page:\n%s", second.Output[:200])
+	}
+	if strings.Contains(second.Output, "Line 1 of") {
+		t.Error("second page should not repeat the first")
+	}
+
+	last, err := visit(6)
+	if err != nil {
+		t.Fatalf("Execute(visit, page 6) failed: %v", err)
+	}
+	if !strings.Contains(last.Output, "Line 600 of") || strings.Contains(last.Output, "to continue reading") {
+		t.Errorf("last page should end the document, got tail:\n%s", last.Output[len(last.Output)-200:])
+	}
+
+	if _, err := visit(7); err == nil {
+		t.Error("Execute(visit) beyond the last page should fail")
+	}
+	if requests != 1 {
+		t.Errorf("the page should be fetched once for the session, got %d requests", requests)
+	}
+
+	found, err := tool.Execute(context.Background(), map[string]interface{}{
+		"method": "find",
+		"query":  "LINE 450 OF",
+	})
+	if err != nil {
+		t.Fatalf("Execute(find) failed: %v", err)
+	}
+	if !strings.Contains(found.Output, "Found 1 match for") || !strings.Contains(found.Output, "(page 4)") ||
+		!strings.Contains(found.Output, "> Line 450 of") || !strings.Contains(found.Output, "  Line 449 of") {
+		t.Errorf("unexpected find output:\n%s", found.Output)
+	}
+
+	many, _ := tool.Execute(context.Background(), map[string]interface{}{
+		"method": "find",
+		"query":  "documentation",
+		"url":    server.URL,
+	})
+	if !strings.Contains(many.Output, "Found 600 matches") || !strings.Contains(many.Output, "showing the first 20") {
+		t.Errorf("find should cap the number of matches:\n%s", many.Output[:200])
+	}
+	if requests != 1 {
+		t.Errorf("find should use the cached page, got %d requests", requests)
+	}
+}
+
+func TestFindWithoutVisit(t *testing.T) {
+	_, err := New().Execute(context.Background(), map[string]interface{}{
+		"method": "find",
+		"query":  "anything",
+	})
+	if err == nil {
+		t.Error("Execute(find) before any visit should fail")
+	}
+}
+
+func TestExtractContentTypes(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		contentType string
+		wants       []string
+		unwanted    []string
+	}{
+		{"api.json", "application/json; charset=utf-8", []string{
+			"{\n  \"name\": \"kiwi\",\n  \"version\": \"2.1.0\",",
+			"... (500 more characters)",
+			"    {\n      \"path\": \"/v1/items/49\",\n      \"method\": \"GET\"\n    }\n    ... 10 more items\n  ],",
+			"\"deprecated\": false,\n  \"rate_limit\": null\n}",
+		}, []string{"/v1/items/50"}},
+		{"feed.xml", "application/rss+xml", []string{
+			"# Go Notes\n\nShort notes on Go & tooling\n\n2 entries:",
+			"1. [Understanding Contexts](https://notes.example.com/posts/contexts/)\n   Mon, 02 Jun 2025 09:00:00 GMT\n   How context.Context carries deadlines & cancellation.",
+			"2. [Table-driven tests](https://notes.example.com/posts/table-tests/)",
+		}, []string{"<p>", "feed.xml"}},
+		{"atom.xml", "application/atom+xml", []string{
+			"# Release Feed\n\nTagged releases\n\n1 entry:",
+			"1. [v2.1.0](https://example.com/releases/v2.1.0)\n   2025-06-01T12:00:00Z\n   Faster scheduler and a new config format.",
+		}, nil},
+		{"readme.md", "text/markdown", []string{
+			"# kiwi\n\nA terminal assistant.\n\n## Install\n\n```bash\ngo install github.com/example/kiwi@latest\n```",
+		}, nil},
+		{"notes.pdf", "application/pdf", []string{
+			"Release Notes (v2.1)\nThe new scheduler reduces latency – by 40%.\nConfiguration moved to config.yaml\nCafé mode is enabled by default.",
+			"Unijk\nPDF✓",
+		}, nil},
+		// Raw files are often served without a useful type
+		{"notes.pdf", "application/octet-stream", []string{"Release Notes (v2.1)"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture+" "+tt.contentType, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatalf("Failed to read fixture: %v", err)
+			}
+
+			content, err := extractContent(data, tt.contentType, nil)
+			if err != nil {
+				t.Fatalf("extractContent() failed: %v", err)
+			}
+			for _, want := range tt.wants {
+				if !strings.Contains(content, want) {
+					t.Errorf("content should contain %q, got:\n%s", want, content)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(content, unwanted) {
+					t.Errorf("content should not contain %q, got:\n%s", unwanted, content)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractContentErrors(t *testing.T) {
+	if _, err := extractContent([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}, "image/png", nil); err == nil {
+		t.Error("images should be rejected")
+	}
+	if _, err := extractContent([]byte("%PDF-1.4\n%%EOF\n"), "application/pdf", nil); err == nil {
+		t.Error("a PDF without text should be reported")
+	}
+	if content, err := extractContent([]byte(`{"broken": `), "application/json", nil); err != nil || content != `{"broken":` {
+		t.Errorf("invalid JSON should be returned as is, got %q, %v", content, err)
+	}
+}
+
+func TestInflateLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(bytes.Repeat([]byte("BT (x) Tj ET\n"), 10000))
+	w.Close()
+
+	if decoded, err := inflate(compressed.Bytes(), 1<<20); err != nil || len(decoded) != 130000 {
+		t.Errorf("inflate within the limit = %d bytes, %v", len(decoded), err)
+	}
+	// A stream expanding past the limit is dropped instead of being read to the end
+	if _, err := inflate(compressed.Bytes(), 1000); err == nil {
+		t.Error("inflate should fail past the limit")
+	}
+	// Truncated streams keep what was decoded
+	if decoded, err := inflate(compressed.Bytes()[:compressed.Len()-8], 1<<20); err != nil || len(decoded) == 0 {
+		t.Errorf("truncated stream = %d bytes, %v", len(decoded), err)
+	}
+}
+
+func TestVisitErrorStatus(t *testing.T) {
+	status := http.StatusTooManyRequests
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "status %d", status)
+	}))
+	defer server.Close()
+
+	tool := New()
+	visit := map[string]interface{}{"method": "visit", "query": server.URL}
+	if _, err := tool.Execute(context.Background(), visit); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("visit should fail on an error status, got %v", err)
+	}
+
+	// The error page was not kept, so the next visit fetches the page again
+	status = http.StatusOK
+	result, err := tool.Execute(context.Background(), visit)
+	if err != nil || !strings.Contains(result.Output, "status 200") {
+		t.Errorf("visit after the error should fetch the page again, got %v:\n%s", err, result.Output)
+	}
+}
+
+func TestVisitJSON(t *testing.T) {
+	server := serveFixture(t, "api.json", "application/json", nil)
+
+	result, err := New().Execute(context.Background(), map[string]interface{}{
+		"method": "visit",
+		"query":  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("Execute(visit) failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "\"name\": \"kiwi\"") {
+		t.Errorf("Execute(visit) should pretty-print JSON, got:\n%s", result.Output)
+	}
+}
+
+func TestVisitCache(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		w.Header().Set("Content-Type", "text/plain")
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=3600")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		fmt.Fprintf(w, "content of %s", r.URL.Path)
+	}))
+	t.Cleanup(server.Close)
+
+	cache := httpcache.NewAt(t.TempDir())
+
+	// Each visit uses a new tool so that only the HTTP cache is shared between them
+	visit := func(path string) (core.ToolExecutionResult, error) {
+		tool := New()
+		tool.SetCache(cache)
+		return tool.Execute(context.Background(), map[string]interface{}{
+			"method": "visit",
+			"query":  server.URL + path,
+		})
+	}
+	servedFrom := func(result core.ToolExecutionResult, status string) bool {
+		for _, step := range result.ToolExecutionSteps {
+			if strings.Contains(step, "Served from the HTTP cache ("+status+")") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, path := range []string{"/fresh", "/etag", "/fresh", "/etag"} {
+		if _, err := visit(path); err != nil {
+			t.Fatalf("visit(%s) failed: %v", path, err)
+		}
+	}
+	if requests["/fresh"] != 1 {
+		t.Errorf("a fresh page should be served from the cache, got %d requests", requests["/fresh"])
+	}
+	if requests["/etag"] != 2 {
+		t.Errorf("a page marked no-cache should be revalidated, got %d requests", requests["/etag"])
+	}
+
+	result, err := visit("/etag")
+	if err != nil || !servedFrom(result, "revalidated") || !strings.Contains(result.Output, "content of /etag") {
+		t.Errorf("a 304 should serve the cached body, got %v, %v:\n%s", result.ToolExecutionSteps, err, result.Output)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil || stats.Entries != 2 || stats.Fresh != 1 {
+		t.Errorf("Stats() = %+v, %v, want 2 entries with 1 fresh", stats, err)
+	}
+
+	// A TTL overrides the headers of the responses stored after it is set
+	cache.SetTTL(time.Hour)
+	visit("/etag")
+	requestsBefore := requests["/etag"]
+	result, err = visit("/etag")
+	if err != nil || !servedFrom(result, "hit") || requests["/etag"] != requestsBefore {
+		t.Errorf("a TTL should make a no-cache page fresh, got %v, %v", result.ToolExecutionSteps, err)
+	}
+	cache.SetTTL(0)
+
+	// Offline mode serves cached pages without the network and fails for the rest
+	cache.SetOffline(true)
+	server.Close()
+	result, err = visit("/fresh")
+	if err != nil || !servedFrom(result, "offline") || !strings.Contains(result.Output, "content of /fresh") {
+		t.Errorf("offline mode should serve cached pages, got %v, %v", result.ToolExecutionSteps, err)
+	}
+	if _, err := visit("/missing"); err == nil || !strings.Contains(err.Error(), "not in the cache") {
+		t.Errorf("offline mode should fail for pages that aren't cached, got %v", err)
+	}
+
+	// Cached pages of a host denied since are not served
+	previous := egress.Current()
+	egress.SetCurrent(egress.New(nil, []string{"127.0.0.1"}, true, 0))
+	if _, err := visit("/fresh"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("a denied host should not be served from the cache, got %v", err)
+	}
+	egress.SetCurrent(previous)
+
+	if count, err := cache.Clear(); err != nil || count != 2 {
+		t.Errorf("Clear() = %d, %v, want 2", count, err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 {
+		t.Errorf("the cache should be empty after Clear(), got %d entries", stats.Entries)
+	}
+}
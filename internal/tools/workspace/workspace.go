@@ -0,0 +1,219 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Access is the kind of access a tool needs to a path
+type Access int
+
+const (
+	// Read access is enough to list, read and search files
+	Read Access = iota
+	// Write access is needed to create, modify or delete files
+	Write
+)
+
+// DefaultDeny lists the paths no tool may touch unless the configuration says otherwise.
+// Entries without a slash match a file or directory name at any depth and may use glob
+// patterns, entries starting with ~/ or / match that path and everything below it.
+var DefaultDeny = []string{".git", ".env", ".env.*", "~/.ssh"}
+
+// Policy decides which paths tools are allowed to read and write
+type Policy struct {
+	readWrite []string
+	readOnly  []string
+	denyNames []string
+	denyPaths []string
+}
+
+var (
+	currentPolicy = Default()
+	policyMutex   sync.RWMutex
+)
+
+// New creates a policy from lists of read-write roots, read-only roots and denied paths.
+// Relative roots are resolved against the working directory. When no read-write roots
+// are given, the working directory and the system temp directory are used.
+func New(readWrite, readOnly, deny []string) *Policy {
+	p := &Policy{}
+
+	if len(readWrite) == 0 {
+		readWrite = []string{".", os.TempDir()}
+	}
+	for _, root := range readWrite {
+		if resolved, err := resolve(expandHome(root)); err == nil {
+			p.readWrite = append(p.readWrite, resolved)
+		}
+	}
+	for _, root := range readOnly {
+		if resolved, err := resolve(expandHome(root)); err == nil {
+			p.readOnly = append(p.readOnly, resolved)
+		}
+	}
+
+	for _, entry := range deny {
+		entry = strings.TrimSuffix(filepath.Clean(entry), string(filepath.Separator))
+		switch {
+		case entry == "" || entry == ".":
+		case strings.HasPrefix(entry, "~") || filepath.IsAbs(entry) || strings.ContainsRune(entry, filepath.Separator):
+			if resolved, err := resolve(expandHome(entry)); err == nil {
+				p.denyPaths = append(p.denyPaths, resolved)
+			}
+		default:
+			p.denyNames = append(p.denyNames, entry)
+		}
+	}
+
+	return p
+}
+
+// Default returns the policy used when nothing is configured
+func Default() *Policy {
+	return New(nil, nil, DefaultDeny)
+}
+
+// Current returns the policy shared by all tools
+func Current() *Policy {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	return currentPolicy
+}
+
+// SetCurrent replaces the policy shared by all tools
+func SetCurrent(p *Policy) {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	currentPolicy = p
+}
+
+// Check validates that path may be accessed and returns it with all symlinks resolved.
+// Checks run on the resolved path, so a symlink inside the workspace cannot be used
+// to reach files outside of it.
+func (p *Policy) Check(path string, access Access) (string, error) {
+	resolved, err := resolve(path)
+	if err != nil {
+		return "", fmt.Errorf("path is not safe: %s: %w", path, err)
+	}
+
+	if pattern, denied := p.denied(resolved); denied {
+		return "", fmt.Errorf("path is not safe: %s is denied by the workspace policy (%s)", path, pattern)
+	}
+
+	for _, root := range p.readWrite {
+		if within(resolved, root) {
+			return resolved, nil
+		}
+	}
+
+	for _, root := range p.readOnly {
+		if within(resolved, root) {
+			if access == Write {
+				return "", fmt.Errorf("path is not safe: %s is in a read-only workspace root", path)
+			}
+			return resolved, nil
+		}
+	}
+
+	return "", fmt.Errorf("path is not safe: %s is outside the workspace", path)
+}
+
+// Allowed reports whether path may be accessed
+func (p *Policy) Allowed(path string, access Access) bool {
+	_, err := p.Check(path, access)
+	return err == nil
+}
+
+// IsDenied reports whether path matches the denylist without resolving symlinks.
+// It is cheap enough to call for every entry of a directory walk.
+func (p *Policy) IsDenied(path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	_, denied := p.denied(absPath)
+	return denied
+}
+
+// Roots returns the read-write and read-only roots of the policy
+func (p *Policy) Roots() (readWrite, readOnly []string) {
+	return append([]string(nil), p.readWrite...), append([]string(nil), p.readOnly...)
+}
+
+// denied returns the denylist entry matching an absolute path
+func (p *Policy) denied(path string) (string, bool) {
+	for _, denyPath := range p.denyPaths {
+		if within(path, denyPath) {
+			return denyPath, true
+		}
+	}
+
+	if len(p.denyNames) > 0 {
+		for _, part := range strings.Split(path, string(filepath.Separator)) {
+			for _, name := range p.denyNames {
+				if matched, _ := filepath.Match(name, part); matched {
+					return name, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// resolve returns the absolute path with all symlinks resolved. Paths that don't exist
+// yet are resolved through their closest existing parent, so a file about to be created
+// is checked against the directory it will really end up in.
+func resolve(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	current := absPath
+	for {
+		resolved, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return absPath, nil
+		}
+		missing = append(missing, filepath.Base(current))
+		current = parent
+	}
+}
+
+// within reports whether path is root or below it
+func within(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel))
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
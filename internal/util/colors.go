@@ -44,4 +44,16 @@ var (
 
 	// HighlightColor is used for highlighting command examples
 	HighlightColor = color.New(color.FgHiWhite)
+
+	// DiffAddColor is used for added lines in diffs
+	DiffAddColor = color.New(color.FgGreen)
+
+	// DiffRemoveColor is used for removed lines in diffs
+	DiffRemoveColor = color.New(color.FgRed)
+
+	// DiffHunkColor is used for hunk headers in diffs
+	DiffHunkColor = color.New(color.FgCyan)
+
+	// DiffHeaderColor is used for file headers in diffs
+	DiffHeaderColor = color.New(color.Bold)
 )
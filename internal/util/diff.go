@@ -0,0 +1,240 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// maxDiffEdits bounds the work done by the diff algorithm; beyond it the
+// remaining lines are reported as a single replacement
+const maxDiffEdits = 2000
+
+// diffOp is a single line in an edit script
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for removed, '+' for added
+	text string
+}
+
+// SplitLines splits text into lines without their line endings
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// UnifiedDiff returns a unified diff between the old and new text, or an empty string if they are equal
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(SplitLines(oldText), SplitLines(newText))
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// Find the changed lines and group them into hunks
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	for i := 0; i < len(changes); {
+		start := changes[i] - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		last := changes[i]
+		for i+1 < len(changes) && changes[i+1]-last <= 2*diffContextLines {
+			i++
+			last = changes[i]
+		}
+		i++
+		end := last + diffContextLines + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		// Work out the line numbers where this hunk starts
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldLine--
+		}
+		if newCount == 0 {
+			newLine--
+		}
+
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+	}
+
+	return out.String()
+}
+
+// ColorizeDiff colors the lines of a unified diff for display in the terminal
+func ColorizeDiff(diff string) string {
+	var out strings.Builder
+	for _, line := range SplitLines(diff) {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			out.WriteString(DiffHeaderColor.Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			out.WriteString(DiffHunkColor.Sprint(line))
+		case strings.HasPrefix(line, "+"):
+			out.WriteString(DiffAddColor.Sprint(line))
+		case strings.HasPrefix(line, "-"):
+			out.WriteString(DiffRemoveColor.Sprint(line))
+		default:
+			out.WriteString(line)
+		}
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// diffLines computes a line-based edit script using the Myers algorithm
+func diffLines(a, b []string) []diffOp {
+	// Strip the common prefix and suffix, which keeps the search small for typical edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// myers returns the shortest edit script between a and b
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	// v[k] holds the furthest x reached on diagonal k; trace keeps the
+	// relevant part of v for each round so the path can be recovered
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	found := false
+
+	for d := 0; d <= n+m && d <= maxDiffEdits; d++ {
+		snapshot := make([]int, 2*d+3)
+		copy(snapshot, v[offset-d-1:offset+d+2])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	if !found {
+		// Too many differences to diff precisely, report a full replacement
+		ops := make([]diffOp, 0, n+m)
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// Walk the trace backwards to recover the edit script
+	var reversed []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snapshot := trace[d]
+		get := func(k int) int { return snapshot[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, diffOp{'+', b[y-1]})
+			} else {
+				reversed = append(reversed, diffOp{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}